@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/intothevoid/nayan/pkg/analysis"
+	nchess "github.com/intothevoid/nayan/pkg/chess"
+	"github.com/intothevoid/nayan/pkg/config"
+	"github.com/intothevoid/nayan/pkg/engine"
+	"github.com/notnil/chess"
+)
+
+// runAnalyze prints the post-game analysis of every game in a PGN file:
+// each move's evaluation and grade, both sides' accuracy, the turning
+// points and the engine's line at each mistake.
+func runAnalyze(fs *flag.FlagSet, cfgFlags *config.Flags, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("need one PGN file")
+	}
+	cfg, err := cfgFlags.Load()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	games, err := chess.GamesFromPGN(f)
+	if err != nil {
+		return err
+	}
+
+	eng, err := engine.NewEngine(engineArgs(cfg)...)
+	if err != nil {
+		return err
+	}
+	defer eng.Close()
+	if err := engine.ApplySavedOptions(eng, nil); err != nil {
+		fmt.Fprintf(os.Stderr, "Engine options: %v\n", err)
+	}
+
+	for i, g := range games {
+		if i > 0 {
+			fmt.Println()
+		}
+		title := fmt.Sprintf("Game %d", i+1)
+		if w, b := g.GetTagPair("White"), g.GetTagPair("Black"); w != nil && b != nil {
+			title += fmt.Sprintf(": %s vs %s", w.Value, b.Value)
+		}
+		fmt.Printf("%s (%s, depth %d)\n\n", title, eng.ID(), cfg.Engine.Depth)
+
+		report, err := analysis.AnalyseGame(eng, g, cfg.Engine.Depth, nil)
+		if err != nil {
+			return err
+		}
+		printReport(g, report)
+	}
+	return nil
+}
+
+// printReport prints a report on g as text.
+func printReport(g *chess.Game, report *analysis.Report) {
+	positions := g.Positions()
+	startPly := nchess.StartPly(positions[0])
+	for i, r := range report.Reviews {
+		ply := startPly + i + 1
+		san := chess.AlgebraicNotation{}.Encode(positions[i], r.Move)
+		line := fmt.Sprintf("%-12s %+6.2f  %s", moveNumberPrefix(ply)+san, float64(report.Evals[i+1])/100, r.Class)
+		if r.Class >= analysis.Mistake && len(r.BestLine) > 0 {
+			line += "  best: " + formatLine(positions[i], ply, r.BestLine)
+		}
+		fmt.Println(line)
+	}
+
+	fmt.Printf("\nWhite: %s\nBlack: %s\n", formatSideStats(report.White), formatSideStats(report.Black))
+	if len(report.TurningPoints) > 0 {
+		fmt.Print("Turning points:")
+		for _, i := range report.TurningPoints {
+			san := chess.AlgebraicNotation{}.Encode(positions[i], report.Reviews[i].Move)
+			fmt.Printf("  %s%s (%s)", moveNumberPrefix(startPly+i+1), san, report.Reviews[i].Class)
+		}
+		fmt.Println()
+	}
+	if g.Outcome() != chess.NoOutcome {
+		fmt.Printf("Result: %s (%s)\n", g.Outcome(), g.Method())
+	}
+}
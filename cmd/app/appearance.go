@@ -0,0 +1,57 @@
+package main
+
+import (
+	"github.com/intothevoid/nayan/pkg/ui"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Preference keys for the board appearance.
+const (
+	prefBoardTheme = "boardTheme"
+	prefPieceSet   = "pieceSet"
+)
+
+// savedAppearance returns the board palette and piece set stored in prefs,
+// falling back to the defaults.
+func savedAppearance(prefs fyne.Preferences) (ui.BoardTheme, ui.PieceSet) {
+	return ui.BoardThemeByName(prefs.String(prefBoardTheme)),
+		ui.PieceSetByName(prefs.String(prefPieceSet))
+}
+
+// applyAppearance styles a board with the saved palette and piece set.
+func applyAppearance(board *ui.BoardWidget, prefs fyne.Preferences) {
+	theme, set := savedAppearance(prefs)
+	board.SetTheme(theme)
+	board.SetPieceSet(set)
+}
+
+// newAppearanceControls returns selectors for the board palette and piece
+// set. Choices are saved to prefs and passed to onTheme/onPieceSet.
+func newAppearanceControls(prefs fyne.Preferences, onTheme func(ui.BoardTheme), onPieceSet func(ui.PieceSet)) fyne.CanvasObject {
+	theme, set := savedAppearance(prefs)
+
+	themeNames := make([]string, len(ui.BoardThemes))
+	for i, t := range ui.BoardThemes {
+		themeNames[i] = t.Name
+	}
+	themeSelect := widget.NewSelect(themeNames, func(name string) {
+		prefs.SetString(prefBoardTheme, name)
+		onTheme(ui.BoardThemeByName(name))
+	})
+	themeSelect.SetSelected(theme.Name)
+
+	setNames := make([]string, len(ui.PieceSets))
+	for i, s := range ui.PieceSets {
+		setNames[i] = s.Name
+	}
+	setSelect := widget.NewSelect(setNames, func(name string) {
+		prefs.SetString(prefPieceSet, name)
+		onPieceSet(ui.PieceSetByName(name))
+	})
+	setSelect.SetSelected(set.Name)
+
+	return container.NewGridWithColumns(2, themeSelect, setSelect)
+}
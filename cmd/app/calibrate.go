@@ -0,0 +1,98 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/intothevoid/nayan/pkg/calibration"
+	"github.com/intothevoid/nayan/pkg/camera"
+	"github.com/intothevoid/nayan/pkg/config"
+	"github.com/intothevoid/nayan/pkg/pipeline"
+	"github.com/intothevoid/nayan/pkg/vision"
+	"gocv.io/x/gocv"
+)
+
+// calibrateWarmupFrames are read and dropped before calibrating so the
+// camera's exposure has settled.
+const calibrateWarmupFrames = 30
+
+// runCalibrate finds the board corners in a camera frame, or takes them
+// from -corners, and saves them for the GUI, headless mode and replays.
+func runCalibrate(fs *flag.FlagSet, cfgFlags *config.Flags, args []string) error {
+	cornersFlag := fs.String("corners", "", `corners as "x,y x,y x,y x,y" in camera pixels, instead of detecting the board`)
+	snapshot := fs.String("snapshot", "", "save the frame with the corners drawn to this image, to check them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := cfgFlags.Load()
+	if err != nil {
+		return err
+	}
+	path, err := cfg.CalibrationPath()
+	if err != nil {
+		return err
+	}
+
+	stream, err := camera.NewVideoStreamSize(cfg.Camera.Device, cfg.Camera.Width, cfg.Camera.Height)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+	var frame *gocv.Mat
+	for i := 0; i < calibrateWarmupFrames || frame == nil; i++ {
+		mat, err := stream.ReadRaw()
+		if err != nil {
+			return err
+		}
+		if !mat.Empty() {
+			frame = mat
+		}
+	}
+	pipeline.Mirror(frame)
+
+	var corners []image.Point
+	if *cornersFlag != "" {
+		if corners, err = parseCorners(*cornersFlag); err != nil {
+			return err
+		}
+	} else {
+		edges := vision.Preprocess(*frame)
+		corners = vision.DetectBoard(edges)
+		edges.Close()
+		if len(corners) != 4 {
+			return fmt.Errorf("board not found in camera %d; pass -corners, or calibrate in the GUI", cfg.Camera.Device)
+		}
+	}
+	corners = vision.ReorderPoints(corners)
+
+	c, err := calibration.New(cfg.Camera.Device, corners)
+	if err != nil {
+		return err
+	}
+	if err := c.Save(path); err != nil {
+		return err
+	}
+	fmt.Printf("Corners %v saved to %s\n", corners, path)
+
+	// Show what the pipeline will see with these corners.
+	params := vision.Params(cfg.Vision)
+	warped := params.WarpBoard(*frame, corners)
+	defer warped.Close()
+	occupancy, _ := params.ScanBoardDebug(warped)
+	vision.PrintOccupancy(occupancy)
+
+	if *snapshot != "" {
+		for i, pt := range corners {
+			gocv.Circle(frame, pt, 10, color.RGBA{0, 255, 0, 0}, 3)
+			gocv.PutTextWithParams(frame, cornerNames[i], image.Pt(pt.X+14, pt.Y-6),
+				gocv.FontHersheyDuplex, 0.6, color.RGBA{0, 255, 0, 0}, 2, gocv.LineAA, false)
+		}
+		if !gocv.IMWrite(*snapshot, *frame) {
+			return fmt.Errorf("could not write %s", *snapshot)
+		}
+		fmt.Printf("Snapshot saved to %s\n", *snapshot)
+	}
+	return nil
+}
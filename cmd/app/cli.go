@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/intothevoid/nayan/pkg/config"
+	"github.com/intothevoid/nayan/pkg/headless"
+)
+
+// command is a subcommand of the app.
+type command struct {
+	name    string
+	args    string // synopsis of the arguments after the flags
+	summary string
+	// run parses args with fs, which has the shared config flags
+	// registered, and runs the command.
+	run func(fs *flag.FlagSet, cfgFlags *config.Flags, args []string) error
+}
+
+var commands = []command{
+	{"calibrate", "", "find the board corners in the camera image and save them", runCalibrate},
+	{"play", "", "play against the engine, in the GUI or with -headless", runPlay},
+	{"replay", "session.avi", "run a recorded session through the pipeline and print the game", runReplay},
+	{"analyze", "games.pgn", "analyse the games in a PGN file with the engine", runAnalyze},
+}
+
+func main() {
+	args := os.Args[1:]
+	if len(args) == 0 {
+		args = []string{"play"} // the GUI
+	}
+	if args[0] == "help" || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" {
+		usage()
+		return
+	}
+	for _, c := range commands {
+		if c.name == args[0] {
+			fs, cfgFlags := newFlagSet(c)
+			if err := c.run(fs, cfgFlags, args[1:]); err != nil {
+				if !errors.Is(err, flag.ErrHelp) {
+					fmt.Fprintf(os.Stderr, "%s: %v\n", c.name, err)
+				}
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [flags]\n\nCommands:\n", os.Args[0])
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nWith no command the GUI is opened. Run %s <command> -h for its flags.\n", os.Args[0])
+}
+
+// newFlagSet returns the flag set for a subcommand with the shared config
+// flags (-config, -profile, -device, -engine, -depth) registered.
+func newFlagSet(c command) (*flag.FlagSet, *config.Flags) {
+	fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s [flags] %s\n\n%s.\n\nFlags:\n", os.Args[0], c.name, c.args, c.summary)
+		fs.PrintDefaults()
+	}
+	return fs, config.AddFlags(fs)
+}
+
+// engineArgs returns the arguments for engine.NewEngine: the configured
+// engine path, if any.
+func engineArgs(cfg config.Config) []string {
+	if cfg.Engine.Path == "" {
+		return nil
+	}
+	return []string{cfg.Engine.Path}
+}
+
+// parseCorners parses "x,y x,y x,y x,y" (commas or spaces) into the four
+// corners in the order vision.ReorderPoints gives them.
+func parseCorners(s string) ([]image.Point, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' })
+	if len(fields) != 8 {
+		return nil, fmt.Errorf("corners: want 4 x,y pairs, got %q", s)
+	}
+	var pts []image.Point
+	for i := 0; i < 8; i += 2 {
+		x, err := strconv.Atoi(fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("corners: %w", err)
+		}
+		y, err := strconv.Atoi(fields[i+1])
+		if err != nil {
+			return nil, fmt.Errorf("corners: %w", err)
+		}
+		pts = append(pts, image.Pt(x, y))
+	}
+	return pts, nil
+}
+
+// runPlay opens the GUI, or runs headless with -headless.
+func runPlay(fs *flag.FlagSet, cfgFlags *config.Flags, args []string) error {
+	noDisplay := fs.Bool("headless", false, "run without a display, logging and speaking the game (-black, -log and -listen apply only here)")
+	opts := headless.DefaultOptions()
+	opts.AddFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := cfgFlags.Load()
+	if err != nil {
+		return err
+	}
+
+	if !*noDisplay {
+		return runGUI(cfg, opts.Record)
+	}
+	opts.Config = cfg
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return headless.Run(ctx, opts)
+}
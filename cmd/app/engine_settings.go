@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/intothevoid/nayan/pkg/engine"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// optionField is an editable widget for one engine option.
+type optionField struct {
+	opt     engine.Option
+	obj     fyne.CanvasObject
+	initial string        // value when the dialog opened
+	value   func() string // current value shown in the widget
+	reset   func()        // restore the engine default
+}
+
+// showEngineSettings opens a dialog listing every option the engine
+// advertises, with a widget matching each option's type. Saved values that
+// differ from the engine default are persisted in store and applied to eng.
+// onClosed, if non-nil, runs once the dialog is dismissed.
+func showEngineSettings(window fyne.Window, eng engine.Engine, store *engine.OptionStore, addDebug func(string), onClosed func()) {
+	overrides := store.Overrides(eng.ID())
+
+	form := widget.NewForm()
+	var fields []*optionField
+	for _, opt := range eng.Options() {
+		current := opt.Default
+		if v, ok := overrides[opt.Name]; ok {
+			current = v
+		}
+		if f := newOptionField(opt, current, eng, addDebug); f != nil {
+			f.initial = current
+			fields = append(fields, f)
+			form.Append(opt.Name, f.obj)
+		}
+	}
+
+	var body fyne.CanvasObject = container.NewVScroll(form)
+	if len(fields) == 0 {
+		body = widget.NewLabel("This engine has no configurable options.")
+	}
+
+	resetBtn := widget.NewButton("Reset to Defaults", func() {
+		for _, f := range fields {
+			f.reset()
+		}
+	})
+
+	title := "Engine Settings"
+	if eng.ID() != "" {
+		title += " — " + eng.ID()
+	}
+	content := container.NewBorder(nil, resetBtn, nil, nil, body)
+
+	d := dialog.NewCustomConfirm(title, "Save", "Cancel", content, func(save bool) {
+		if !save {
+			return
+		}
+		newOverrides := map[string]string{}
+		var errs []string
+		for _, f := range fields {
+			v := f.value()
+			if v == f.opt.Default {
+				continue
+			}
+			if err := f.opt.Validate(v); err != nil {
+				errs = append(errs, err.Error())
+				continue
+			}
+			newOverrides[f.opt.Name] = v
+		}
+		if len(errs) > 0 {
+			dialog.ShowError(fmt.Errorf("%s", strings.Join(errs, "\n")), window)
+			return
+		}
+
+		store.SetOverrides(eng.ID(), newOverrides)
+		if err := store.Save(); err != nil {
+			dialog.ShowError(fmt.Errorf("saving engine options: %w", err), window)
+			return
+		}
+
+		// Only send options that changed, including ones reverted to their
+		// default, so the engine doesn't reallocate its hash on every save.
+		applied := map[string]string{}
+		for _, f := range fields {
+			if f.opt.Type != engine.OptionButton && f.value() != f.initial {
+				applied[f.opt.Name] = f.value()
+			}
+		}
+		go func() {
+			if err := eng.ApplyOptions(applied); err != nil {
+				addDebug(fmt.Sprintf("Engine options: %v", err))
+				return
+			}
+			addDebug(fmt.Sprintf("Engine options saved (%d overrides)", len(newOverrides)))
+		}()
+	}, window)
+	if onClosed != nil {
+		d.SetOnClosed(onClosed)
+	}
+	d.Resize(fyne.NewSize(520, 640))
+	d.Show()
+}
+
+// newOptionField creates the editing widget state for an option. Button
+// options have no value: they are shown as a button that fires immediately.
+func newOptionField(opt engine.Option, current string, eng engine.Engine, addDebug func(string)) *optionField {
+	switch opt.Type {
+	case engine.OptionCheck:
+		check := widget.NewCheck("", nil)
+		check.SetChecked(current == "true")
+		return &optionField{
+			opt:   opt,
+			obj:   check,
+			value: func() string { return fmt.Sprint(check.Checked) },
+			reset: func() { check.SetChecked(opt.Default == "true") },
+		}
+	case engine.OptionCombo:
+		sel := widget.NewSelect(opt.Vars, nil)
+		sel.SetSelected(current)
+		return &optionField{
+			opt:   opt,
+			obj:   sel,
+			value: func() string { return sel.Selected },
+			reset: func() { sel.SetSelected(opt.Default) },
+		}
+	case engine.OptionSpin, engine.OptionString:
+		entry := widget.NewEntry()
+		entry.SetText(current)
+		if opt.Type == engine.OptionSpin {
+			entry.SetPlaceHolder(fmt.Sprintf("%d–%d", opt.Min, opt.Max))
+			entry.Validator = opt.Validate
+		}
+		return &optionField{
+			opt:   opt,
+			obj:   entry,
+			value: func() string { return strings.TrimSpace(entry.Text) },
+			reset: func() { entry.SetText(opt.Default) },
+		}
+	case engine.OptionButton:
+		btn := widget.NewButton(opt.Name, func() {
+			go func() {
+				if err := eng.SetOption(opt.Name, ""); err != nil {
+					addDebug(fmt.Sprintf("Engine option %s: %v", opt.Name, err))
+				}
+			}()
+		})
+		return &optionField{
+			opt:   opt,
+			obj:   btn,
+			value: func() string { return opt.Default },
+			reset: func() {},
+		}
+	}
+	return nil
+}
@@ -1,24 +1,34 @@
 package main
 
 import (
-	"bufio"
-	_ "embed"
+	"context"
+	"errors"
 	"fmt"
 	"image"
 	"image/color"
-	"os"
-	"os/exec"
-	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/intothevoid/nayan/pkg/analysis"
+	"github.com/intothevoid/nayan/pkg/audio"
+	"github.com/intothevoid/nayan/pkg/calibration"
 	"github.com/intothevoid/nayan/pkg/camera"
 	nchess "github.com/intothevoid/nayan/pkg/chess"
+	"github.com/intothevoid/nayan/pkg/commentary"
+	"github.com/intothevoid/nayan/pkg/config"
 	"github.com/intothevoid/nayan/pkg/engine"
+	"github.com/intothevoid/nayan/pkg/game"
+	"github.com/intothevoid/nayan/pkg/overlay"
+	"github.com/intothevoid/nayan/pkg/pipeline"
+	"github.com/intothevoid/nayan/pkg/relay"
+	"github.com/intothevoid/nayan/pkg/server"
+	"github.com/intothevoid/nayan/pkg/speech"
 	"github.com/intothevoid/nayan/pkg/ui"
 	"github.com/intothevoid/nayan/pkg/vision"
+	"github.com/intothevoid/nayan/pkg/voice"
 	"github.com/notnil/chess"
 	"gocv.io/x/gocv"
 
@@ -31,10 +41,6 @@ import (
 	"fyne.io/fyne/v2/widget"
 )
 
-const (
-	DEVICE_ID_IPHONE int = 0
-	DEVICE_ID_WEBCAM int = 1
-)
 
 // Manual calibration state machine
 type calibState int
@@ -45,36 +51,31 @@ const (
 	calibDone                        // corners captured, detecting pieces
 )
 
-// Game state machine
-type appState int
-
-const (
-	statePreGame  appState = iota // waiting for user to start a game
-	statePlaying                  // game in progress
-	stateGameOver                 // game finished
-	stateCpuVsCpu                 // CPU vs CPU exhibition
-)
-
-// stabilityThreshold is the number of consecutive frames with the same
-// occupancy diff required before we infer a move. Prevents false detections
-// from hand movement or transient noise.
-const stabilityThreshold = 5
+// analysisDepth is the Stockfish search depth used for the post-game report.
+const analysisDepth = 14
 
 // Corner labels in selection order
 var cornerNames = [4]string{"top-left", "top-right", "bottom-right", "bottom-left"}
 
-// Embedded alert sound (played via afplay from a temp file).
-//
-//go:embed Funk.aiff
-var alertSoundData []byte
-var alertSoundPath string
+// alertPlayer plays the invalid-move alert. Replaced at startup by the
+// backend detected for this platform.
+var alertPlayer audio.Player = audio.Nop{}
 
-// speak state — protected by speakMu so a new utterance kills any in-progress one.
+// speaker is the text-to-speech backend used by speak, chosen at startup
+// and switchable from the voiceover controls.
 var (
-	speakMu  sync.Mutex
-	speakCmd *exec.Cmd
+	speakMu sync.Mutex
+	speaker speech.Speaker = speech.Silent{}
 )
 
+// defaultVoiceLabel is shown in the voice list when the backend cannot list
+// its voices; it selects the backend's default voice.
+const defaultVoiceLabel = "Default"
+
+// prefCommentaryLanguage is the preference key storing the code of the
+// voiceover language.
+const prefCommentaryLanguage = "commentaryLanguage"
+
 // Move label styles — left-aligned for human, right-aligned for CPU.
 // The "active" variants use the primary accent color for the last-updated label.
 var (
@@ -120,19 +121,27 @@ func (l *fixedHeightLayout) Layout(objects []fyne.CanvasObject, size fyne.Size)
 	}
 }
 
-func main() {
-	initAlertSound()
-
-	// 1. Setup the Fyne UI App
-	myApp := app.New()
-	window := myApp.NewWindow("Nayan - OpenCV Chess Companion")
-
-	// 2. Initialize the Camera
-	stream, err := camera.NewVideoStream(DEVICE_ID_WEBCAM)
+// runGUI runs the desktop app with the settings in cfg, recording the
+// camera to record if it is not empty. The settings dialog can change cfg
+// while the app runs.
+func runGUI(cfg config.Config, record string) error {
+	// 1. Initialize the Camera. It stays open with this device and frame
+	// size until the app exits.
+	device := cfg.Camera.Device
+	stream, err := camera.NewVideoStreamSize(device, cfg.Camera.Width, cfg.Camera.Height)
 	if err != nil {
-		panic(fmt.Sprintf("Could not open camera: %v", err))
+		return fmt.Errorf("could not open camera: %w", err)
 	}
 	defer stream.Close()
+	if record != "" {
+		if err := stream.Record(record); err != nil {
+			return err
+		}
+	}
+
+	// 2. Setup the Fyne UI App
+	myApp := app.NewWithID("io.github.intothevoid.nayan")
+	window := myApp.NewWindow("Nayan - OpenCV Chess Companion")
 
 	// 3. Create display widgets
 	mainDisplay := ui.NewVideoDisplay()   // Camera feed (large)
@@ -198,8 +207,15 @@ func main() {
 	debugLabel := widget.NewLabel("")
 	debugLabel.TextStyle = fyne.TextStyle{Monospace: true}
 
+	// api serves the game to other screens when the config enables it;
+	// it is created with the controller below.
+	var api *server.Server
+
 	// Helper to update status label from any goroutine
 	setStatus := func(msg string) {
+		if api != nil {
+			api.SetStatus(msg)
+		}
 		fyne.Do(func() {
 			statusLabel.SetText(msg)
 		})
@@ -242,15 +258,47 @@ func main() {
 	var calibMu sync.Mutex
 	calibMode := calibIdle
 	calibCorners := make([]image.Point, 0, 4)
-	var manualCorners []image.Point // final 4 corners for warping
-	calibDoneFrame := 0             // frame counter for "Calibration complete!" overlay
+	calibDoneFrame := 0 // frame counter for "Calibration complete!" overlay
+
+	// The vision pipeline warps to the calibrated corners and feeds the
+	// game controller (attached below). Corners are saved so a fixed
+	// camera need only be calibrated once.
+	pipe := pipeline.New(nil)
+	pipe.SetParams(vision.Params(cfg.Vision))
+
+	// cfg is replaced by the settings dialog (see applyConfig).
+	var cfgMu sync.Mutex
+	currentConfig := func() config.Config {
+		cfgMu.Lock()
+		defer cfgMu.Unlock()
+		return cfg
+	}
+
+	// loadCalibration restores the corners saved for the profile in c, or
+	// clears them if there are none. calibMu must be held.
+	var calibPath string
+	loadCalibration := func(c config.Config) {
+		pipe.SetCorners(nil)
+		calibMode = calibIdle
+		var err error
+		if calibPath, err = c.CalibrationPath(); err != nil {
+			addDebug(fmt.Sprintf("Calibration will not be saved: %v", err))
+		} else if saved, err := calibration.Load(calibPath); err == nil && saved.Device == device {
+			pipe.SetCorners(saved.Points())
+			calibMode = calibDone
+			addDebug("Loaded saved calibration; recalibrate if the board has moved")
+		} else if err != nil && !errors.Is(err, calibration.ErrNotCalibrated) {
+			addDebug(fmt.Sprintf("Saved calibration: %v", err))
+		}
+	}
+	loadCalibration(cfg)
 
 	// Reusable calibration start function
 	startCalibration := func() {
 		calibMu.Lock()
 		calibMode = calibSelecting
 		calibCorners = calibCorners[:0]
-		manualCorners = nil
+		pipe.SetCorners(nil)
 		calibDoneFrame = 0
 		calibMu.Unlock()
 
@@ -334,74 +382,272 @@ func main() {
 	}
 
 	// ── Game controls ──
+	// Games against the engine are run by the controller, which infers
+	// moves from the camera and reports them as events. The CPU vs CPU
+	// exhibition is driven from here.
+	controller := game.NewController(nil)
+	if cfg.Server.Enabled {
+		api = server.New(controller)
+	}
+	// boardOverlay renders the board for streaming software when the
+	// config enables it; it is started with the server below.
+	var boardOverlay *overlay.Overlay
+	if cfg.Overlay.Enabled() {
+		o, err := overlay.New(cfg.Overlay)
+		if err != nil {
+			return err
+		}
+		o.OnError = func(err error) { addDebug(err.Error()) }
+		boardOverlay = o
+	}
+	// liveRelay rewrites the relay's live.pgn after every move when the
+	// config enables it.
+	var liveRelay *relay.Board
+	if cfg.Relay.Enabled() {
+		rb, err := relay.New(cfg.Relay)
+		if err != nil {
+			return err
+		}
+		rb.OnError = func(err error) { addDebug(err.Error()) }
+		liveRelay = rb
+		addDebug(fmt.Sprintf("Relaying board %d to %s", cfg.Relay.Board, rb.Path()))
+	}
+	pipe.Controller = controller
 	var gameMu sync.Mutex
-	currentState := statePreGame
-	var gameState *nchess.GameState
-	var stockfish *engine.Engine
+	watching := false                 // CPU vs CPU running
+	var watchedGame *nchess.GameState // CPU vs CPU game, kept after it ends until reset
+
+	// Engine option overrides persisted per engine ID
+	optionStorePath, err := engine.DefaultOptionStorePath()
+	if err != nil {
+		addDebug(fmt.Sprintf("Engine options will not be saved: %v", err))
+	}
+	if p, err := audio.Detect(); err != nil {
+		addDebug("No sound player found (install paplay or aplay); alerts are muted")
+	} else {
+		alertPlayer = p
+		addDebug(fmt.Sprintf("Playing sounds with %s", p.Name()))
+	}
 
-	// Stability counter for move detection
-	stableDiffCount := 0
-	var pendingOcc [8][8]bool
+	optionStore, err := engine.LoadOptionStore(optionStorePath)
+	if err != nil {
+		addDebug(fmt.Sprintf("Engine options: %v", err))
+		optionStore = engine.NewOptionStore(optionStorePath)
+	}
 
-	// Settle period: wait 2 seconds after stability before inferring a move
-	settling := false
-	var settleStart time.Time
+	// startEngine launches the UCI engine (or the built-in engine when
+	// Stockfish isn't installed) and applies the user's saved option
+	// overrides for it.
+	var builtinNotice sync.Once
+	startEngine := func() (engine.Engine, error) {
+		eng, err := engine.NewEngine(engineArgs(currentConfig())...)
+		if err != nil {
+			return nil, err
+		}
+		if eng.ID() == engine.BuiltinID {
+			builtinNotice.Do(func() {
+				addDebug("Stockfish not found — using the built-in engine")
+			})
+		}
+		if err := engine.ApplySavedOptions(eng, optionStore); err != nil {
+			addDebug(fmt.Sprintf("Engine options: %v", err))
+		}
+		return eng, nil
+	}
+	var gameReport *analysis.Report // post-game analysis of the shown game, nil until ready
 
-	// Invalid move state
-	invalidMoveActive := false
+	// shownGame returns the game on the board: the CPU vs CPU game if one
+	// has been watched since the last reset, otherwise the controller's.
+	shownGame := func() *nchess.GameState {
+		gameMu.Lock()
+		defer gameMu.Unlock()
+		if watchedGame != nil {
+			return watchedGame
+		}
+		return controller.Game()
+	}
+
+	// Invalid board alert, sounding until the board is corrected
 	var invalidSoundStop chan struct{}
+	stopInvalidAlert := func() {
+		gameMu.Lock()
+		if invalidSoundStop != nil {
+			close(invalidSoundStop)
+			invalidSoundStop = nil
+		}
+		gameMu.Unlock()
+		boardWidget.ClearInvalid()
+	}
 
-	// Track last Stockfish recommendation so we can restore highlights
-	// after an invalid-move correction, and enforce the CPU move.
+	// The engine's recommendation is drawn as an arrow and repeated by
+	// voice until it is played; the controller enforces it.
 	var recMu sync.Mutex
-	var recFromRow, recFromCol, recToRow, recToCol int
-	var recMove *chess.Move
-	recActive := false
 	var recRepeatStop chan struct{} // stops the voiceover repeat goroutine
 
-	storeRecommendation := func(fR, fC, tR, tC int, move *chess.Move) {
-		recMu.Lock()
-		recFromRow, recFromCol, recToRow, recToCol = fR, fC, tR, tC
-		recMove = move
-		recActive = true
-		recMu.Unlock()
-	}
 	clearRecommendation := func() {
+		boardWidget.ClearAnnotations()
 		recMu.Lock()
-		recActive = false
-		recMove = nil
 		if recRepeatStop != nil {
 			close(recRepeatStop)
 			recRepeatStop = nil
 		}
 		recMu.Unlock()
 	}
-	getRecommendedMove := func() *chess.Move {
-		recMu.Lock()
-		m := recMove
-		recMu.Unlock()
-		return m
-	}
+	// restoreRecommendation redraws the recommendation after the board was
+	// corrected or previewed.
 	restoreRecommendation := func() {
-		recMu.Lock()
-		active := recActive
-		fR, fC, tR, tC := recFromRow, recFromCol, recToRow, recToCol
-		recMu.Unlock()
-		if active {
-			boardWidget.HighlightMove(fR, fC, tR, tC)
+		if rec := controller.Recommendation(); rec != nil {
+			arrow := moveArrow(rec, ui.AnnotationGreen)
+			boardWidget.HighlightMove(arrow.FromRow, arrow.FromCol, arrow.ToRow, arrow.ToCol)
+			boardWidget.SetAnnotations([]ui.Arrow{arrow}, nil)
 		}
 	}
 
+	// ── Captured pieces above and below the board ──
+	// Each player's captures are shown on their side of the board, with
+	// the material advantage next to the side that is ahead.
+	capturedTop := ui.NewCapturedPieces()
+	capturedBottom := ui.NewCapturedPieces()
+	var materialMu sync.Mutex
+	var materialGS *nchess.GameState
+	materialPly := -1 // ply shown, -1 = latest
+
+	// showMaterial displays the captures after ply (-1 = latest) of gs, or
+	// clears both rows if gs is nil.
+	showMaterial := func(gs *nchess.GameState, ply int) {
+		materialMu.Lock()
+		materialGS, materialPly = gs, ply
+		materialMu.Unlock()
+
+		if gs == nil {
+			capturedTop.Set(nil, 0)
+			capturedBottom.Set(nil, 0)
+			return
+		}
+		if moves := len(gs.Game().Moves()); ply < 0 || ply > moves {
+			ply = moves
+		}
+		caps := gs.CapturesAt(ply)
+		balance := nchess.MaterialBalance(gs.Game().Positions()[ply])
+
+		toUI := func(pieces []chess.Piece) []ui.PieceType {
+			out := make([]ui.PieceType, len(pieces))
+			for i, p := range pieces {
+				out[i] = ui.PieceFromChess(p)
+			}
+			return out
+		}
+		white, black := toUI(caps.ByWhite), toUI(caps.ByBlack)
+		if boardWidget.Flipped() {
+			capturedTop.Set(white, balance)
+			capturedBottom.Set(black, -balance)
+		} else {
+			capturedTop.Set(black, -balance)
+			capturedBottom.Set(white, balance)
+		}
+	}
+	// refreshMaterial redraws the captures after the board is flipped.
+	refreshMaterial := func() {
+		materialMu.Lock()
+		gs, ply := materialGS, materialPly
+		materialMu.Unlock()
+		showMaterial(gs, ply)
+	}
+
+	// ── Move list beside the board ──
+	// Tapping a move previews that position on the virtual board; "Back to
+	// Live" (or the next detected move) returns to the game.
+	moveList := ui.NewMoveList()
+	liveBtn := widget.NewButtonWithIcon("Back to Live", theme.MediaFastForwardIcon(), nil)
+	liveBtn.Hide()
+
+	// showLive redraws the live position after a preview.
+	showLive := func() {
+		moveList.SetSelected(0)
+		fyne.Do(liveBtn.Hide)
+
+		gs := shownGame()
+		showMaterial(gs, -1)
+		if gs == nil {
+			return
+		}
+		boardWidget.UpdatePieces(ui.PiecesFromChess(gs.PieceGrid()), false)
+		boardWidget.ClearHighlight()
+		boardWidget.ClearAnnotations()
+		boardWidget.ClearCheck()
+		if moves := gs.Game().Moves(); len(moves) > 0 {
+			if kR, kC, inCheck := gs.CheckedKingSquare(moves[len(moves)-1]); inCheck {
+				boardWidget.HighlightCheck(kR, kC)
+			}
+		}
+		restoreRecommendation()
+	}
+	liveBtn.OnTapped = showLive
+
+	// previewPly shows the position after the given ply without touching
+	// the game. The latest ply is the live position.
+	previewPly := func(ply int) {
+		gs := shownGame()
+		if gs == nil {
+			return
+		}
+		positions := gs.Game().Positions()
+		moves := gs.Game().Moves()
+		if ply < 1 || ply >= len(moves) {
+			showLive()
+			return
+		}
+
+		grid := nchess.PieceGridFromPosition(positions[ply])
+		boardWidget.UpdatePieces(ui.PiecesFromChess(grid), false)
+		boardWidget.ClearAnnotations()
+		boardWidget.ClearCheck()
+		m := moves[ply-1]
+		fromRow, fromCol := nchess.RowColFromSquare(m.S1())
+		toRow, toCol := nchess.RowColFromSquare(m.S2())
+		boardWidget.HighlightMove(fromRow, fromCol, toRow, toCol)
+
+		showMaterial(gs, ply)
+		moveList.SetSelected(ply)
+		fyne.Do(liveBtn.Show)
+	}
+	moveList.OnSelected = previewPly
+
+	// syncMoveList refreshes the move list after the game changes (nil
+	// clears it). Any preview is dropped: callers redraw the live board.
+	syncMoveList := func(gs *nchess.GameState) {
+		var sans []string
+		startPly := 0
+		if gs != nil {
+			sans = gs.SANMoves()
+			startPly = gs.StartPly()
+		}
+		moveList.SetMoves(sans, startPly)
+		moveList.SetSelected(0)
+		fyne.Do(liveBtn.Hide)
+		showMaterial(gs, -1)
+	}
+
 	// Difficulty select (1-10), maps to Stockfish depth
 	difficultyOptions := []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10"}
 	difficultySelect := widget.NewSelect(difficultyOptions, nil)
-	difficultySelect.SetSelected("5")
+	difficultySelect.SetSelected(strconv.Itoa(min(max(cfg.Engine.Depth/2, 1), 10)))
+
+	// engineDepth maps the selected difficulty to a Stockfish search depth.
+	engineDepth := func() int {
+		difficulty, _ := strconv.Atoi(difficultySelect.Selected)
+		if difficulty < 1 {
+			difficulty = 5
+		}
+		return difficulty * 2
+	}
 
-	// "Thinking..." label shown during settle period
-	thinkingLabel := widget.NewLabel("")
-	thinkingLabel.TextStyle = fyne.TextStyle{Bold: true, Italic: true}
-	thinkingLabel.Alignment = fyne.TextAlignCenter
-	thinkingLabel.Hidden = true
+	// Board orientation — follows the chosen colour, but can be flipped
+	// manually (e.g. when the camera is mounted on the other side)
+	flipCheck := widget.NewCheck("Flip Board", func(on bool) {
+		boardWidget.SetFlipped(on)
+		refreshMaterial()
+	})
 
 	selectedColor := nchess.White
 	colorRadio := widget.NewRadioGroup([]string{"White", "Black"}, func(value string) {
@@ -410,25 +656,195 @@ func main() {
 		} else {
 			selectedColor = nchess.White
 		}
+		flipCheck.SetChecked(selectedColor == nchess.Black)
 	})
 	colorRadio.SetSelected("White")
 	colorRadio.Horizontal = true
 
-	// Voiceover controls
-	voices := availableVoices()
+	// Voiceover controls. The speech backend is auto-detected; any other
+	// installed backend, or silence, can be chosen instead.
+	var lang func() *commentary.Language // commentary language, set below
 	voiceoverCheck := widget.NewCheck("Voiceover", nil)
-	voiceoverCheck.SetChecked(true) // enabled by default
-	voiceSelect := widget.NewSelect(voices, nil)
-	defaultVoice := voices[0]
-	for _, v := range voices {
-		if v == "Daniel" {
-			defaultVoice = "Daniel"
-			break
+	voiceSelect := widget.NewSelect(nil, nil)
+
+	silent := speech.Silent{Log: func(_, text string) { addDebug("Voiceover (silent): " + text) }}
+	speakers := map[string]speech.Speaker{silent.Name(): silent}
+	var speakerNames []string
+	for _, s := range speech.Available() {
+		speakers[s.Name()] = s
+		speakerNames = append(speakerNames, s.Name())
+	}
+	speakerNames = append(speakerNames, silent.Name())
+	speakerSelect := widget.NewSelect(speakerNames, func(name string) {
+		voices, selected := setSpeaker(speakers[name])
+		voiceSelect.SetOptions(voices)
+		voiceSelect.SetSelected(selected)
+	})
+	speakerSelect.SetSelected(speakerNames[0])
+	if speakerNames[0] == silent.Name() {
+		addDebug("No text-to-speech program found (install espeak-ng or speech-dispatcher); voiceover is silent")
+	} else {
+		addDebug(fmt.Sprintf("Voiceover using %s", speakerNames[0]))
+	}
+
+	// Commentary language. Choosing a voice switches to its language when
+	// there is a translation for it; the choice is remembered.
+	langNames := make([]string, len(commentary.Languages))
+	for i, l := range commentary.Languages {
+		langNames[i] = l.Name
+	}
+	langSelect := widget.NewSelect(langNames, func(string) {
+		myApp.Preferences().SetString(prefCommentaryLanguage, lang().Code)
+	})
+	lang = func() *commentary.Language {
+		if i := langSelect.SelectedIndex(); i >= 0 {
+			return commentary.Languages[i]
 		}
+		return &commentary.English
 	}
-	voiceSelect.SetSelected(defaultVoice)
+	langSelect.SetSelected(commentary.ByCode(myApp.Preferences().StringWithFallback(prefCommentaryLanguage, "en")).Name)
+	voiceSelect.OnChanged = func(name string) {
+		if l, ok := commentary.ForLocale(voiceLanguage(name)); ok {
+			langSelect.SetSelected(l.Name)
+		}
+	}
+
+	// applyVoice shows the configured voiceover settings; a backend or
+	// voice that is not installed leaves the current choice.
+	applyVoice := func(v config.Voice) {
+		voiceoverCheck.SetChecked(v.Enabled)
+		if _, ok := speakers[v.Backend]; ok {
+			speakerSelect.SetSelected(v.Backend)
+		}
+		if slices.Contains(voiceSelect.Options, v.Name) {
+			voiceSelect.SetSelected(v.Name)
+		}
+		if v.Language != "" {
+			langSelect.SetSelected(commentary.ByCode(v.Language).Name)
+		}
+	}
+	applyVoice(cfg.Voice)
 	cpuOnlyCheck := widget.NewCheck("Voiceover CPU Only", nil)
 	cpuOnlyCheck.SetChecked(true) // true by default
+	namedCaptureCheck := widget.NewCheck("Name Captures", nil)
+
+	// describe phrases a move in the commentary language; preMove is true
+	// for moves about to be played.
+	describe := func(move *chess.Move, pos *chess.Position, preMove bool) string {
+		return lang().Describe(move, pos, preMove, commentary.Options{NameCaptured: namedCaptureCheck.Checked})
+	}
+
+	// speakRecommendation announces the engine's move and repeats it every
+	// 10 seconds until the recommendation is cleared.
+	speakRecommendation := func(move *chess.Move, pos *chess.Position) {
+		if !voiceoverCheck.Checked {
+			return
+		}
+		text := describe(move, pos, true)
+		speak(voiceSelect.Selected, text)
+		recMu.Lock()
+		if recRepeatStop != nil {
+			close(recRepeatStop)
+		}
+		stop := make(chan struct{})
+		recRepeatStop = stop
+		recMu.Unlock()
+		go func() {
+			ticker := time.NewTicker(10 * time.Second)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-stop:
+					return
+				case <-ticker.C:
+					if voiceoverCheck.Checked {
+						speak(voiceSelect.Selected, text)
+					}
+				}
+			}
+		}()
+	}
+
+	// Move feedback controls — grade each human move and optionally show
+	// the move Stockfish would have played instead.
+	feedbackCheck := widget.NewCheck("Move Feedback", nil)
+	feedbackCheck.SetChecked(true)
+	suggestCheck := widget.NewCheck("Suggest Better Move", nil)
+	suggestCheck.SetChecked(true)
+
+	// Pondering — let Stockfish think on the human's time about the reply
+	// it expects, so a correct guess is answered instantly.
+	ponderCheck := widget.NewCheck("Ponder", nil)
+	ponderCheck.SetChecked(true)
+
+	// applySettings passes the difficulty, engine toggles and configured
+	// move detection to the controller; they apply from the next move.
+	applySettings := func() {
+		g := currentConfig().Game
+		s := controller.Settings()
+		s.Depth = engineDepth()
+		s.Ponder = ponderCheck.Checked
+		s.Review = feedbackCheck.Checked
+		s.StabilityThreshold = g.StabilityFrames
+		s.SettleTime = g.SettleTime
+		controller.SetSettings(s)
+	}
+	difficultySelect.OnChanged = func(string) { applySettings() }
+	feedbackCheck.OnChanged = func(bool) { applySettings() }
+	ponderCheck.OnChanged = func(bool) { applySettings() }
+	applySettings()
+
+	// applyConfig makes c, saved from the settings dialog, the current
+	// config. Detection, game and voice settings apply at once and the
+	// engine path from the next game; a new profile brings its own
+	// calibration. Camera, server, overlay and relay changes wait for a
+	// restart.
+	applyConfig := func(c config.Config) {
+		cfgMu.Lock()
+		old := cfg
+		cfg = c
+		cfgMu.Unlock()
+
+		pipe.SetParams(vision.Params(c.Vision))
+		difficultySelect.SetSelected(strconv.Itoa(min(max(c.Engine.Depth/2, 1), 10)))
+		applySettings()
+		applyVoice(c.Voice)
+		if c.Profile != old.Profile || c.Camera.Calibration != old.Camera.Calibration {
+			calibMu.Lock()
+			loadCalibration(c)
+			calibrated := calibMode == calibDone
+			calibMu.Unlock()
+			if !calibrated {
+				setStatus("Click CALIBRATE, then click the 4 board corners")
+			}
+		}
+		if c.Camera.Device != old.Camera.Device || c.Camera.Width != old.Camera.Width || c.Camera.Height != old.Camera.Height {
+			addDebug("Camera settings take effect after a restart")
+		}
+		if c.Server != old.Server {
+			addDebug("Server settings take effect after a restart")
+		}
+		if c.Overlay != old.Overlay {
+			addDebug("Overlay settings take effect after a restart")
+		}
+		if c.Relay != old.Relay {
+			addDebug("Relay settings take effect after a restart")
+		}
+		name := c.Profile
+		if name == "" {
+			name = "default"
+		}
+		addDebug(fmt.Sprintf("Settings saved to profile %s", name))
+	}
+
+	reviewLabel := widget.NewLabel("")
+	reviewLabel.TextStyle = fyne.TextStyle{Italic: true}
+	reviewLabel.Wrapping = fyne.TextWrapWord
+	setReviewLabel := func(text string) {
+		fyne.Do(func() {
+			reviewLabel.SetText(text)
+		})
+	}
 
 	// Color name helpers (depend on selectedColor)
 	humanColorName := func() string {
@@ -446,46 +862,92 @@ func main() {
 
 	// resetToPreGame resets game state to post-calibration (pre-game) mode.
 	resetToPreGame := func() {
+		controller.Stop()
 		gameMu.Lock()
-		currentState = statePreGame
-		if stockfish != nil {
-			stockfish.Close()
-			stockfish = nil
-		}
-		gameState = nil
-		stableDiffCount = 0
-		settling = false
-		if invalidMoveActive {
-			close(invalidSoundStop)
-			invalidMoveActive = false
-		}
+		watchedGame = nil
+		gameReport = nil
 		gameMu.Unlock()
 
+		stopInvalidAlert()
 		clearRecommendation()
 		boardWidget.ClearHighlight()
 		boardWidget.ClearCheck()
-		boardWidget.ClearInvalid()
 		boardWidget.UpdatePieces(ui.StartingPosition(), true)
 		resetMoveLabels()
+		syncMoveList(nil)
+		setReviewLabel("")
 		fyne.Do(func() {
 			fenLabel.SetText("FEN: (waiting for game start)")
 		})
 	}
 
+	// showReview reports the grade of a human move played from prePos on
+	// screen, and by voice for inaccuracies or worse.
+	showReview := func(prePos *chess.Position, notation string, review *analysis.MoveReview) {
+		addDebug(fmt.Sprintf("Move review: %s — %s (loss %d cp)", notation, review.Class, review.CPLoss))
+
+		text := fmt.Sprintf("%s: %s", notation, review.Class)
+		spoken := review.Class.String()
+		if review.Class >= analysis.Inaccuracy {
+			text += fmt.Sprintf(" (-%.2f)", float64(review.CPLoss)/100)
+			if suggestCheck.Checked && review.BestMove != nil {
+				best := chess.AlgebraicNotation{}.Encode(prePos, review.BestMove)
+				text += fmt.Sprintf(" — best was %s", best)
+				spoken += ". " + lang().Suggest(review.BestMove, prePos)
+			}
+			if voiceoverCheck.Checked {
+				speak(voiceSelect.Selected, spoken)
+			}
+		}
+		setReviewLabel(text)
+	}
+
+	// analyseFinishedGame runs the post-game analysis on a separate engine
+	// instance (the game engine may be closed by a reset meanwhile) and
+	// opens the report in the move history window.
+	analyseFinishedGame := func(gs *nchess.GameState) {
+		eng, err := startEngine()
+		if err != nil {
+			addDebug(fmt.Sprintf("Post-game analysis unavailable: %v", err))
+			return
+		}
+		defer eng.Close()
+
+		addDebug("Post-game analysis started")
+		report, err := analysis.AnalyseGame(eng, gs.Game(), analysisDepth, func(done, total int) {
+			setStatus(fmt.Sprintf("Analysing game... %d/%d positions", done, total))
+		})
+		if err != nil {
+			addDebug(fmt.Sprintf("Post-game analysis failed: %v", err))
+			setStatus(fmt.Sprintf("Game over: %s", gs.Outcome()))
+			return
+		}
+
+		if shownGame() == gs {
+			gameMu.Lock()
+			gameReport = report
+			gameMu.Unlock()
+		}
+
+		addDebug(fmt.Sprintf("Analysis: White accuracy %.1f%%, Black accuracy %.1f%%",
+			report.White.Accuracy, report.Black.Accuracy))
+		setStatus(fmt.Sprintf("Game over: %s. Analysis ready.", gs.Outcome()))
+		fyne.Do(func() {
+			showMoveHistoryWindow(myApp, gs, report, boardWidget.Flipped())
+		})
+	}
+
 	// Start/Stop Game button — green/success importance
 	startBtn := widget.NewButton("Start Game", nil)
 	startBtn.Importance = widget.SuccessImportance
 
 	// Forward-declare cpuVsCpuBtn so startBtn.OnTapped can reference it
 	var cpuVsCpuBtn *widget.Button
+	var startGame func(setup *nchess.Setup)
 
 	startBtn.OnTapped = func() {
-		gameMu.Lock()
-		state := currentState
-		gameMu.Unlock()
-
 		// If game is in progress, stop it
-		if state == statePlaying {
+		if controller.State() == game.Playing {
 			resetToPreGame()
 			fyne.Do(func() {
 				startBtn.SetText("Start Game")
@@ -495,7 +957,12 @@ func main() {
 			setStatus("Game stopped. Click Start Game to begin a new game.")
 			return
 		}
+		startGame(nil)
+	}
 
+	// startGame begins a game against the engine from setup, or from the
+	// standard starting position if setup is nil.
+	startGame = func(setup *nchess.Setup) {
 		// Check if board is calibrated
 		calibMu.Lock()
 		isCalibrated := calibMode == calibDone
@@ -515,129 +982,511 @@ func main() {
 			return
 		}
 
+		gs := nchess.NewGame(selectedColor)
+		if setup != nil {
+			var err error
+			if gs, err = setup.NewGame(selectedColor); err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+		}
+
 		gameMu.Lock()
-		gameState = nchess.NewGame(selectedColor)
-		currentState = statePlaying
-		stableDiffCount = 0
-		settling = false
-		invalidMoveActive = false
+		watchedGame = nil
+		gameReport = nil
 		gameMu.Unlock()
+		applySettings()
+		controller.Start(gs)
 
+		stopInvalidAlert()
 		clearRecommendation()
 		boardWidget.ClearHighlight()
 		boardWidget.ClearCheck()
-		boardWidget.UpdatePieces(pieceGridToUI(gameState.PieceGrid()), false)
+		boardWidget.UpdatePieces(ui.PiecesFromChess(gs.PieceGrid()), false)
 		resetMoveLabels()
+		syncMoveList(gs)
+		setReviewLabel("")
 		fyne.Do(func() {
-			fenLabel.SetText("FEN: " + gameState.FEN())
+			fenLabel.SetText("FEN: " + gs.FEN())
 			startBtn.SetText("Stop Game")
 			cpuVsCpuBtn.Disable()
 		})
 
-		addDebug(fmt.Sprintf("Game started — playing as %s", colorRadio.Selected))
-		setStatus("Game started! Make your move on the board.")
+		if setup != nil {
+			addDebug(fmt.Sprintf("Game started from %s — playing as %s", gs.FEN(), colorRadio.Selected))
+			setStatus("Game started! Set up the physical board to match, then make your move.")
+		} else {
+			addDebug(fmt.Sprintf("Game started — playing as %s", colorRadio.Selected))
+			setStatus("Game started! Make your move on the board.")
+		}
 
-		// Start Stockfish engine (graceful fallback)
+		// Start Stockfish engine (graceful fallback). The controller asks
+		// it for the first move if the human is Black.
 		go func() {
-			eng, err := engine.NewEngine()
+			eng, err := startEngine()
 			if err != nil {
-				addDebug(fmt.Sprintf("Stockfish not available: %v", err))
+				addDebug(fmt.Sprintf("Engine not available: %v", err))
 				setStatus("Game started (no engine). Make your move.")
 				return
 			}
+			addDebug(fmt.Sprintf("%s engine started", eng.ID()))
+			controller.SetEngine(eng)
+		}()
+	}
+
+	// View Moves button — opens popup with move history navigation
+	viewMovesBtn := widget.NewButton("View Moves", func() {
+		gs := shownGame()
+		gameMu.Lock()
+		report := gameReport
+		gameMu.Unlock()
+
+		if gs == nil {
+			dialog.ShowInformation("No Game", "Start a game first to view moves.", window)
+			return
+		}
+
+		showMoveHistoryWindow(myApp, gs, report, boardWidget.Flipped())
+	})
+
+	// Engine Settings button — edits the options advertised by the engine.
+	// Uses the running game engine if there is one, otherwise starts a
+	// temporary instance just to read its option list.
+	engineSettingsBtn := widget.NewButton("Engine Settings", func() {
+		if eng := controller.Engine(); eng != nil {
+			showEngineSettings(window, eng, optionStore, addDebug, nil)
+			return
+		}
+		go func() {
+			eng, err := startEngine()
+			if err != nil {
+				fyne.Do(func() {
+					dialog.ShowError(fmt.Errorf("engine not available: %w", err), window)
+				})
+				return
+			}
+			fyne.Do(func() {
+				showEngineSettings(window, eng, optionStore, addDebug, func() { eng.Close() })
+			})
+		}()
+	})
+
+	// Settings button — edits the config profiles: camera, detection,
+	// timing, engine and voice.
+	settingsBtn := widget.NewButton("Settings", func() {
+		showSettings(window, currentConfig(), applyConfig)
+	})
+
+	// Position Editor button — sets up an arbitrary position to analyse or
+	// play from, starting with the current game's position if there is one.
+	editorBtn := widget.NewButton("Position Editor", func() {
+		fen := ""
+		if gs := shownGame(); gs != nil {
+			fen = gs.FEN()
+		}
+
+		showPositionEditor(myApp, fen, boardWidget.Flipped(), startEngine, engineDepth, func(setup nchess.Setup) {
 			gameMu.Lock()
-			stockfish = eng
+			running := watching
 			gameMu.Unlock()
-			addDebug("Stockfish engine started")
+			if running {
+				dialog.ShowInformation("CPU vs CPU Running", "Stop the CPU vs CPU game before starting a new one.", window)
+				return
+			}
+			if controller.State() == game.Playing {
+				resetToPreGame()
+				addDebug("Game stopped to play from the position editor")
+			}
+			startGame(&setup)
+		})
+	})
 
-			// If human is Black, query Stockfish for White's first move
+	// finishGame ends a game against the engine: reports the result, starts
+	// the post-game analysis and offers a new game.
+	finishGame := func(gs *nchess.GameState) {
+		outcome := gs.Outcome()
+		addDebug(fmt.Sprintf("Game over: %s", outcome))
+		setStatus(fmt.Sprintf("Game over: %s", outcome))
+		go analyseFinishedGame(gs)
+		fyne.Do(func() {
+			startBtn.SetText("Start Game")
+			cpuVsCpuBtn.Enable()
+			dialog.ShowConfirm("Game Over",
+				outcome+"\n\nWould you like to start a new game?",
+				func(yes bool) {
+					if yes {
+						resetToPreGame()
+					}
+				}, window)
+		})
+	}
+
+	// Game events from the controller: redraw the board, announce moves
+	// and results, and alert on invalid boards. Events arrive on the vision
+	// loop or on engine goroutines.
+	controller.OnEvent = func(ev game.Event) {
+		if api != nil {
+			api.Publish(ev)
+		}
+		if boardOverlay != nil {
+			boardOverlay.Publish(ev)
+		}
+		if liveRelay != nil {
+			liveRelay.Publish(ev)
+		}
+		gs := ev.Game
+		switch ev.Kind {
+		case game.MoveMade:
+			source := "Move detected"
+			if ev.Manual {
+				source = "Move entered"
+			}
+			addDebug(fmt.Sprintf("%s: %s", source, ev.Notation))
+			stopInvalidAlert()
+			boardWidget.UpdatePieces(ui.PiecesFromChess(gs.PieceGrid()), false)
+			boardWidget.ClearHighlight()
+			clearRecommendation()
+			syncMoveList(gs)
+
+			// Check indicator
+			boardWidget.ClearCheck()
+			if kR, kC, inCheck := gs.CheckedKingSquare(ev.Move); inCheck {
+				boardWidget.HighlightCheck(kR, kC)
+			}
+
+			fyne.Do(func() {
+				fenLabel.SetText("FEN: " + gs.FEN())
+			})
+
+			if ev.ByHuman {
+				setHumanMoveLabel(fmt.Sprintf("%s moved %s", humanColorName(), ev.Notation))
+			} else {
+				setCpuMoveLabel(fmt.Sprintf("%s moved %s", cpuColorName(), ev.Notation))
+			}
+
+			// Voiceover for human moves only (CPU moves are announced
+			// earlier when Stockfish recommends them), followed by the
+			// result or a claimable draw in the same utterance so neither
+			// cuts the other off.
+			var spoken []string
+			if ev.ByHuman && !cpuOnlyCheck.Checked {
+				spoken = append(spoken, describe(ev.Move, ev.Position, false))
+			}
+			if gs.IsGameOver() {
+				spoken = append(spoken, lang().Outcome(gs.Game()))
+			} else if ev.Method != chess.NoMethod {
+				spoken = append(spoken, lang().Claimable(ev.Method))
+			}
+			if voiceoverCheck.Checked && len(spoken) > 0 {
+				speak(voiceSelect.Selected, commentary.Join(spoken...))
+			}
+
+		case game.DrawClaimable:
+			addDebug(fmt.Sprintf("Draw can be claimed (%s)", ev.Method))
+			setStatus(fmt.Sprintf("A draw can be claimed (%s)", ev.Method))
+
+		case game.Recommendation:
+			addDebug(fmt.Sprintf("Stockfish recommends: %s (%s)", ev.Notation, ev.Elapsed.Round(time.Millisecond)))
+			arrow := moveArrow(ev.Move, ui.AnnotationGreen)
+			boardWidget.HighlightMove(arrow.FromRow, arrow.FromCol, arrow.ToRow, arrow.ToCol)
+			boardWidget.SetAnnotations([]ui.Arrow{arrow}, nil)
+			setCpuMoveLabel(fmt.Sprintf("%s to move %s", cpuColorName(), ev.Notation))
+			speakRecommendation(ev.Move, ev.Position)
+
+		case game.Pondering:
+			addDebug(fmt.Sprintf("Pondering on %s", ev.Notation))
+
+		case game.MoveReviewed:
+			showReview(ev.Position, ev.Notation, ev.Review)
+
+		case game.Hint:
+			addDebug(fmt.Sprintf("Hint: %s", ev.Notation))
+			setStatus(fmt.Sprintf("Hint: %s", ev.Notation))
+			boardWidget.SetAnnotations([]ui.Arrow{moveArrow(ev.Move, ui.AnnotationBlue)}, nil)
+			if voiceoverCheck.Checked {
+				speak(voiceSelect.Selected, describe(ev.Move, ev.Position, true))
+			}
+
+		case game.InvalidBoard:
+			// Flash the differing squares every time, and sound the
+			// alert until the board is corrected.
 			gameMu.Lock()
-			gs := gameState
-			if gs == nil {
-				gameMu.Unlock()
-				return
+			first := invalidSoundStop == nil
+			if first {
+				invalidSoundStop = make(chan struct{})
+				go invalidMoveAlertLoop(invalidSoundStop, currentConfig().Game.AlertInterval, nil)
 			}
-			isHumanTurn := gs.IsHumanTurn()
 			gameMu.Unlock()
+			if first {
+				addDebug(fmt.Sprintf("Invalid move detected: %v", ev.Err))
+				setStatus("Invalid move! Please correct the board.")
+			}
+			boardWidget.FlashInvalid(ev.Squares)
+			if voiceoverCheck.Checked {
+				speak(voiceSelect.Selected, lang().InvalidMove)
+			}
 
-			if !isHumanTurn {
-				difficulty, _ := strconv.Atoi(difficultySelect.Selected)
-				if difficulty < 1 {
-					difficulty = 5
-				}
-				depth := difficulty * 2
-				speakFn := func(move *chess.Move, pos *chess.Position) {
-					if voiceoverCheck.Checked {
-						colorName := "White"
-						if pos.Turn() == chess.Black {
-							colorName = "Black"
-						}
-						text := moveCommentary(colorName, move, pos, true)
-						speak(voiceSelect.Selected, text)
-						// Repeat the recommendation every 10 seconds
-						recMu.Lock()
-						if recRepeatStop != nil {
-							close(recRepeatStop)
-						}
-						stop := make(chan struct{})
-						recRepeatStop = stop
-						recMu.Unlock()
-						go func() {
-							ticker := time.NewTicker(10 * time.Second)
-							defer ticker.Stop()
-							for {
-								select {
-								case <-stop:
-									return
-								case <-ticker.C:
-									if voiceoverCheck.Checked {
-										speak(voiceSelect.Selected, text)
-									}
-								}
-							}
-						}()
-					}
+		case game.BoardCorrected:
+			stopInvalidAlert()
+			restoreRecommendation()
+			setStatus("Board corrected. Your move.")
+			addDebug("Board matches expected position")
+
+		case game.TakenBack:
+			stopInvalidAlert()
+			clearRecommendation()
+			boardWidget.UpdatePieces(ui.PiecesFromChess(gs.PieceGrid()), false)
+			boardWidget.ClearHighlight()
+			boardWidget.ClearCheck()
+			if moves := gs.Game().Moves(); len(moves) > 0 {
+				if kR, kC, inCheck := gs.CheckedKingSquare(moves[len(moves)-1]); inCheck {
+					boardWidget.HighlightCheck(kR, kC)
 				}
-				queryStockfish(gs, eng, depth, cpuColorName(), setCpuMoveLabel, boardWidget, storeRecommendation, addDebug, speakFn)
 			}
-		}()
+			resetMoveLabels()
+			syncMoveList(gs)
+			setReviewLabel("")
+			fyne.Do(func() {
+				fenLabel.SetText("FEN: " + gs.FEN())
+			})
+			addDebug(fmt.Sprintf("Took back %d moves", ev.Plies))
+			setStatus("Move taken back. Put the pieces back to match the board.")
+
+		case game.BoardRestored:
+			addDebug("Board restored after take back")
+			setStatus("Board restored. Your move.")
+
+		case game.GameOver:
+			// Resignations have no move to announce, so the result is
+			// spoken here rather than with the last move.
+			if gs.Game().Method() == chess.Resignation && voiceoverCheck.Checked {
+				speak(voiceSelect.Selected, lang().Outcome(gs.Game()))
+			}
+			finishGame(gs)
+
+		case game.EngineError:
+			addDebug(fmt.Sprintf("Engine error: %v", ev.Err))
+		}
 	}
 
-	// View Moves button — opens popup with move history navigation
-	viewMovesBtn := widget.NewButton("View Moves", func() {
+	// Manual move entry: dragging a piece on the virtual board overrides
+	// whatever the camera saw. The controller resynchronises detection so
+	// the next frame is compared against the position after the entered
+	// move.
+	boardWidget.OnMove = func(fromRow, fromCol, toRow, toCol int) {
+		gs := controller.Game()
+		if controller.State() != game.Playing || liveBtn.Visible() {
+			return
+		}
+		candidates := gs.MovesBetween(fromRow, fromCol, toRow, toCol)
+		if len(candidates) == 0 {
+			setStatus("Illegal move")
+			return
+		}
+
+		apply := func(m *chess.Move) {
+			setStatus("Move entered. Update the physical board to match.")
+			go func() {
+				if err := controller.EnterMove(m); err != nil {
+					addDebug(fmt.Sprintf("Failed to apply move: %v", err))
+				}
+			}()
+		}
+		if len(candidates) == 1 {
+			apply(candidates[0])
+			return
+		}
+		_, set := savedAppearance(myApp.Preferences())
+		showPromotionPicker(window, candidates, gs.Game().Position().Turn(), set, apply)
+	}
+
+	// ── Game actions, from the buttons or by voice ──
+
+	// hint shows the move the engine would play for the human, with a blue
+	// arrow, and speaks it.
+	hint := func() {
+		err := controller.Hint()
+		switch {
+		case errors.Is(err, game.ErrNotPlaying):
+			setStatus("Start a game to get a hint.")
+		case errors.Is(err, game.ErrNotYourTurn):
+			setStatus("Hints are for your own move.")
+		case errors.Is(err, game.ErrNoEngine):
+			setStatus("Hints need the engine, which is not running.")
+		case err == nil:
+			setStatus("Looking for a hint...")
+		}
+	}
+
+	// takeBack undoes the human's last move, and the engine's reply if it
+	// has been played. Move detection then waits for the physical board to
+	// be put back.
+	takeBack := func() {
+		err := controller.TakeBack()
+		switch {
+		case errors.Is(err, game.ErrNotPlaying):
+			setStatus("No game in progress.")
+		case errors.Is(err, game.ErrEngineThinking):
+			setStatus("Wait for the engine's move before taking back.")
+		case errors.Is(err, game.ErrNoMoves):
+			setStatus("No move to take back.")
+		case err != nil:
+			addDebug(fmt.Sprintf("Take back failed: %v", err))
+		}
+	}
+
+	// resign ends the game with the human resigning.
+	resign := func() {
+		if err := controller.Resign(); err != nil {
+			setStatus("No game in progress.")
+		}
+	}
+
+	// newGame abandons any game against the engine and starts another.
+	newGame := func() {
 		gameMu.Lock()
-		gs := gameState
+		running := watching
 		gameMu.Unlock()
-
-		if gs == nil {
-			dialog.ShowInformation("No Game", "Start a game first to view moves.", window)
+		if running {
+			setStatus("Stop the CPU vs CPU game before starting a new one.")
 			return
 		}
+		if controller.State() != game.Idle {
+			resetToPreGame()
+			addDebug("Game abandoned for a new one")
+		}
+		startGame(nil)
+	}
+
+	// The API starts and stops games as the Start/Stop button does, on
+	// the UI goroutine.
+	if api != nil {
+		api.StartGame = func(human nchess.Color) error {
+			calibMu.Lock()
+			calibrated := calibMode == calibDone
+			calibMu.Unlock()
+			gameMu.Lock()
+			running := watching
+			gameMu.Unlock()
+			switch {
+			case !calibrated:
+				return errors.New("the board is not calibrated")
+			case running:
+				return errors.New("a CPU vs CPU game is running")
+			}
+			fyne.DoAndWait(func() {
+				if human == nchess.Black {
+					colorRadio.SetSelected("Black")
+				} else {
+					colorRadio.SetSelected("White")
+				}
+				if controller.State() != game.Idle {
+					resetToPreGame()
+				}
+				addDebug("Game started through the API")
+				startGame(nil)
+			})
+			return nil
+		}
+		api.StopGame = func() error {
+			fyne.DoAndWait(func() {
+				if controller.State() == game.Playing {
+					startBtn.OnTapped()
+				}
+			})
+			return nil
+		}
+	}
 
-		showMoveHistoryWindow(myApp, gs)
+	hintBtn := widget.NewButtonWithIcon("Hint", theme.HelpIcon(), hint)
+	takeBackBtn := widget.NewButtonWithIcon("Take Back", theme.ContentUndoIcon(), takeBack)
+	resignBtn := widget.NewButtonWithIcon("Resign", theme.CancelIcon(), func() {
+		dialog.ShowConfirm("Resign", "Resign this game?", func(yes bool) {
+			if yes {
+				resign()
+			}
+		}, window)
 	})
 
+	// Voice commands — a recognizer turns speech into text, which is
+	// matched against the command phrases and runs the same actions as
+	// the buttons. New game and resign wait for a spoken "yes", as the
+	// Resign button waits for its dialog.
+	voiceCommandCheck := widget.NewCheck("Voice Commands", nil)
+	var confirmer voice.Confirmer
+	var stopListening context.CancelFunc
+	voiceCommandCheck.OnChanged = func(on bool) {
+		if stopListening != nil {
+			stopListening()
+			stopListening = nil
+		}
+		if !on {
+			addDebug("Voice commands off")
+			return
+		}
+		recognizer, err := voice.Detect()
+		if err != nil {
+			addDebug(fmt.Sprintf("Voice commands unavailable: %v", err))
+			dialog.ShowError(fmt.Errorf("%w\n\nSee the README for setting up voice commands", err), window)
+			voiceCommandCheck.SetChecked(false)
+			return
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		stopListening = cancel
+		addDebug(fmt.Sprintf("Listening for voice commands with %s", recognizer.Name()))
+		go func() {
+			err := voice.Listen(ctx, recognizer, func(c voice.Command, text string) {
+				addDebug(fmt.Sprintf("Heard %q: %s", text, c))
+				if c.NeedsConfirmation() {
+					prompt := fmt.Sprintf("Say yes to confirm %s", c)
+					setStatus(fmt.Sprintf("%s, within %d seconds.", prompt, int(voice.ConfirmTimeout.Seconds())))
+					if voiceoverCheck.Checked {
+						speak(voiceSelect.Selected, prompt)
+					}
+				}
+				c = confirmer.Resolve(c)
+				fyne.Do(func() {
+					switch c {
+					case voice.Hint:
+						hint()
+					case voice.TakeBack:
+						takeBack()
+					case voice.NewGame:
+						newGame()
+					case voice.Resign:
+						resign()
+					}
+				})
+			})
+			if err != nil {
+				addDebug(fmt.Sprintf("Voice commands stopped: %v", err))
+				fyne.Do(func() { voiceCommandCheck.SetChecked(false) })
+			}
+		}()
+	}
+
 	// ── CPU vs CPU mode ──
 	var cpuVsCpuStop chan struct{}
 	cpuVsCpuBtn = widget.NewButton("Watch CPU vs CPU", nil)
 
 	cpuVsCpuBtn.OnTapped = func() {
 		gameMu.Lock()
-		state := currentState
+		running := watching
 		gameMu.Unlock()
 
 		// If CPU vs CPU is running, stop it
-		if state == stateCpuVsCpu {
+		if running {
 			close(cpuVsCpuStop)
 			gameMu.Lock()
-			currentState = statePreGame
-			gameState = nil
+			watching = false
+			watchedGame = nil
 			gameMu.Unlock()
 
 			boardWidget.ClearHighlight()
 			boardWidget.ClearCheck()
 			boardWidget.UpdatePieces(ui.StartingPosition(), true)
 			resetMoveLabels()
+			syncMoveList(nil)
 			fyne.Do(func() {
 				fenLabel.SetText("FEN: (waiting for game start)")
 				cpuVsCpuBtn.SetText("Watch CPU vs CPU")
@@ -652,17 +1501,20 @@ func main() {
 		}
 
 		// Start CPU vs CPU
-		gameMu.Lock()
+		controller.Stop()
 		gs := nchess.NewGame(nchess.White)
-		gameState = gs
-		currentState = stateCpuVsCpu
+		gameMu.Lock()
+		watchedGame = gs
+		watching = true
+		gameReport = nil
 		gameMu.Unlock()
 
 		cpuVsCpuStop = make(chan struct{})
 		boardWidget.ClearHighlight()
 		boardWidget.ClearCheck()
-		boardWidget.UpdatePieces(pieceGridToUI(gs.PieceGrid()), false)
+		boardWidget.UpdatePieces(ui.PiecesFromChess(gs.PieceGrid()), false)
 		resetMoveLabels()
+		syncMoveList(gs)
 
 		fyne.Do(func() {
 			fenLabel.SetText("FEN: " + gs.FEN())
@@ -675,18 +1527,14 @@ func main() {
 		addDebug("CPU vs CPU started")
 		setStatus("CPU vs CPU game in progress...")
 
-		difficulty, _ := strconv.Atoi(difficultySelect.Selected)
-		if difficulty < 1 {
-			difficulty = 5
-		}
-		depth := difficulty * 2
+		depth := engineDepth()
 
 		stop := cpuVsCpuStop
 		go func() {
-			eng, err := engine.NewEngine()
+			eng, err := startEngine()
 			if err != nil {
-				addDebug(fmt.Sprintf("Stockfish not available: %v", err))
-				setStatus("Cannot start CPU vs CPU: Stockfish not found.")
+				addDebug(fmt.Sprintf("Engine not available: %v", err))
+				setStatus("Cannot start CPU vs CPU: engine failed to start.")
 				return
 			}
 			defer eng.Close()
@@ -705,12 +1553,12 @@ func main() {
 				if gs.IsGameOver() {
 					outcome := gs.Outcome()
 					if voiceoverCheck.Checked {
-						speak(voiceSelect.Selected, outcome)
+						speak(voiceSelect.Selected, lang().Outcome(gs.Game()))
 					}
 					addDebug(fmt.Sprintf("CPU vs CPU game over: %s", outcome))
 					setStatus(fmt.Sprintf("CPU vs CPU: %s", outcome))
 					gameMu.Lock()
-					currentState = stateGameOver
+					watching = false
 					gameMu.Unlock()
 					fyne.Do(func() {
 						cpuVsCpuBtn.SetText("Watch CPU vs CPU")
@@ -742,11 +1590,7 @@ func main() {
 
 				// Voiceover — announce before applying the move
 				if voiceoverCheck.Checked {
-					colorName := "White"
-					if !isWhiteTurn {
-						colorName = "Black"
-					}
-					speak(voiceSelect.Selected, moveCommentary(colorName, bestMove, prePos, true))
+					speak(voiceSelect.Selected, describe(bestMove, prePos, true))
 				}
 
 				if applyErr := gs.ApplyMove(bestMove); applyErr != nil {
@@ -757,7 +1601,8 @@ func main() {
 				fromRow, fromCol := nchess.RowColFromSquare(bestMove.S1())
 				toRow, toCol := nchess.RowColFromSquare(bestMove.S2())
 				boardWidget.HighlightMove(fromRow, fromCol, toRow, toCol)
-				boardWidget.UpdatePieces(pieceGridToUI(gs.PieceGrid()), false)
+				boardWidget.UpdatePieces(ui.PiecesFromChess(gs.PieceGrid()), false)
+				syncMoveList(gs)
 
 				// Check indicator
 				boardWidget.ClearCheck()
@@ -779,25 +1624,53 @@ func main() {
 		}()
 	}
 
-	// Button rows — two rows of two buttons each
+	// Button rows
 	buttonRow1 := container.NewGridWithColumns(2, calibrateBtn, startBtn)
 	buttonRow2 := container.NewGridWithColumns(2, viewMovesBtn, cpuVsCpuBtn)
-
-	voiceoverRow := container.NewBorder(nil, nil, voiceoverCheck, cpuOnlyCheck, voiceSelect)
+	buttonRow3 := container.NewGridWithColumns(3, settingsBtn, engineSettingsBtn, editorBtn)
+	buttonRow4 := container.NewGridWithColumns(3, hintBtn, takeBackBtn, resignBtn)
+
+	voiceoverRow := container.NewBorder(nil, nil, voiceoverCheck, container.NewHBox(cpuOnlyCheck, namedCaptureCheck),
+		container.NewGridWithColumns(3, speakerSelect, voiceSelect, langSelect))
+	feedbackRow := container.NewHBox(feedbackCheck, suggestCheck, ponderCheck, voiceCommandCheck)
+
+	// Board palette and piece set, persisted in the app preferences
+	appearanceRow := newAppearanceControls(myApp.Preferences(),
+		func(theme ui.BoardTheme) {
+			boardWidget.SetTheme(theme)
+			if boardOverlay != nil {
+				boardOverlay.SetTheme(theme)
+			}
+		},
+		func(set ui.PieceSet) {
+			boardWidget.SetPieceSet(set)
+			capturedTop.SetPieceSet(set)
+			capturedBottom.SetPieceSet(set)
+			if boardOverlay != nil {
+				boardOverlay.SetPieceSet(set)
+			}
+		})
 
 	gameControls := container.NewVBox(
 		widget.NewRichTextFromMarkdown("**Difficulty:**"),
 		difficultySelect,
 		widget.NewRichTextFromMarkdown("**Play as:**"),
-		colorRadio,
+		container.NewBorder(nil, nil, nil, flipCheck, colorRadio),
+		widget.NewRichTextFromMarkdown("**Board:**"),
+		appearanceRow,
 		voiceoverRow,
+		feedbackRow,
 		buttonRow1,
 		buttonRow2,
+		buttonRow3,
+		buttonRow4,
 	)
 
 	moveStatusRow := container.NewGridWithColumns(2, humanMoveLabel, cpuMoveLabel)
-	analysisPanel := container.NewVBox(moveStatusRow, gameControls, fenLabel)
-	rightPanel := container.NewBorder(thinkingLabel, analysisPanel, nil, nil, boardWidget)
+	analysisPanel := container.NewVBox(moveStatusRow, reviewLabel, gameControls, fenLabel)
+	movePanel := container.NewBorder(widget.NewRichTextFromMarkdown("**Moves:**"), liveBtn, nil, nil, moveList)
+	boardArea := container.NewBorder(capturedTop, capturedBottom, nil, nil, boardWidget)
+	rightPanel := container.NewBorder(nil, analysisPanel, nil, movePanel, boardArea)
 
 	// ── Top area ──
 	topSplit := container.NewHSplit(leftPanel, rightPanel)
@@ -806,8 +1679,6 @@ func main() {
 	// ── Overall layout ──
 	mainLayout := container.NewBorder(nil, fixedStatusBar, nil, nil, topSplit)
 
-	var lastOccupancy [8][8]bool
-
 	setStatus("Waiting for camera...")
 	addDebug("Application started")
 
@@ -830,363 +1701,180 @@ func main() {
 		}
 
 		// All 4 corners collected — finalize calibration
-		manualCorners = vision.ReorderPoints(calibCorners)
+		corners := vision.ReorderPoints(calibCorners)
+		pipe.SetCorners(corners)
 		calibMode = calibDone
 		calibDoneFrame = 0
 		setStatus("Calibration complete! Corners locked.")
 		addDebug("All 4 corners captured, calibration done")
-	}
-
-	// 4. The Background Loop (Goroutine)
-	go func() {
-		frameCount := 0
-		for {
-			mat, err := stream.ReadRaw()
-			if err != nil || mat.Empty() {
-				continue
-			}
-
-			// Mirror the camera feed so it feels natural
-			gocv.Flip(*mat, mat, -1)
-			frameCount++
-
-			if frameCount == 1 {
-				setStatus("Click CALIBRATE, then click the 4 board corners")
-				addDebug("First frame received from camera")
+		if calibPath != "" {
+			saved, err := calibration.New(device, corners)
+			if err == nil {
+				err = saved.Save(calibPath)
 			}
-
-			// Run preprocessing for debug views
-			tempMat := mat.Clone()
-			stages := vision.PreprocessStages(tempMat)
-			tempMat.Close()
-
-			// Update debug views only if enabled
-			toggleMu.Lock()
-			wantGrey := showGrey
-			wantEdges := showEdges
-			wantWarped := showWarped
-			toggleMu.Unlock()
-
-			if wantGrey {
-				greyImg, _ := stages.Grey.ToImage()
-				greyDisplay.UpdateFrame(greyImg)
-			}
-
-			if wantEdges {
-				edgesImg, _ := stages.Edges.ToImage()
-				edgesDisplay.UpdateFrame(edgesImg)
+			if err != nil {
+				addDebug(fmt.Sprintf("Could not save calibration: %v", err))
 			}
+		}
+	}
 
-			// Snapshot calibration state for this frame
+	// 4. The Background Loop (Goroutine): the pipeline detects pieces and
+	// feeds the controller; each frame is then drawn with its overlays.
+	pipe.OnFrame = func(frame *pipeline.Frame) {
+		mat := frame.Camera
+		if api != nil && frame.Detected() {
+			api.SetOccupancy(frame.Occupancy)
+		}
+		if frame.Number == 1 {
 			calibMu.Lock()
-			mode := calibMode
-			cornersCopy := make([]image.Point, len(calibCorners))
-			copy(cornersCopy, calibCorners)
-			var warpCorners []image.Point
-			if manualCorners != nil {
-				warpCorners = make([]image.Point, 4)
-				copy(warpCorners, manualCorners)
-			}
-			doneFrame := calibDoneFrame
-			calibDoneFrame++
+			calibrated := calibMode == calibDone
 			calibMu.Unlock()
+			if calibrated {
+				setStatus("Ready. Click Start Game to play.")
+			} else {
+				setStatus("Click CALIBRATE, then click the 4 board corners")
+			}
+			addDebug("First frame received from camera")
+		}
 
-			// Draw overlay depending on calibration state
-			switch mode {
-			case calibIdle:
-				// Prompt the user to click the Calibrate button
-				text := "Click the Calibrate button to begin..."
-				gocv.PutTextWithParams(mat, text,
-					image.Pt(mat.Cols()/2-250, mat.Rows()/2),
-					gocv.FontHersheyDuplex, 0.7,
-					color.RGBA{255, 255, 255, 0}, 2, gocv.LineAA, false)
-
-			case calibSelecting:
-				// Draw already-clicked corners as numbered circles
-				colours := []color.RGBA{
-					{0, 255, 0, 0},   // green
-					{0, 200, 255, 0}, // cyan
-					{255, 165, 0, 0}, // orange
-					{255, 0, 255, 0}, // magenta
-				}
-				for i, pt := range cornersCopy {
-					gocv.Circle(mat, pt, 10, colours[i], 3)
-					gocv.PutTextWithParams(mat, fmt.Sprintf("%d", i+1),
-						image.Pt(pt.X+14, pt.Y-6),
-						gocv.FontHersheyDuplex, 0.6,
-						colours[i], 2, gocv.LineAA, false)
-				}
-
-				next := len(cornersCopy)
-				if next < 4 {
-					gocv.PutTextWithParams(mat,
-						fmt.Sprintf("Click corner %d/4: %s", next+1, cornerNames[next]),
-						image.Pt(20, 40),
-						gocv.FontHersheyDuplex, 0.7,
-						color.RGBA{255, 255, 0, 0}, 2, gocv.LineAA, false)
-				}
-
-			case calibDone:
-				// Show "Calibration complete!" briefly (~2 seconds = ~60 frames)
-				if doneFrame < 60 {
-					gocv.PutTextWithParams(mat, "Calibration complete!",
-						image.Pt(20, 40),
-						gocv.FontHersheyDuplex, 0.8,
-						color.RGBA{0, 255, 0, 0}, 2, gocv.LineAA, false)
-				}
+		// Run preprocessing for debug views
+		tempMat := mat.Clone()
+		stages := vision.PreprocessStages(tempMat)
+		tempMat.Close()
+		defer stages.Grey.Close()
+		defer stages.Edges.Close()
 
-				// Warp using manual corners
-				warpedMat := vision.WarpBoard(*mat, warpCorners)
+		// Update debug views only if enabled
+		toggleMu.Lock()
+		wantGrey := showGrey
+		wantEdges := showEdges
+		wantWarped := showWarped
+		toggleMu.Unlock()
 
-				// Detect pieces using variance-based detection (no reference needed)
-				occupancy, metrics := vision.ScanBoardDebug(warpedMat)
-				brightness := vision.ScanBrightness(warpedMat)
-				vision.DrawOccupancy(&warpedMat, occupancy)
+		if wantGrey {
+			greyImg, _ := stages.Grey.ToImage()
+			greyDisplay.UpdateFrame(greyImg)
+		}
 
-				if occupancy != lastOccupancy {
-					vision.PrintOccupancy(occupancy)
-					fmt.Print(vision.FormatMetrics(metrics))
+		if wantEdges {
+			edgesImg, _ := stages.Edges.ToImage()
+			edgesDisplay.UpdateFrame(edgesImg)
+		}
 
-					count := 0
-					for r := 0; r < 8; r++ {
-						for c := 0; c < 8; c++ {
-							if occupancy[r][c] {
-								count++
-							}
-						}
-					}
-					addDebug(fmt.Sprintf("Occupancy changed: %d squares occupied", count))
-					lastOccupancy = occupancy
-				}
+		// Snapshot calibration state for this frame
+		calibMu.Lock()
+		mode := calibMode
+		cornersCopy := make([]image.Point, len(calibCorners))
+		copy(cornersCopy, calibCorners)
+		doneFrame := calibDoneFrame
+		calibDoneFrame++
+		calibMu.Unlock()
 
-				// ── Game logic: infer moves from occupancy changes ──
-				gameMu.Lock()
-				gs := gameState
-				state := currentState
-				eng := stockfish
-				gameMu.Unlock()
-
-				if state == statePlaying && gs != nil {
-					expected := gs.ExpectedOccupancy()
-					if occupancy != expected {
-						// Occupancy differs from game state — potential move
-						if occupancy == pendingOcc {
-							stableDiffCount++
-						} else {
-							pendingOcc = occupancy
-							stableDiffCount = 1
-							// Occupancy changed during settle — reset
-							if settling {
-								settling = false
-								fyne.Do(func() { thinkingLabel.Hide() })
-							}
-						}
+		// Draw overlay depending on calibration state
+		switch mode {
+		case calibIdle:
+			// Prompt the user to click the Calibrate button
+			text := "Click the Calibrate button to begin..."
+			gocv.PutTextWithParams(mat, text,
+				image.Pt(mat.Cols()/2-250, mat.Rows()/2),
+				gocv.FontHersheyDuplex, 0.7,
+				color.RGBA{255, 255, 255, 0}, 2, gocv.LineAA, false)
+
+		case calibSelecting:
+			// Draw already-clicked corners as numbered circles
+			colours := []color.RGBA{
+				{0, 255, 0, 0},   // green
+				{0, 200, 255, 0}, // cyan
+				{255, 165, 0, 0}, // orange
+				{255, 0, 255, 0}, // magenta
+			}
+			for i, pt := range cornersCopy {
+				gocv.Circle(mat, pt, 10, colours[i], 3)
+				gocv.PutTextWithParams(mat, fmt.Sprintf("%d", i+1),
+					image.Pt(pt.X+14, pt.Y-6),
+					gocv.FontHersheyDuplex, 0.6,
+					colours[i], 2, gocv.LineAA, false)
+			}
 
-						// Start settle period once stable enough
-						if !settling && stableDiffCount >= stabilityThreshold {
-							settling = true
-							settleStart = time.Now()
-						}
+			next := len(cornersCopy)
+			if next < 4 {
+				gocv.PutTextWithParams(mat,
+					fmt.Sprintf("Click corner %d/4: %s", next+1, cornerNames[next]),
+					image.Pt(20, 40),
+					gocv.FontHersheyDuplex, 0.7,
+					color.RGBA{255, 255, 0, 0}, 2, gocv.LineAA, false)
+			}
 
-						// After 2-second settle period, infer move
-						if settling && time.Since(settleStart) >= 2*time.Second {
-							settling = false
-							fyne.Do(func() { thinkingLabel.Hide() })
-
-							var move *chess.Move
-							var inferErr error
-
-							if gs.IsHumanTurn() {
-								// Human's turn — infer using brightness to
-								// disambiguate when multiple captures match.
-								move, inferErr = gs.InferMoveWithColor(occupancy, brightness)
-							} else if rec := getRecommendedMove(); rec != nil {
-								// CPU's turn — verify the board matches the
-								// recommended move's occupancy directly, avoiding
-								// ambiguity when multiple captures produce the
-								// same occupancy grid.
-								if occupancy == gs.OccupancyAfterMove(rec) {
-									move = rec
-								} else {
-									inferErr = fmt.Errorf("board does not match recommended move %s", rec)
-								}
-							} else {
-								// CPU's turn but no recommendation yet — fallback
-								move, inferErr = gs.InferMoveWithColor(occupancy, brightness)
-							}
-
-							if inferErr != nil {
-								// Invalid move — flash differing squares and play alert
-								if !invalidMoveActive {
-									invalidMoveActive = true
-									addDebug(fmt.Sprintf("Invalid move detected: %v", inferErr))
-									setStatus("Invalid move! Please correct the board.")
-									invalidSoundStop = make(chan struct{})
-									go invalidMoveAlertLoop(invalidSoundStop, nil)
-								}
-								diffs := diffSquares(expected, occupancy)
-								boardWidget.FlashInvalid(diffs)
-								// Announce every time squares flash red
-								if voiceoverCheck.Checked {
-									speak(voiceSelect.Selected, "Invalid move")
-								}
-							} else {
-								// Valid move — clear any invalid state
-								if invalidMoveActive {
-									invalidMoveActive = false
-									close(invalidSoundStop)
-									boardWidget.ClearInvalid()
-								}
+		case calibDone:
+			// Show "Calibration complete!" briefly (~2 seconds = ~60 frames)
+			if doneFrame < 60 {
+				gocv.PutTextWithParams(mat, "Calibration complete!",
+					image.Pt(20, 40),
+					gocv.FontHersheyDuplex, 0.8,
+					color.RGBA{0, 255, 0, 0}, 2, gocv.LineAA, false)
+			}
 
-								wasHumanTurn := gs.IsHumanTurn()
-								prePos := gs.Game().Position()
-								notation := gs.MoveToAlgebraic(move)
-								if applyErr := gs.ApplyMove(move); applyErr != nil {
-									addDebug(fmt.Sprintf("Failed to apply move: %v", applyErr))
-								} else {
-									addDebug(fmt.Sprintf("Move detected: %s", notation))
-									boardWidget.UpdatePieces(pieceGridToUI(gs.PieceGrid()), false)
-									boardWidget.ClearHighlight()
-									clearRecommendation()
-
-									// Check indicator
-									boardWidget.ClearCheck()
-									if kR, kC, inCheck := gs.CheckedKingSquare(move); inCheck {
-										boardWidget.HighlightCheck(kR, kC)
-									}
-
-									fyne.Do(func() {
-										fenLabel.SetText("FEN: " + gs.FEN())
-									})
-
-									if wasHumanTurn {
-										setHumanMoveLabel(fmt.Sprintf("%s moved %s", humanColorName(), notation))
-									} else {
-										setCpuMoveLabel(fmt.Sprintf("%s moved %s", cpuColorName(), notation))
-									}
-
-									// Voiceover for human moves only (CPU moves are announced
-									// earlier when Stockfish recommends them).
-									if voiceoverCheck.Checked && wasHumanTurn && !cpuOnlyCheck.Checked {
-										colorName := "White"
-										if prePos.Turn() == chess.Black {
-											colorName = "Black"
-										}
-										speak(voiceSelect.Selected, moveCommentary(colorName, move, prePos, false))
-									}
-
-									if gs.IsGameOver() {
-										gameMu.Lock()
-										currentState = stateGameOver
-										gameMu.Unlock()
-										outcome := gs.Outcome()
-										if voiceoverCheck.Checked {
-											speak(voiceSelect.Selected, outcome)
-										}
-										addDebug(fmt.Sprintf("Game over: %s", outcome))
-										setStatus(fmt.Sprintf("Game over: %s", outcome))
-										fyne.Do(func() {
-											startBtn.SetText("Start Game")
-											cpuVsCpuBtn.Enable()
-											dialog.ShowConfirm("Game Over",
-												outcome+"\n\nWould you like to start a new game?",
-												func(yes bool) {
-													if yes {
-														resetToPreGame()
-													}
-												}, window)
-										})
-									} else if !gs.IsHumanTurn() && eng != nil {
-										// Engine's turn — query Stockfish
-										difficulty, _ := strconv.Atoi(difficultySelect.Selected)
-										if difficulty < 1 {
-											difficulty = 5
-										}
-										depth := difficulty * 2
-										speakFn := func(m *chess.Move, p *chess.Position) {
-											if voiceoverCheck.Checked {
-												cn := "White"
-												if p.Turn() == chess.Black {
-													cn = "Black"
-												}
-												text := moveCommentary(cn, m, p, true)
-												speak(voiceSelect.Selected, text)
-												// Repeat the recommendation every 10 seconds
-												recMu.Lock()
-												if recRepeatStop != nil {
-													close(recRepeatStop)
-												}
-												stop := make(chan struct{})
-												recRepeatStop = stop
-												recMu.Unlock()
-												go func() {
-													ticker := time.NewTicker(10 * time.Second)
-													defer ticker.Stop()
-													for {
-														select {
-														case <-stop:
-															return
-														case <-ticker.C:
-															if voiceoverCheck.Checked {
-																speak(voiceSelect.Selected, text)
-															}
-														}
-													}
-												}()
-											}
-										}
-										go queryStockfish(gs, eng, depth, cpuColorName(), setCpuMoveLabel, boardWidget, storeRecommendation, addDebug, speakFn)
-									}
-								}
-							}
-							stableDiffCount = 0
-						}
-					} else {
-						// Occupancy matches expected — reset stability counter
-						stableDiffCount = 0
-						if settling {
-							settling = false
-						}
-						if invalidMoveActive {
-							invalidMoveActive = false
-							close(invalidSoundStop)
-							boardWidget.ClearInvalid()
-							// Restore Stockfish recommendation highlights
-							restoreRecommendation()
-							setStatus("Board corrected. Your move.")
-							addDebug("Board matches expected position")
+			if !frame.Detected() {
+				break
+			}
+			if frame.Changed {
+				vision.PrintOccupancy(frame.Occupancy)
+				fmt.Print(frame.Params.FormatMetrics(frame.Metrics))
+
+				count := 0
+				for r := 0; r < 8; r++ {
+					for c := 0; c < 8; c++ {
+						if frame.Occupancy[r][c] {
+							count++
 						}
 					}
 				}
-
-				// Draw grid and update warped debug view
-				vision.DrawGrid(&warpedMat)
-
-				if wantWarped {
-					warpedImg, _ := warpedMat.ToImage()
-					warpedDisplay.UpdateFrame(warpedImg)
-				}
-
-				// Draw corner markers on camera feed
-				for _, pt := range warpCorners {
-					gocv.Circle(mat, pt, 8, color.RGBA{255, 255, 255, 0}, 2)
-				}
-
-				warpedMat.Close()
+				addDebug(fmt.Sprintf("Occupancy changed: %d squares occupied", count))
 			}
 
-			// Update the main camera display
-			origImg, _ := mat.ToImage()
-			mainDisplay.UpdateFrame(origImg)
+			if wantWarped {
+				warpedImg, _ := frame.Warped.ToImage()
+				warpedDisplay.UpdateFrame(warpedImg)
+			}
 
-			// Cleanup intermediate Mats
-			stages.Grey.Close()
-			stages.Edges.Close()
+			// Draw corner markers on camera feed
+			for _, pt := range pipe.Corners() {
+				gocv.Circle(mat, pt, 8, color.RGBA{255, 255, 255, 0}, 2)
+			}
+		}
 
-			// Cap the frame rate (~30 FPS)
-			time.Sleep(time.Millisecond * 33)
+		// Update the main camera display
+		origImg, _ := mat.ToImage()
+		mainDisplay.UpdateFrame(origImg)
+	}
+	visionCtx, stopVision := context.WithCancel(context.Background())
+	go pipe.Run(visionCtx, stream)
+	if api != nil {
+		addr := cfg.Server.Addr
+		go func() {
+			if err := api.ListenAndServe(visionCtx, addr); err != nil {
+				addDebug(fmt.Sprintf("API server: %v", err))
+			}
+		}()
+		addDebug(fmt.Sprintf("Serving the game API on %s", addr))
+		if urls, err := server.URLs(addr); err == nil {
+			addDebug("Spectators can watch at " + strings.Join(urls, " or "))
 		}
-	}()
+	}
+	if boardOverlay != nil {
+		go boardOverlay.Run(visionCtx)
+		if path := cfg.Overlay.PNG; path != "" {
+			addDebug("Writing the board overlay to " + path)
+		}
+		if addr := cfg.Overlay.MJPEG; addr != "" {
+			go func() {
+				if err := boardOverlay.ListenAndServe(visionCtx, addr); err != nil {
+					addDebug(fmt.Sprintf("Overlay server: %v", err))
+				}
+			}()
+			addDebug(fmt.Sprintf("Serving the board overlay at http://%s/board.mjpg", addr))
+		}
+	}
 
 	// 5. Layout and Run
 	window.SetContent(mainLayout)
@@ -1195,23 +1883,26 @@ func main() {
 	window.ShowAndRun()
 
 	// Cleanup Stockfish on exit
-	gameMu.Lock()
-	if stockfish != nil {
-		stockfish.Close()
-	}
-	gameMu.Unlock()
+	stopVision()
+	controller.Stop()
+	return nil
 }
 
 // showMoveHistoryWindow opens a popup window with a chessboard and prev/next
-// buttons to navigate through the game's move history.
-func showMoveHistoryWindow(myApp fyne.App, gs *nchess.GameState) {
+// buttons to navigate through the game's move history. If report is non-nil
+// the window also shows the post-game analysis: an eval graph, accuracy for
+// each side, the turning points, and the engine's best line at each mistake.
+func showMoveHistoryWindow(myApp fyne.App, gs *nchess.GameState, report *analysis.Report, flipped bool) {
 	historyWindow := myApp.NewWindow("Move History")
 
 	historyBoard := ui.NewBoardWidget()
+	historyBoard.SetFlipped(flipped)
+	applyAppearance(historyBoard, myApp.Preferences())
 
 	positions := gs.Game().Positions()
 	moves := gs.Game().Moves()
 	totalPositions := len(positions) // includes starting position
+	startPly := gs.StartPly()
 
 	// Current index into positions (0 = starting, len(moves) = current)
 	currentIdx := totalPositions - 1
@@ -1221,10 +1912,20 @@ func showMoveHistoryWindow(myApp fyne.App, gs *nchess.GameState) {
 		Style: widget.RichTextStyleSubHeading,
 	})
 
+	bestLineLabel := widget.NewLabel("")
+	bestLineLabel.Wrapping = fyne.TextWrapWord
+	bestLineLabel.Hidden = true
+
+	var evalGraph *ui.EvalGraph
+	if report != nil {
+		evalGraph = ui.NewEvalGraph()
+		evalGraph.SetEvals(report.Evals)
+	}
+
 	updateHistoryDisplay := func(idx int) {
 		pos := positions[idx]
 		grid := nchess.PieceGridFromPosition(pos)
-		historyBoard.UpdatePieces(pieceGridToUI(grid), false)
+		historyBoard.UpdatePieces(ui.PiecesFromChess(grid), false)
 
 		var text string
 		if idx == 0 {
@@ -1233,15 +1934,31 @@ func showMoveHistoryWindow(myApp fyne.App, gs *nchess.GameState) {
 			move := moves[idx-1]
 			prePos := positions[idx-1]
 			notation := chess.AlgebraicNotation{}.Encode(prePos, move)
-			moveNum := (idx + 1) / 2
-			if idx%2 == 1 {
-				text = fmt.Sprintf("%d. %s", moveNum, notation)
-			} else {
-				text = fmt.Sprintf("%d. ... %s", moveNum, notation)
-			}
+			text = moveNumberPrefix(startPly+idx) + notation
 		}
 		text += fmt.Sprintf("  (%d/%d)", idx, totalPositions-1)
 
+		bestLineLabel.Hidden = true
+		historyBoard.ClearAnnotations()
+		if report != nil && idx > 0 {
+			review := report.Reviews[idx-1]
+			text += "  " + review.Class.String()
+			if review.Class >= analysis.Inaccuracy && len(review.BestLine) > 0 {
+				// Played move in red, the engine's choice in green
+				historyBoard.SetAnnotations([]ui.Arrow{
+					moveArrow(review.Move, ui.AnnotationRed),
+					moveArrow(review.BestLine[0], ui.AnnotationGreen),
+				}, nil)
+				bestLineLabel.SetText(fmt.Sprintf("Lost %.2f. Best line: %s",
+					float64(review.CPLoss)/100, formatLine(positions[idx-1], startPly+idx, review.BestLine)))
+				bestLineLabel.Hidden = false
+			}
+			bestLineLabel.Refresh()
+		}
+		if evalGraph != nil {
+			evalGraph.SetMarker(idx)
+		}
+
 		moveInfoLabel.Segments = []widget.RichTextSegment{&widget.TextSegment{
 			Text:  text,
 			Style: widget.RichTextStyleSubHeading,
@@ -1263,57 +1980,107 @@ func showMoveHistoryWindow(myApp fyne.App, gs *nchess.GameState) {
 		}
 	}
 
+	goTo := func(idx int) {
+		currentIdx = idx
+		updateHistoryDisplay(currentIdx)
+		updateButtons(currentIdx)
+	}
+
 	prevBtn.OnTapped = func() {
 		if currentIdx > 0 {
-			currentIdx--
-			updateHistoryDisplay(currentIdx)
-			updateButtons(currentIdx)
+			goTo(currentIdx - 1)
 		}
 	}
 
 	nextBtn.OnTapped = func() {
 		if currentIdx < totalPositions-1 {
-			currentIdx++
-			updateHistoryDisplay(currentIdx)
-			updateButtons(currentIdx)
+			goTo(currentIdx + 1)
 		}
 	}
 
 	// Initialize display at current (latest) position
-	updateHistoryDisplay(currentIdx)
-	updateButtons(currentIdx)
+	goTo(currentIdx)
 
 	navRow := container.NewGridWithColumns(2, prevBtn, nextBtn)
-	bottomPanel := container.NewVBox(moveInfoLabel, navRow)
-	content := container.NewBorder(nil, bottomPanel, nil, nil, historyBoard)
+	bottomPanel := container.NewVBox(moveInfoLabel, bestLineLabel, navRow)
+
+	var topPanel fyne.CanvasObject
+	if report != nil {
+		evalGraph.OnTapped = goTo
+
+		summary := widget.NewLabel(fmt.Sprintf("White: %s\nBlack: %s",
+			formatSideStats(report.White), formatSideStats(report.Black)))
+		summary.TextStyle = fyne.TextStyle{Monospace: true}
+
+		// One button per turning point jumps straight to that move.
+		turning := container.NewHBox(widget.NewLabel("Turning points:"))
+		for _, i := range report.TurningPoints {
+			ply := i + 1
+			review := report.Reviews[i]
+			label := fmt.Sprintf("%s%s (%s)", moveNumberPrefix(startPly+ply),
+				chess.AlgebraicNotation{}.Encode(positions[i], review.Move), review.Class)
+			turning.Add(widget.NewButton(label, func() { goTo(ply) }))
+		}
+		if len(report.TurningPoints) == 0 {
+			turning.Add(widget.NewLabel("none"))
+		}
+
+		graphBox := container.New(&fixedHeightLayout{height: 100}, evalGraph)
+		topPanel = container.NewVBox(graphBox, summary, container.NewHScroll(turning))
+	}
+
+	content := container.NewBorder(topPanel, bottomPanel, nil, nil, historyBoard)
 
 	historyWindow.SetContent(content)
-	historyWindow.Resize(fyne.NewSize(500, 600))
+	if report != nil {
+		historyWindow.Resize(fyne.NewSize(600, 850))
+	} else {
+		historyWindow.Resize(fyne.NewSize(500, 600))
+	}
 	historyWindow.Show()
 }
 
-// diffSquares returns the [row, col] pairs where expected and observed differ.
-func diffSquares(expected, observed [8][8]bool) [][2]int {
-	var diffs [][2]int
-	for r := 0; r < 8; r++ {
-		for c := 0; c < 8; c++ {
-			if expected[r][c] != observed[r][c] {
-				diffs = append(diffs, [2]int{r, c})
-			}
+// moveNumberPrefix returns "12. " for White's ply or "12. ... " for Black's,
+// where ply is the 1-based index of the move counted from White's first
+// move: a game set up from a position adds its nchess.StartPly.
+func moveNumberPrefix(ply int) string {
+	moveNum := (ply + 1) / 2
+	if ply%2 == 1 {
+		return fmt.Sprintf("%d. ", moveNum)
+	}
+	return fmt.Sprintf("%d. ... ", moveNum)
+}
+
+// formatLine renders an engine line in SAN starting from pos, where ply
+// numbers the line's first move as for moveNumberPrefix.
+func formatLine(pos *chess.Position, ply int, line []*chess.Move) string {
+	var parts []string
+	for i, m := range line {
+		san := chess.AlgebraicNotation{}.Encode(pos, m)
+		if i == 0 || (ply+i)%2 == 1 {
+			san = strings.TrimSpace(moveNumberPrefix(ply+i)) + " " + san
 		}
+		parts = append(parts, san)
+		pos = pos.Update(m)
 	}
-	return diffs
+	return strings.Join(parts, " ")
+}
+
+// formatSideStats summarises one side's accuracy and error counts.
+func formatSideStats(s analysis.SideStats) string {
+	return fmt.Sprintf("accuracy %5.1f%%  ACPL %4.0f  %d inaccuracies, %d mistakes, %d blunders",
+		s.Accuracy, s.ACPL, s.Inaccuracies, s.Mistakes, s.Blunders)
 }
 
-// invalidMoveAlertLoop plays an alert sound immediately, then every 4 seconds,
+// invalidMoveAlertLoop plays an alert sound immediately, then every interval,
 // until the stop channel is closed. afterFirstAlert (if non-nil) is called once
 // after the first sound finishes — used for voiceover announcements.
-func invalidMoveAlertLoop(stop <-chan struct{}, afterFirstAlert func()) {
+func invalidMoveAlertLoop(stop <-chan struct{}, interval time.Duration, afterFirstAlert func()) {
 	playAlertSound()
 	if afterFirstAlert != nil {
 		afterFirstAlert()
 	}
-	ticker := time.NewTicker(4 * time.Second)
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 	for {
 		select {
@@ -1325,191 +2092,60 @@ func invalidMoveAlertLoop(stop <-chan struct{}, afterFirstAlert func()) {
 	}
 }
 
-// initAlertSound writes the embedded sound to a temp file so afplay can use it.
-func initAlertSound() {
-	dir := os.TempDir()
-	path := filepath.Join(dir, "nayan-alert.aiff")
-	if err := os.WriteFile(path, alertSoundData, 0644); err != nil {
-		// Fallback to system sound if temp write fails
-		path = "/System/Library/Sounds/Funk.aiff"
-	}
-	alertSoundPath = path
-}
-
-// playAlertSound plays the embedded alert sound (macOS).
+// playAlertSound plays the alert sound, blocking until it finishes.
 func playAlertSound() {
-	exec.Command("afplay", alertSoundPath).Run()
-}
-
-// pieceGridToUI converts a chess.Piece grid from GameState to ui.PieceType grid.
-func pieceGridToUI(grid [8][8]chess.Piece) [8][8]ui.PieceType {
-	var result [8][8]ui.PieceType
-	for row := 0; row < 8; row++ {
-		for col := 0; col < 8; col++ {
-			result[row][col] = chessPieceToUI(grid[row][col])
-		}
+	if err := alertPlayer.Play(audio.Alert); err != nil {
+		fmt.Printf("Alert sound failed: %v\n", err)
 	}
-	return result
 }
 
-// chessPieceToUI converts a single chess.Piece to ui.PieceType.
-func chessPieceToUI(p chess.Piece) ui.PieceType {
-	switch p {
-	case chess.WhiteKing:
-		return ui.WhiteKing
-	case chess.WhiteQueen:
-		return ui.WhiteQueen
-	case chess.WhiteRook:
-		return ui.WhiteRook
-	case chess.WhiteBishop:
-		return ui.WhiteBishop
-	case chess.WhiteKnight:
-		return ui.WhiteKnight
-	case chess.WhitePawn:
-		return ui.WhitePawn
-	case chess.BlackKing:
-		return ui.BlackKing
-	case chess.BlackQueen:
-		return ui.BlackQueen
-	case chess.BlackRook:
-		return ui.BlackRook
-	case chess.BlackBishop:
-		return ui.BlackBishop
-	case chess.BlackKnight:
-		return ui.BlackKnight
-	case chess.BlackPawn:
-		return ui.BlackPawn
-	default:
-		return ui.NoPieceType
-	}
+// moveArrow returns an annotation arrow for m in the given colour.
+func moveArrow(m *chess.Move, c color.Color) ui.Arrow {
+	fromRow, fromCol := nchess.RowColFromSquare(m.S1())
+	toRow, toCol := nchess.RowColFromSquare(m.S2())
+	return ui.Arrow{FromRow: fromRow, FromCol: fromCol, ToRow: toRow, ToCol: toCol, Color: c}
 }
 
-// queryStockfish asks the engine for the best move and updates the UI.
-// speakMove is called with the best move and position so the caller can
-// trigger a pre-move voiceover announcement.
-func queryStockfish(gs *nchess.GameState, eng *engine.Engine, depth int, cpuColor string, setCpuLabel func(string), boardWidget *ui.BoardWidget, storeRec func(int, int, int, int, *chess.Move), addDebug func(string), speakMove func(*chess.Move, *chess.Position)) {
-	bestMove, err := eng.BestMove(gs.Game(), depth)
-	if err != nil {
-		addDebug(fmt.Sprintf("Stockfish error: %v", err))
-		return
-	}
-
-	pos := gs.Game().Position()
-	notation := chess.AlgebraicNotation{}.Encode(pos, bestMove)
-	addDebug(fmt.Sprintf("Stockfish recommends: %s", notation))
-
-	fromRow, fromCol := nchess.RowColFromSquare(bestMove.S1())
-	toRow, toCol := nchess.RowColFromSquare(bestMove.S2())
-	storeRec(fromRow, fromCol, toRow, toCol, bestMove)
-	boardWidget.HighlightMove(fromRow, fromCol, toRow, toCol)
+// setSpeaker switches the text-to-speech backend and returns its voice
+// names (defaultVoiceLabel alone if it cannot list them) and the voice to
+// preselect.
+func setSpeaker(s speech.Speaker) (voices []string, selected string) {
+	speakMu.Lock()
+	speaker = s
+	speakMu.Unlock()
 
-	setCpuLabel(fmt.Sprintf("%s to move %s", cpuColor, notation))
-
-	if speakMove != nil {
-		speakMove(bestMove, pos)
-	}
-}
-
-// availableVoices returns the list of macOS TTS voices by parsing `say -v ?`.
-func availableVoices() []string {
-	out, err := exec.Command("say", "-v", "?").Output()
-	if err != nil {
-		return []string{"Daniel"}
-	}
-	var voices []string
-	scanner := bufio.NewScanner(strings.NewReader(string(out)))
-	for scanner.Scan() {
-		line := scanner.Text()
-		// Each line: "VoiceName           xx_XX    # description"
-		idx := strings.Index(line, "  ")
-		if idx > 0 {
-			voices = append(voices, strings.TrimSpace(line[:idx]))
-		}
+	for _, v := range s.Voices() {
+		voices = append(voices, v.Name)
 	}
 	if len(voices) == 0 {
-		return []string{"Daniel"}
+		return []string{defaultVoiceLabel}, defaultVoiceLabel
 	}
-	return voices
+	return voices, s.DefaultVoice()
 }
 
-// pieceName returns a human-readable name for a chess piece.
-func pieceName(p chess.Piece) string {
-	switch p {
-	case chess.WhiteKing, chess.BlackKing:
-		return "king"
-	case chess.WhiteQueen, chess.BlackQueen:
-		return "queen"
-	case chess.WhiteRook, chess.BlackRook:
-		return "rook"
-	case chess.WhiteBishop, chess.BlackBishop:
-		return "bishop"
-	case chess.WhiteKnight, chess.BlackKnight:
-		return "knight"
-	case chess.WhitePawn, chess.BlackPawn:
-		return "pawn"
-	default:
-		return ""
+// voiceLanguage returns the locale of the current speaker's voice called
+// name, or "" if it is not known.
+func voiceLanguage(name string) string {
+	speakMu.Lock()
+	s := speaker
+	speakMu.Unlock()
+	for _, v := range s.Voices() {
+		if v.Name == name {
+			return v.Language
+		}
 	}
+	return ""
 }
 
-// squareName returns a spoken-friendly name like "e 4" from a chess.Square.
-func squareName(sq chess.Square) string {
-	return fmt.Sprintf("%c %d", rune('a')+rune(sq.File()), int(sq.Rank())+1)
-}
-
-// speak runs the macOS `say` command in a goroutine. A new utterance kills any
-// in-progress one so speech never overlaps.
+// speak says text with the current speaker in a goroutine. A new utterance
+// interrupts any in-progress one so speech never overlaps.
 func speak(voice, text string) {
-	go func() {
-		speakMu.Lock()
-		if speakCmd != nil && speakCmd.Process != nil {
-			speakCmd.Process.Kill()
-		}
-		cmd := exec.Command("say", "-v", voice, text)
-		speakCmd = cmd
-		speakMu.Unlock()
-		cmd.Run()
-	}()
-}
-
-// moveCommentary builds a natural-language phrase describing a chess move.
-// When preMove is true (CPU announcements), uses future tense: "to move to" / "to take".
-// When preMove is false (human moves already made), uses past tense: "to" / "takes".
-func moveCommentary(colorName string, move *chess.Move, pos *chess.Position, preMove bool) string {
-	piece := pos.Board().Piece(move.S1())
-	dest := squareName(move.S2())
-	name := pieceName(piece)
-
-	if move.HasTag(chess.KingSideCastle) {
-		if preMove {
-			return colorName + " to castle king side"
-		}
-		return colorName + " castles king side"
-	}
-	if move.HasTag(chess.QueenSideCastle) {
-		if preMove {
-			return colorName + " to castle queen side"
-		}
-		return colorName + " castles queen side"
-	}
-
-	var verb string
-	if preMove {
-		verb = "to move to"
-		if move.HasTag(chess.Capture) || move.HasTag(chess.EnPassant) {
-			verb = "to take"
-		}
-	} else {
-		verb = "to"
-		if move.HasTag(chess.Capture) || move.HasTag(chess.EnPassant) {
-			verb = "takes"
-		}
+	if voice == defaultVoiceLabel {
+		voice = ""
 	}
-
-	text := fmt.Sprintf("%s %s %s %s", colorName, name, verb, dest)
-
-	if move.HasTag(chess.Check) {
-		text += ". Check!"
-	}
-	return text
+	speakMu.Lock()
+	s := speaker
+	speakMu.Unlock()
+	go s.Say(voice, text)
 }
+
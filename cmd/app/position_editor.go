@@ -0,0 +1,331 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	nchess "github.com/intothevoid/nayan/pkg/chess"
+	"github.com/intothevoid/nayan/pkg/engine"
+	"github.com/intothevoid/nayan/pkg/ui"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/notnil/chess"
+)
+
+// paletteIconSize is the size of each piece button in the editor palette.
+const paletteIconSize = 48
+
+// paletteRows are the pieces offered by the editor, one row per colour.
+var paletteRows = [2][]chess.Piece{
+	{chess.WhiteKing, chess.WhiteQueen, chess.WhiteRook, chess.WhiteBishop, chess.WhiteKnight, chess.WhitePawn},
+	{chess.BlackKing, chess.BlackQueen, chess.BlackRook, chess.BlackBishop, chess.BlackKnight, chess.BlackPawn},
+}
+
+// showPositionEditor opens a window for setting up an arbitrary position,
+// starting from fen (the standard starting position if empty or invalid).
+//
+// Click a square to place the palette piece (clicking the same piece again
+// removes it), drag pieces to move them, and right-click to clear a square.
+// "Analyse" evaluates the position with a temporary engine from
+// startEngine; "Play from Here" passes the validated setup to onPlay.
+func showPositionEditor(myApp fyne.App, fen string, flipped bool, startEngine func() (engine.Engine, error), depth func() int, onPlay func(nchess.Setup)) {
+	w := myApp.NewWindow("Position Editor")
+
+	setup, err := nchess.SetupFromFEN(fen)
+	if fen == "" || err != nil {
+		setup = nchess.StartingSetup()
+	}
+
+	board := ui.NewBoardWidget()
+	board.SetFlipped(flipped)
+	applyAppearance(board, myApp.Preferences())
+	_, set := savedAppearance(myApp.Preferences())
+
+	fenEntry := widget.NewEntry()
+	fenEntry.TextStyle = fyne.TextStyle{Monospace: true}
+	statusLabel := widget.NewLabel("")
+	statusLabel.Wrapping = fyne.TextWrapWord
+	analysisLabel := widget.NewLabel("")
+	analysisLabel.Wrapping = fyne.TextWrapWord
+
+	turnRadio := widget.NewRadioGroup([]string{"White", "Black"}, nil)
+	turnRadio.Horizontal = true
+	whiteOO := widget.NewCheck("White O-O", nil)
+	whiteOOO := widget.NewCheck("White O-O-O", nil)
+	blackOO := widget.NewCheck("Black O-O", nil)
+	blackOOO := widget.NewCheck("Black O-O-O", nil)
+	epEntry := widget.NewEntry()
+	epEntry.SetPlaceHolder("-")
+
+	var playBtn, analyseBtn *widget.Button
+	updating := false // true while refresh writes to the controls
+
+	// refresh redraws the board and controls from setup and revalidates.
+	refresh := func() {
+		updating = true
+		defer func() { updating = false }()
+
+		board.UpdatePieces(ui.PiecesFromChess(setup.Board), false)
+		board.ClearAnnotations()
+		analysisLabel.SetText("")
+
+		if setup.Turn == chess.Black {
+			turnRadio.SetSelected("Black")
+		} else {
+			turnRadio.SetSelected("White")
+		}
+		whiteOO.SetChecked(setup.WhiteKingside)
+		whiteOOO.SetChecked(setup.WhiteQueenside)
+		blackOO.SetChecked(setup.BlackKingside)
+		blackOOO.SetChecked(setup.BlackQueenside)
+		if setup.EnPassant == chess.NoSquare {
+			epEntry.SetText("")
+		} else {
+			epEntry.SetText(setup.EnPassant.String())
+		}
+		fenEntry.SetText(setup.FEN())
+
+		if err := setup.Validate(); err != nil {
+			statusLabel.Importance = widget.DangerImportance
+			statusLabel.SetText("Invalid position: " + err.Error())
+			playBtn.Disable()
+			analyseBtn.Disable()
+			return
+		}
+		statusLabel.Importance = widget.SuccessImportance
+		statusLabel.SetText("Position is valid")
+		playBtn.Enable()
+		analyseBtn.Enable()
+	}
+
+	// Side to move, castling rights and en passant square.
+	turnRadio.OnChanged = func(s string) {
+		if updating {
+			return
+		}
+		setup.Turn = chess.White
+		if s == "Black" {
+			setup.Turn = chess.Black
+		}
+		refresh()
+	}
+	for _, c := range []struct {
+		check *widget.Check
+		right *bool
+	}{
+		{whiteOO, &setup.WhiteKingside}, {whiteOOO, &setup.WhiteQueenside},
+		{blackOO, &setup.BlackKingside}, {blackOOO, &setup.BlackQueenside},
+	} {
+		right := c.right
+		c.check.OnChanged = func(on bool) {
+			if updating {
+				return
+			}
+			*right = on
+			refresh()
+		}
+	}
+	epEntry.OnSubmitted = func(s string) {
+		sq, ok := parseSquare(s)
+		if !ok {
+			statusLabel.Importance = widget.DangerImportance
+			statusLabel.SetText(fmt.Sprintf("%q is not a square", s))
+			statusLabel.Refresh()
+			return
+		}
+		setup.EnPassant = sq
+		refresh()
+	}
+	fenEntry.OnSubmitted = func(s string) {
+		parsed, err := nchess.SetupFromFEN(s)
+		if err != nil {
+			statusLabel.Importance = widget.DangerImportance
+			statusLabel.SetText("Invalid FEN: " + err.Error())
+			statusLabel.Refresh()
+			return
+		}
+		setup = parsed
+		refresh()
+	}
+
+	// Piece palette. selected is the piece placed by a click; NoPiece
+	// means the eraser.
+	selected := chess.WhiteQueen
+	var paletteBtns []*widget.Button
+	var paletteObjs []fyne.CanvasObject
+	var eraserBtn *widget.Button
+	selectPiece := func(p chess.Piece) {
+		selected = p
+		i := 0
+		for _, row := range paletteRows {
+			for _, piece := range row {
+				paletteBtns[i].Importance = widget.LowImportance
+				if piece == p {
+					paletteBtns[i].Importance = widget.HighImportance
+				}
+				paletteBtns[i].Refresh()
+				i++
+			}
+		}
+		eraserBtn.Importance = widget.LowImportance
+		if p == chess.NoPiece {
+			eraserBtn.Importance = widget.HighImportance
+		}
+		eraserBtn.Refresh()
+	}
+	for _, row := range paletteRows {
+		for _, piece := range row {
+			p := piece
+			btn := widget.NewButtonWithIcon("", set.Resource(ui.PieceFromChess(p)), func() { selectPiece(p) })
+			paletteBtns = append(paletteBtns, btn)
+			paletteObjs = append(paletteObjs, btn)
+		}
+	}
+	eraserBtn = widget.NewButtonWithIcon("", theme.DeleteIcon(), func() { selectPiece(chess.NoPiece) })
+	paletteObjs = append(paletteObjs, eraserBtn)
+	palette := container.NewGridWrap(fyne.NewSize(paletteIconSize, paletteIconSize), paletteObjs...)
+
+	// Board editing.
+	board.OnTapped = func(row, col int) {
+		if setup.Board[row][col] == selected {
+			setup.Board[row][col] = chess.NoPiece
+		} else {
+			setup.Board[row][col] = selected
+		}
+		refresh()
+	}
+	board.OnSecondaryTapped = func(row, col int) {
+		setup.Board[row][col] = chess.NoPiece
+		refresh()
+	}
+	board.OnMove = func(fromRow, fromCol, toRow, toCol int) {
+		setup.Board[toRow][toCol] = setup.Board[fromRow][fromCol]
+		setup.Board[fromRow][fromCol] = chess.NoPiece
+		refresh()
+	}
+
+	startBtn := widget.NewButton("Starting Position", func() {
+		setup = nchess.StartingSetup()
+		refresh()
+	})
+	clearBtn := widget.NewButton("Clear Board", func() {
+		setup = nchess.Setup{Turn: setup.Turn, EnPassant: chess.NoSquare}
+		refresh()
+	})
+	flipBtn := widget.NewButtonWithIcon("Flip", theme.ViewRefreshIcon(), func() {
+		board.SetFlipped(!board.Flipped())
+	})
+
+	analyseBtn = widget.NewButtonWithIcon("Analyse", theme.SearchIcon(), nil)
+	analyseBtn.OnTapped = func() {
+		analysed := setup
+		opt, err := chess.FEN(analysed.FEN())
+		if err != nil {
+			analysisLabel.SetText(err.Error())
+			return
+		}
+		pos := chess.NewGame(opt).Position()
+		analyseBtn.Disable()
+		analysisLabel.SetText("Analysing...")
+		go func() {
+			text, arrows := analysePosition(startEngine, pos, depth())
+			fyne.Do(func() {
+				if setup != analysed {
+					return // edited while the engine was thinking
+				}
+				analyseBtn.Enable()
+				analysisLabel.SetText(text)
+				board.SetAnnotations(arrows, nil)
+			})
+		}()
+	}
+
+	playBtn = widget.NewButtonWithIcon("Play from Here", theme.MediaPlayIcon(), func() {
+		if setup.Validate() != nil {
+			return
+		}
+		onPlay(setup)
+		w.Close()
+	})
+	playBtn.Importance = widget.SuccessImportance
+
+	refresh()
+	selectPiece(selected)
+
+	castling := container.NewGridWithColumns(2, whiteOO, whiteOOO, blackOO, blackOOO)
+	form := widget.NewForm(
+		widget.NewFormItem("Side to move", turnRadio),
+		widget.NewFormItem("Castling", castling),
+		widget.NewFormItem("En passant", epEntry),
+	)
+	controls := container.NewVBox(
+		widget.NewLabelWithStyle("Pieces", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		palette,
+		widget.NewLabel("Click to place, drag to move, right-click to remove."),
+		widget.NewSeparator(),
+		form,
+		container.NewGridWithColumns(3, startBtn, clearBtn, flipBtn),
+		widget.NewSeparator(),
+		statusLabel,
+		container.NewGridWithColumns(2, analyseBtn, playBtn),
+		analysisLabel,
+	)
+	fenRow := container.NewBorder(nil, nil, widget.NewLabel("FEN:"), nil, fenEntry)
+
+	w.SetContent(container.NewBorder(nil, fenRow, nil, container.NewPadded(controls), board))
+	w.Resize(fyne.NewSize(1000, 680))
+	w.Show()
+}
+
+// analysePosition evaluates pos with a freshly started engine and returns a
+// summary of the score and best line, plus an arrow for the best move.
+func analysePosition(startEngine func() (engine.Engine, error), pos *chess.Position, depth int) (string, []ui.Arrow) {
+	eng, err := startEngine()
+	if err != nil {
+		return fmt.Sprintf("Engine not available: %v", err), nil
+	}
+	defer eng.Close()
+
+	ev, err := eng.Evaluate(pos, depth)
+	if err != nil {
+		return fmt.Sprintf("Analysis failed: %v", err), nil
+	}
+	if ev.BestMove == nil {
+		return "No legal moves.", nil
+	}
+
+	text := fmt.Sprintf("%s (depth %d, %s)\n%s",
+		formatScore(*ev, pos.Turn()), depth, eng.ID(), formatLine(pos, nchess.StartPly(pos)+1, ev.PV))
+	return text, []ui.Arrow{moveArrow(ev.BestMove, ui.AnnotationGreen)}
+}
+
+// formatScore renders an evaluation from White's point of view, as "+0.35"
+// or "#-3" for a forced mate.
+func formatScore(ev engine.Evaluation, turn chess.Color) string {
+	sign := 1
+	if turn == chess.Black {
+		sign = -1
+	}
+	if ev.Mate != 0 {
+		return fmt.Sprintf("#%d", sign*ev.Mate)
+	}
+	return fmt.Sprintf("%+.2f", float64(sign*ev.CP)/100)
+}
+
+// parseSquare parses an en passant field: a square name, or "" or "-" for
+// none.
+func parseSquare(s string) (chess.Square, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" || s == "-" {
+		return chess.NoSquare, true
+	}
+	for sq := chess.A1; sq <= chess.H8; sq++ {
+		if sq.String() == s {
+			return sq, true
+		}
+	}
+	return chess.NoSquare, false
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"github.com/intothevoid/nayan/pkg/ui"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/notnil/chess"
+)
+
+// promotionIconSize is the size of each piece button in the picker.
+const promotionIconSize = 64
+
+// showPromotionPicker asks which piece a pawn promotes to. candidates are
+// the legal promotion moves for one from/to pair; the chosen one is passed
+// to onChosen. Dismissing the dialog cancels the move.
+func showPromotionPicker(window fyne.Window, candidates []*chess.Move, color chess.Color, set ui.PieceSet, onChosen func(*chess.Move)) {
+	var d dialog.Dialog
+	buttons := container.NewGridWithColumns(len(candidates))
+	for _, pt := range []chess.PieceType{chess.Queen, chess.Rook, chess.Bishop, chess.Knight} {
+		for _, m := range candidates {
+			if m.Promo() != pt {
+				continue
+			}
+			move := m
+			btn := widget.NewButtonWithIcon("", set.Resource(ui.PieceFromChess(chess.NewPiece(pt, color))), func() {
+				d.Hide()
+				onChosen(move)
+			})
+			buttons.Add(container.NewGridWrap(fyne.NewSize(promotionIconSize, promotionIconSize), btn))
+		}
+	}
+	d = dialog.NewCustom("Promote to", "Cancel", buttons, window)
+	d.Show()
+}
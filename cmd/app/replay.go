@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/intothevoid/nayan/pkg/calibration"
+	"github.com/intothevoid/nayan/pkg/camera"
+	nchess "github.com/intothevoid/nayan/pkg/chess"
+	"github.com/intothevoid/nayan/pkg/config"
+	"github.com/intothevoid/nayan/pkg/game"
+	"github.com/intothevoid/nayan/pkg/pipeline"
+	"github.com/intothevoid/nayan/pkg/vision"
+)
+
+// frameClock is the controller's clock during a replay: it advances one
+// frame period per frame, so the settle time is measured in video time
+// however fast the frames are processed.
+type frameClock struct {
+	mu   sync.Mutex
+	now  time.Time
+	step time.Duration
+}
+
+func (c *frameClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *frameClock) tick() {
+	c.mu.Lock()
+	c.now = c.now.Add(c.step)
+	c.mu.Unlock()
+}
+
+// runReplay runs a session recorded with "play -record" through the vision
+// pipeline and game controller, printing the moves it infers and the game
+// as PGN. No engine is used, so any legal move is accepted for either side.
+func runReplay(fs *flag.FlagSet, cfgFlags *config.Flags, args []string) error {
+	cornersFlag := fs.String("corners", "", `corners as "x,y x,y x,y x,y" (default: the saved calibration)`)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("need one recorded session")
+	}
+	cfg, err := cfgFlags.Load()
+	if err != nil {
+		return err
+	}
+
+	var corners calibration.Calibration
+	if *cornersFlag != "" {
+		pts, err := parseCorners(*cornersFlag)
+		if err != nil {
+			return err
+		}
+		copy(corners.Corners[:], pts)
+	} else {
+		path, err := cfg.CalibrationPath()
+		if err != nil {
+			return err
+		}
+		saved, err := calibration.Load(path)
+		if err != nil {
+			return err
+		}
+		corners = *saved
+	}
+
+	stream, err := camera.NewVideoFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+	fps := stream.FPS()
+	if fps <= 0 {
+		fps = 30
+	}
+	start := time.Unix(0, 0)
+	clock := &frameClock{now: start, step: time.Duration(float64(time.Second) / fps)}
+
+	controller := game.NewController(clock)
+	s := controller.Settings()
+	s.Review = false
+	s.StabilityThreshold = cfg.Game.StabilityFrames
+	s.SettleTime = cfg.Game.SettleTime
+	controller.SetSettings(s)
+	controller.OnEvent = func(ev game.Event) {
+		at := clock.Now().Sub(start).Round(100 * time.Millisecond)
+		switch ev.Kind {
+		case game.MoveMade:
+			ply := ev.Game.StartPly() + len(ev.Game.Game().Moves())
+			fmt.Printf("%8s  %s%s\n", at, moveNumberPrefix(ply), ev.Notation)
+		case game.InvalidBoard:
+			fmt.Printf("%8s  invalid board: %v\n", at, ev.Err)
+		case game.BoardCorrected:
+			fmt.Printf("%8s  board corrected\n", at)
+		case game.GameOver:
+			fmt.Printf("%8s  %s\n", at, ev.Game.Outcome())
+		}
+	}
+	gs := nchess.NewGame(nchess.White)
+	controller.Start(gs)
+	defer controller.Stop()
+
+	pipe := pipeline.New(controller)
+	pipe.Interval = 0
+	pipe.SetParams(vision.Params(cfg.Vision))
+	pipe.SetCorners(corners.Points())
+	frames := 0
+	pipe.OnFrame = func(f *pipeline.Frame) {
+		frames = f.Number
+		clock.tick()
+	}
+	if err := pipe.Run(context.Background(), stream); err != nil {
+		return err
+	}
+	controller.Wait()
+
+	fmt.Printf("\n%d frames, %d moves\n\n%s\n", frames, len(gs.Game().Moves()), gs.Game().String())
+	return nil
+}
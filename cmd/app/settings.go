@@ -0,0 +1,210 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/intothevoid/nayan/pkg/config"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// defaultProfile is how the settings dialog names the default config file.
+const defaultProfile = "(default)"
+
+// settingField is an editable widget for one config setting.
+type settingField struct {
+	label string
+	obj   fyne.CanvasObject
+	load  func(c config.Config)        // show the value in c
+	store func(c *config.Config) error // parse the shown value into c
+}
+
+func intSetting(label string, field func(*config.Config) *int) settingField {
+	entry := widget.NewEntry()
+	return settingField{label, entry,
+		func(c config.Config) { entry.SetText(strconv.Itoa(*field(&c))) },
+		func(c *config.Config) error {
+			v, err := strconv.Atoi(strings.TrimSpace(entry.Text))
+			if err != nil {
+				return fmt.Errorf("%s: %q is not a whole number", label, entry.Text)
+			}
+			*field(c) = v
+			return nil
+		}}
+}
+
+func floatSetting(label string, field func(*config.Config) *float64) settingField {
+	entry := widget.NewEntry()
+	return settingField{label, entry,
+		func(c config.Config) { entry.SetText(strconv.FormatFloat(*field(&c), 'g', -1, 64)) },
+		func(c *config.Config) error {
+			v, err := strconv.ParseFloat(strings.TrimSpace(entry.Text), 64)
+			if err != nil {
+				return fmt.Errorf("%s: %q is not a number", label, entry.Text)
+			}
+			*field(c) = v
+			return nil
+		}}
+}
+
+func durationSetting(label string, field func(*config.Config) *time.Duration) settingField {
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder("e.g. 2s or 500ms")
+	return settingField{label, entry,
+		func(c config.Config) { entry.SetText(field(&c).String()) },
+		func(c *config.Config) error {
+			v, err := time.ParseDuration(strings.TrimSpace(entry.Text))
+			if err != nil {
+				return fmt.Errorf("%s: %q is not a duration like 2s", label, entry.Text)
+			}
+			*field(c) = v
+			return nil
+		}}
+}
+
+func stringSetting(label, placeholder string, field func(*config.Config) *string) settingField {
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder(placeholder)
+	return settingField{label, entry,
+		func(c config.Config) { entry.SetText(*field(&c)) },
+		func(c *config.Config) error {
+			*field(c) = strings.TrimSpace(entry.Text)
+			return nil
+		}}
+}
+
+func boolSetting(label string, field func(*config.Config) *bool) settingField {
+	check := widget.NewCheck("", nil)
+	return settingField{label, check,
+		func(c config.Config) { check.SetChecked(*field(&c)) },
+		func(c *config.Config) error {
+			*field(c) = check.Checked
+			return nil
+		}}
+}
+
+// showSettings opens a dialog editing every setting in the config file,
+// starting from cfg. Choosing a profile shows its settings; Save validates
+// the form, writes it to the profile named under "Save as" (a new name
+// creates a profile) and passes the saved config to onSaved.
+func showSettings(window fyne.Window, cfg config.Config, onSaved func(config.Config)) {
+	fields := []settingField{
+		intSetting("Camera device", func(c *config.Config) *int { return &c.Camera.Device }),
+		intSetting("Frame width", func(c *config.Config) *int { return &c.Camera.Width }),
+		intSetting("Frame height", func(c *config.Config) *int { return &c.Camera.Height }),
+		stringSetting("Calibration file", "beside the profile", func(c *config.Config) *string { return &c.Camera.Calibration }),
+		intSetting("Warp size", func(c *config.Config) *int { return &c.Vision.WarpSize }),
+		floatSetting("Square inset", func(c *config.Config) *float64 { return &c.Vision.SquareInset }),
+		floatSetting("Variance threshold", func(c *config.Config) *float64 { return &c.Vision.VarianceThreshold }),
+		floatSetting("Edge threshold (%)", func(c *config.Config) *float64 { return &c.Vision.EdgeThreshold }),
+		floatSetting("Combined variance min", func(c *config.Config) *float64 { return &c.Vision.CombinedVarianceMin }),
+		floatSetting("Combined edge min (%)", func(c *config.Config) *float64 { return &c.Vision.CombinedEdgeMin }),
+		intSetting("Stability frames", func(c *config.Config) *int { return &c.Game.StabilityFrames }),
+		durationSetting("Settle time", func(c *config.Config) *time.Duration { return &c.Game.SettleTime }),
+		durationSetting("Alert interval", func(c *config.Config) *time.Duration { return &c.Game.AlertInterval }),
+		stringSetting("Engine", "stockfish, else built-in", func(c *config.Config) *string { return &c.Engine.Path }),
+		intSetting("Engine depth", func(c *config.Config) *int { return &c.Engine.Depth }),
+		boolSetting("Voiceover", func(c *config.Config) *bool { return &c.Voice.Enabled }),
+		stringSetting("Speech backend", "auto-detect", func(c *config.Config) *string { return &c.Voice.Backend }),
+		stringSetting("Voice", "backend default", func(c *config.Config) *string { return &c.Voice.Name }),
+		stringSetting("Language code", "last used", func(c *config.Config) *string { return &c.Voice.Language }),
+		boolSetting("API server", func(c *config.Config) *bool { return &c.Server.Enabled }),
+		stringSetting("Server address", "host:port", func(c *config.Config) *string { return &c.Server.Addr }),
+		intSetting("Overlay width", func(c *config.Config) *int { return &c.Overlay.Width }),
+		intSetting("Overlay height", func(c *config.Config) *int { return &c.Overlay.Height }),
+		boolSetting("Overlay eval bar", func(c *config.Config) *bool { return &c.Overlay.EvalBar }),
+		stringSetting("Overlay PNG file", "off", func(c *config.Config) *string { return &c.Overlay.PNG }),
+		stringSetting("Overlay MJPEG address", "off, or host:port", func(c *config.Config) *string { return &c.Overlay.MJPEG }),
+		stringSetting("Overlay background", "#rrggbb", func(c *config.Config) *string { return &c.Overlay.Background }),
+		intSetting("Overlay frame rate", func(c *config.Config) *int { return &c.Overlay.FPS }),
+		stringSetting("Relay folder", "off", func(c *config.Config) *string { return &c.Relay.Dir }),
+		intSetting("Relay board number", func(c *config.Config) *int { return &c.Relay.Board }),
+		stringSetting("Relay time control", "seconds+increment", func(c *config.Config) *string { return &c.Relay.TimeControl }),
+		stringSetting("Relay event", "?", func(c *config.Config) *string { return &c.Relay.Event }),
+		stringSetting("Relay site", "?", func(c *config.Config) *string { return &c.Relay.Site }),
+		stringSetting("Relay round", "?", func(c *config.Config) *string { return &c.Relay.Round }),
+		stringSetting("Relay White", "?", func(c *config.Config) *string { return &c.Relay.White }),
+		stringSetting("Relay Black", "?", func(c *config.Config) *string { return &c.Relay.Black }),
+	}
+	form := widget.NewForm()
+	for _, f := range fields {
+		form.Append(f.label, f.obj)
+	}
+	show := func(c config.Config) {
+		for _, f := range fields {
+			f.load(c)
+		}
+	}
+	show(cfg)
+
+	saveAs := widget.NewEntry()
+	saveAs.SetPlaceHolder(defaultProfile)
+	saveAs.SetText(cfg.Profile)
+
+	profiles, err := config.Profiles()
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("listing profiles: %w", err), window)
+	}
+	profileSelect := widget.NewSelect(append([]string{defaultProfile}, profiles...), func(name string) {
+		if name == defaultProfile {
+			name = ""
+		}
+		c, err := config.LoadProfile(name)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		show(c)
+		saveAs.SetText(name)
+	})
+	if cfg.Profile == "" {
+		profileSelect.Selected = defaultProfile
+	} else {
+		profileSelect.Selected = cfg.Profile
+	}
+
+	resetBtn := widget.NewButton("Reset to Defaults", func() { show(config.Default()) })
+	top := widget.NewForm(widget.NewFormItem("Profile", profileSelect))
+	bottom := container.NewBorder(nil, nil, nil, resetBtn,
+		widget.NewForm(widget.NewFormItem("Save as", saveAs)))
+	content := container.NewBorder(top, bottom, nil, nil, container.NewVScroll(form))
+
+	d := dialog.NewCustomConfirm("Settings", "Save", "Cancel", content, func(save bool) {
+		if !save {
+			return
+		}
+		c := config.Default()
+		var errs []error
+		for _, f := range fields {
+			if err := f.store(&c); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		c.Profile = strings.TrimSpace(saveAs.Text)
+		if c.Profile == defaultProfile {
+			c.Profile = ""
+		}
+		if err := errors.Join(errs...); err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		path, err := config.ProfilePath(c.Profile)
+		if err == nil {
+			err = c.Save(path)
+		}
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("saving settings: %w", err), window)
+			return
+		}
+		onSaved(c)
+	}, window)
+	d.Resize(fyne.NewSize(520, 680))
+	d.Show()
+}
@@ -0,0 +1,38 @@
+// Command headless runs Nayan without a display, e.g. on a single-board
+// computer under a club table: it watches the board through the camera,
+// plays the engine's replies by voice and logs the game. It is the same as
+// "app play -headless" without linking the GUI.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/intothevoid/nayan/pkg/config"
+	"github.com/intothevoid/nayan/pkg/headless"
+)
+
+func main() {
+	opts := headless.DefaultOptions()
+	cfgFlags := config.AddFlags(flag.CommandLine)
+	opts.AddFlags(flag.CommandLine)
+	flag.Parse()
+
+	cfg, err := cfgFlags.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	opts.Config = cfg
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := headless.Run(ctx, opts); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
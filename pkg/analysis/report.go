@@ -0,0 +1,158 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+
+	"github.com/intothevoid/nayan/pkg/engine"
+	"github.com/notnil/chess"
+)
+
+// maxTurningPoints caps how many critical moments a report lists.
+const maxTurningPoints = 5
+
+// maxAverageLoss caps a single move's contribution to the average
+// centipawn loss so one missed mate does not swamp the whole game.
+const maxAverageLoss = 1000
+
+// Report is the result of a post-game analysis pass.
+type Report struct {
+	// Reviews holds one entry per move, in game order.
+	Reviews []*MoveReview
+
+	// Evals holds the score of every position in the game (starting
+	// position included) from White's point of view, for the eval graph.
+	Evals []int
+
+	White SideStats
+	Black SideStats
+
+	// TurningPoints lists the indices into Reviews of the moves that
+	// swung the game the most, in game order.
+	TurningPoints []int
+}
+
+// SideStats summarises one side's play.
+type SideStats struct {
+	Moves        int
+	ACPL         float64 // average centipawn loss
+	Accuracy     float64 // 0-100, lichess-style accuracy
+	Inaccuracies int
+	Mistakes     int
+	Blunders     int
+}
+
+// AnalyseGame evaluates every position in game and reviews every move.
+// progress, if non-nil, is called after each position is evaluated.
+func AnalyseGame(ev Evaluator, game *chess.Game, depth int, progress func(done, total int)) (*Report, error) {
+	positions := game.Positions()
+	moves := game.Moves()
+
+	// Evaluate each position once; a move's "after" evaluation is the next
+	// move's "before" evaluation.
+	report := &Report{Evals: make([]int, len(positions))}
+	evals := make([]*engine.Evaluation, len(positions))
+	for i, pos := range positions {
+		e, err := ev.Evaluate(pos, depth)
+		if err != nil {
+			return nil, err
+		}
+		evals[i] = e
+		report.Evals[i] = whitePOV(pos, e.Centipawns())
+		if progress != nil {
+			progress(i+1, len(positions))
+		}
+	}
+
+	var whiteAcc, blackAcc []float64
+	for i, move := range moves {
+		r := reviewFromEvals(move, evals[i], evals[i+1])
+		report.Reviews = append(report.Reviews, r)
+
+		stats, acc := &report.White, &whiteAcc
+		if positions[i].Turn() == chess.Black {
+			stats, acc = &report.Black, &blackAcc
+		}
+		stats.add(r)
+		*acc = append(*acc, moveAccuracy(r.EvalBefore, r.EvalAfter))
+	}
+	report.White.finish(whiteAcc)
+	report.Black.finish(blackAcc)
+	report.TurningPoints = turningPoints(report.Reviews)
+	return report, nil
+}
+
+// whitePOV converts a side-to-move score into White's point of view.
+func whitePOV(pos *chess.Position, cp int) int {
+	if pos.Turn() == chess.Black {
+		return -cp
+	}
+	return cp
+}
+
+// add accumulates a move review into the side's totals.
+func (s *SideStats) add(r *MoveReview) {
+	s.Moves++
+	loss := r.CPLoss
+	if loss > maxAverageLoss {
+		loss = maxAverageLoss
+	}
+	s.ACPL += float64(loss)
+	switch r.Class {
+	case Inaccuracy:
+		s.Inaccuracies++
+	case Mistake:
+		s.Mistakes++
+	case Blunder:
+		s.Blunders++
+	}
+}
+
+// finish turns the accumulated totals into averages.
+func (s *SideStats) finish(accuracies []float64) {
+	if s.Moves == 0 {
+		return
+	}
+	s.ACPL /= float64(s.Moves)
+	sum := 0.0
+	for _, a := range accuracies {
+		sum += a
+	}
+	s.Accuracy = sum / float64(len(accuracies))
+}
+
+// winPercent maps a centipawn score to a winning chance (0-100) using the
+// same logistic curve as lichess.
+func winPercent(cp int) float64 {
+	return 50 + 50*(2/(1+math.Exp(-0.00368208*float64(cp)))-1)
+}
+
+// moveAccuracy scores a single move 0-100 from the drop in winning chance
+// it caused for the mover (lichess formula).
+func moveAccuracy(before, after int) float64 {
+	drop := winPercent(before) - winPercent(after)
+	if drop < 0 {
+		drop = 0
+	}
+	acc := 103.1668*math.Exp(-0.04354*drop) - 3.1669
+	return math.Max(0, math.Min(100, acc))
+}
+
+// turningPoints picks the mistakes and blunders with the largest centipawn
+// loss and returns their indices in game order.
+func turningPoints(reviews []*MoveReview) []int {
+	var idx []int
+	for i, r := range reviews {
+		if r.Class >= Mistake {
+			idx = append(idx, i)
+		}
+	}
+	sort.SliceStable(idx, func(a, b int) bool {
+		return reviews[idx[a]].CPLoss > reviews[idx[b]].CPLoss
+	})
+	if len(idx) > maxTurningPoints {
+		idx = idx[:maxTurningPoints]
+	}
+	sort.Ints(idx)
+	return idx
+}
@@ -0,0 +1,84 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/intothevoid/nayan/pkg/engine"
+	"github.com/notnil/chess"
+)
+
+func TestAnalyseGameScholarsMate(t *testing.T) {
+	game := chess.NewGame()
+	sans := []string{"e4", "e5", "Qh5", "Nc6", "Bc4", "Nf6", "Qxf7#"}
+	for _, san := range sans {
+		if err := game.MoveStr(san); err != nil {
+			t.Fatalf("move %s: %v", san, err)
+		}
+	}
+	positions := game.Positions()
+
+	// Scores from the side to move's point of view. Black's 3...Nf6 walks
+	// into mate in one, so the position after it is scored as mate for White.
+	scores := []engine.Evaluation{
+		{CP: 30}, {CP: -35}, {CP: 30}, {CP: 20}, {CP: 10}, {CP: 60}, {Mate: 1}, {CP: -engine.MateScore},
+	}
+	ev := &fakeEvaluator{evals: map[string]*engine.Evaluation{}}
+	for i, pos := range positions {
+		e := scores[i]
+		ev.evals[pos.String()] = &e
+	}
+	// Give the position before 3...Nf6 a best move so the review can name it.
+	g6 := mustMove(t, positions[5], "g6")
+	ev.evals[positions[5].String()].BestMove = g6
+
+	var calls int
+	report, err := AnalyseGame(ev, game, 10, func(done, total int) {
+		calls++
+		if total != len(positions) {
+			t.Errorf("progress total = %d, want %d", total, len(positions))
+		}
+	})
+	if err != nil {
+		t.Fatalf("AnalyseGame failed: %v", err)
+	}
+	if calls != len(positions) {
+		t.Errorf("progress called %d times, want %d", calls, len(positions))
+	}
+
+	if len(report.Reviews) != len(sans) {
+		t.Fatalf("got %d reviews, want %d", len(report.Reviews), len(sans))
+	}
+	if report.Evals[1] != 35 {
+		t.Errorf("Evals[1] = %d, want 35 (White's point of view)", report.Evals[1])
+	}
+
+	nf6 := report.Reviews[5]
+	if nf6.Class != Blunder {
+		t.Errorf("3...Nf6 classified %s, want Blunder", nf6.Class)
+	}
+	if nf6.BestMove != g6 {
+		t.Errorf("3...Nf6 best move = %v, want g6", nf6.BestMove)
+	}
+
+	if report.Black.Blunders != 1 {
+		t.Errorf("Black blunders = %d, want 1", report.Black.Blunders)
+	}
+	if report.White.Blunders != 0 {
+		t.Errorf("White blunders = %d, want 0", report.White.Blunders)
+	}
+	if report.White.Accuracy <= report.Black.Accuracy {
+		t.Errorf("White accuracy %.1f should beat Black's %.1f", report.White.Accuracy, report.Black.Accuracy)
+	}
+	if len(report.TurningPoints) != 1 || report.TurningPoints[0] != 5 {
+		t.Errorf("TurningPoints = %v, want [5]", report.TurningPoints)
+	}
+}
+
+func TestMoveAccuracy(t *testing.T) {
+	if acc := moveAccuracy(50, 50); acc < 99 {
+		t.Errorf("accuracy with no loss = %.1f, want ~100", acc)
+	}
+	if acc := moveAccuracy(50, -800); acc > 10 {
+		t.Errorf("accuracy of a losing blunder = %.1f, want near 0", acc)
+	}
+}
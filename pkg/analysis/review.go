@@ -0,0 +1,112 @@
+package analysis
+
+import (
+	"github.com/intothevoid/nayan/pkg/engine"
+	"github.com/notnil/chess"
+)
+
+// Classification grades a move by how much it lost against the engine's
+// preferred move.
+type Classification int
+
+const (
+	Best Classification = iota
+	Good
+	Inaccuracy
+	Mistake
+	Blunder
+)
+
+// Centipawn-loss thresholds for each classification. A loss at or above a
+// threshold falls into that class.
+const (
+	inaccuracyLoss = 50
+	mistakeLoss    = 100
+	blunderLoss    = 300
+)
+
+// String returns the display name of the classification.
+func (c Classification) String() string {
+	switch c {
+	case Best:
+		return "Best"
+	case Good:
+		return "Good"
+	case Inaccuracy:
+		return "Inaccuracy"
+	case Mistake:
+		return "Mistake"
+	case Blunder:
+		return "Blunder"
+	default:
+		return "Unknown"
+	}
+}
+
+// Classify grades a move from its centipawn loss. isBest reports whether the
+// move played was the engine's own choice, which always counts as Best.
+func Classify(cpLoss int, isBest bool) Classification {
+	switch {
+	case isBest || cpLoss <= 0:
+		return Best
+	case cpLoss >= blunderLoss:
+		return Blunder
+	case cpLoss >= mistakeLoss:
+		return Mistake
+	case cpLoss >= inaccuracyLoss:
+		return Inaccuracy
+	default:
+		return Good
+	}
+}
+
+// Evaluator scores positions. Any engine.Engine satisfies it; tests use a fake.
+type Evaluator interface {
+	Evaluate(pos *chess.Position, depth int) (*engine.Evaluation, error)
+}
+
+// MoveReview is the verdict on a single move.
+type MoveReview struct {
+	Move     *chess.Move
+	BestMove *chess.Move   // engine's preferred move in the same position
+	BestLine []*chess.Move // engine's principal variation from the same position
+
+	// EvalBefore and EvalAfter are scored from the mover's point of view.
+	EvalBefore int
+	EvalAfter  int
+	CPLoss     int
+	Class      Classification
+}
+
+// ReviewMove evaluates pos before and after move and classifies the move by
+// the centipawn loss it caused for the side that played it.
+func ReviewMove(ev Evaluator, pos *chess.Position, move *chess.Move, depth int) (*MoveReview, error) {
+	before, err := ev.Evaluate(pos, depth)
+	if err != nil {
+		return nil, err
+	}
+	after, err := ev.Evaluate(pos.Update(move), depth)
+	if err != nil {
+		return nil, err
+	}
+	return reviewFromEvals(move, before, after), nil
+}
+
+// reviewFromEvals builds a MoveReview from the evaluations of the positions
+// before and after move. after is scored from the opponent's point of view.
+func reviewFromEvals(move *chess.Move, before, after *engine.Evaluation) *MoveReview {
+	r := &MoveReview{
+		Move:       move,
+		BestMove:   before.BestMove,
+		BestLine:   before.PV,
+		EvalBefore: before.Centipawns(),
+		EvalAfter:  -after.Centipawns(),
+	}
+	r.CPLoss = r.EvalBefore - r.EvalAfter
+	if r.CPLoss < 0 {
+		r.CPLoss = 0
+	}
+	isBest := before.BestMove != nil && before.BestMove.String() == move.String()
+	r.Class = Classify(r.CPLoss, isBest)
+	return r
+}
@@ -0,0 +1,130 @@
+package analysis
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/intothevoid/nayan/pkg/engine"
+	"github.com/notnil/chess"
+)
+
+// fakeEvaluator returns canned evaluations keyed by position FEN.
+type fakeEvaluator struct {
+	evals map[string]*engine.Evaluation
+}
+
+func (f *fakeEvaluator) Evaluate(pos *chess.Position, depth int) (*engine.Evaluation, error) {
+	ev, ok := f.evals[pos.String()]
+	if !ok {
+		return nil, fmt.Errorf("no evaluation for %s", pos)
+	}
+	return ev, nil
+}
+
+// mustMove decodes a SAN move in pos.
+func mustMove(t *testing.T, pos *chess.Position, san string) *chess.Move {
+	t.Helper()
+	m, err := chess.AlgebraicNotation{}.Decode(pos, san)
+	if err != nil {
+		t.Fatalf("decode %s: %v", san, err)
+	}
+	return m
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		loss   int
+		isBest bool
+		want   Classification
+	}{
+		{0, false, Best},
+		{120, true, Best}, // engine's own move is always best
+		{10, false, Good},
+		{49, false, Good},
+		{50, false, Inaccuracy},
+		{99, false, Inaccuracy},
+		{100, false, Mistake},
+		{299, false, Mistake},
+		{300, false, Blunder},
+		{5000, false, Blunder},
+	}
+	for _, tt := range tests {
+		if got := Classify(tt.loss, tt.isBest); got != tt.want {
+			t.Errorf("Classify(%d, %v) = %s, want %s", tt.loss, tt.isBest, got, tt.want)
+		}
+	}
+}
+
+func TestReviewMoveBlunder(t *testing.T) {
+	pos := chess.StartingPosition()
+	e4 := mustMove(t, pos, "e4")
+	f3 := mustMove(t, pos, "f3")
+
+	after := pos.Update(f3)
+	ev := &fakeEvaluator{evals: map[string]*engine.Evaluation{
+		pos.String():   {CP: 30, BestMove: e4, PV: []*chess.Move{e4}},
+		after.String(): {CP: 400}, // opponent's point of view
+	}}
+
+	r, err := ReviewMove(ev, pos, f3, 10)
+	if err != nil {
+		t.Fatalf("ReviewMove failed: %v", err)
+	}
+	if r.EvalBefore != 30 || r.EvalAfter != -400 {
+		t.Errorf("evals = (%d, %d), want (30, -400)", r.EvalBefore, r.EvalAfter)
+	}
+	if r.CPLoss != 430 {
+		t.Errorf("CPLoss = %d, want 430", r.CPLoss)
+	}
+	if r.Class != Blunder {
+		t.Errorf("Class = %s, want Blunder", r.Class)
+	}
+	if r.BestMove != e4 {
+		t.Errorf("BestMove = %s, want e2e4", r.BestMove)
+	}
+}
+
+func TestReviewMoveBest(t *testing.T) {
+	pos := chess.StartingPosition()
+	e4 := mustMove(t, pos, "e4")
+
+	ev := &fakeEvaluator{evals: map[string]*engine.Evaluation{
+		pos.String():            {CP: 30, BestMove: e4},
+		pos.Update(e4).String(): {CP: -45}, // deeper search disagrees slightly
+	}}
+
+	r, err := ReviewMove(ev, pos, e4, 10)
+	if err != nil {
+		t.Fatalf("ReviewMove failed: %v", err)
+	}
+	if r.Class != Best {
+		t.Errorf("Class = %s, want Best", r.Class)
+	}
+	if r.CPLoss != 0 {
+		t.Errorf("CPLoss = %d, want 0 (gains are not losses)", r.CPLoss)
+	}
+}
+
+func TestReviewMoveMissedMate(t *testing.T) {
+	// White to move has Qxf7# (scholar's mate) but plays a quiet move.
+	fen, _ := chess.FEN("r1bqkb1r/pppp1ppp/2n2n2/4p2Q/2B1P3/8/PPPP1PPP/RNB1K1NR w KQkq - 4 4")
+	pos := chess.NewGame(fen).Position()
+	mate := mustMove(t, pos, "Qxf7#")
+	quiet := mustMove(t, pos, "Nc3")
+
+	ev := &fakeEvaluator{evals: map[string]*engine.Evaluation{
+		pos.String():               {Mate: 1, BestMove: mate},
+		pos.Update(quiet).String(): {CP: 250},
+	}}
+
+	r, err := ReviewMove(ev, pos, quiet, 10)
+	if err != nil {
+		t.Fatalf("ReviewMove failed: %v", err)
+	}
+	if r.Class != Blunder {
+		t.Errorf("Class = %s, want Blunder", r.Class)
+	}
+	if r.EvalBefore != engine.MateScore-1 {
+		t.Errorf("EvalBefore = %d, want %d", r.EvalBefore, engine.MateScore-1)
+	}
+}
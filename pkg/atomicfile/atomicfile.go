@@ -0,0 +1,28 @@
+// Package atomicfile replaces files in a single step, so programs polling
+// them never read one half written.
+package atomicfile
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Write replaces path with what write writes. The data goes to a temporary
+// file in the same directory, named from pattern as by os.CreateTemp, which
+// is then renamed over path.
+func Write(path, pattern string, write func(io.Writer) error) error {
+	f, err := os.CreateTemp(filepath.Dir(path), pattern)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // fails harmlessly once renamed
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,40 @@
+package atomicfile
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "live.pgn")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := Write(path, ".test-*", func(w io.Writer) error {
+		_, err := io.WriteString(w, "new")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new" {
+		t.Errorf("file holds %q, want new", data)
+	}
+
+	// A failed write leaves the old file in place.
+	failed := errors.New("encode failed")
+	if err := Write(path, ".test-*", func(w io.Writer) error { return failed }); !errors.Is(err, failed) {
+		t.Errorf("Write = %v, want %v", err, failed)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new" {
+		t.Errorf("file holds %q after a failed write, want new", data)
+	}
+	if tmp, _ := filepath.Glob(filepath.Join(dir, ".test-*")); len(tmp) != 0 {
+		t.Errorf("temporary files left behind: %v", tmp)
+	}
+}
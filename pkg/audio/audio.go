@@ -0,0 +1,153 @@
+package audio
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+//go:embed sounds/*.wav
+var sounds embed.FS
+
+// Sound is a short clip shipped with the app as 16-bit PCM WAV, which every
+// backend can play.
+type Sound string
+
+// Alert is the sound played while the board is in an invalid state.
+const Alert Sound = "alert"
+
+// Data returns the WAV bytes of the sound.
+func (s Sound) Data() ([]byte, error) {
+	return sounds.ReadFile("sounds/" + string(s) + ".wav")
+}
+
+// Player plays sounds. Play blocks until the sound has finished.
+type Player interface {
+	// Name identifies the backend, e.g. "paplay".
+	Name() string
+	Play(s Sound) error
+}
+
+// backend is an external program that plays a WAV file.
+type backend struct {
+	name string
+	args func(path string) []string // command line for playing path
+}
+
+// commandPlayer plays sounds by running a backend on a temp file.
+type commandPlayer struct {
+	backend
+
+	mu    sync.Mutex
+	files map[Sound]string // sound → extracted temp file
+}
+
+func (p *commandPlayer) Name() string { return p.name }
+
+func (p *commandPlayer) Play(s Sound) error {
+	path, err := p.file(s)
+	if err != nil {
+		return err
+	}
+	args := p.args(path)
+	if err := exec.Command(args[0], args[1:]...).Run(); err != nil {
+		return fmt.Errorf("%s: %w", p.name, err)
+	}
+	return nil
+}
+
+// file writes s to a temp file on first use, since players need a path.
+func (p *commandPlayer) file(s Sound) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if path, ok := p.files[s]; ok {
+		return path, nil
+	}
+	data, err := s.Data()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(os.TempDir(), "nayan-"+string(s)+".wav")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", err
+	}
+	if p.files == nil {
+		p.files = map[Sound]string{}
+	}
+	p.files[s] = path
+	return path, nil
+}
+
+// Command-line players, in order of preference per platform.
+var (
+	afplay = backend{"afplay", func(path string) []string { return []string{"afplay", path} }}
+	paplay = backend{"paplay", func(path string) []string { return []string{"paplay", path} }}
+	aplay  = backend{"aplay", func(path string) []string { return []string{"aplay", "-q", path} }}
+	winPS  = backend{"powershell", func(path string) []string {
+		return []string{"powershell", "-NoProfile", "-Command",
+			fmt.Sprintf("(New-Object Media.SoundPlayer '%s').PlaySync()", path)}
+	}}
+
+	platformBackends = map[string][]backend{
+		"darwin":  {afplay},
+		"linux":   {paplay, aplay},
+		"freebsd": {paplay, aplay},
+		"windows": {winPS},
+	}
+)
+
+// ErrNoPlayer is returned by Detect when no backend is available.
+var ErrNoPlayer = errors.New("audio: no sound player found")
+
+// Detect returns a player for this platform: afplay on macOS, PulseAudio's
+// paplay or ALSA's aplay on Linux, PowerShell on Windows. If none is
+// installed it returns a Nop player and ErrNoPlayer.
+func Detect() (Player, error) {
+	return detect(runtime.GOOS, exec.LookPath)
+}
+
+func detect(goos string, lookPath func(string) (string, error)) (Player, error) {
+	for _, b := range platformBackends[goos] {
+		if _, err := lookPath(b.args("")[0]); err == nil {
+			return &commandPlayer{backend: b}, nil
+		}
+	}
+	return Nop{}, ErrNoPlayer
+}
+
+// Nop is a player that plays nothing.
+type Nop struct{}
+
+func (Nop) Name() string       { return "none" }
+func (Nop) Play(s Sound) error { return nil }
+
+// Recorder is a player that records the sounds it is asked to play, for
+// tests.
+type Recorder struct {
+	mu     sync.Mutex
+	played []Sound
+}
+
+func (r *Recorder) Name() string { return "recorder" }
+
+func (r *Recorder) Play(s Sound) error {
+	if _, err := s.Data(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.played = append(r.played, s)
+	r.mu.Unlock()
+	return nil
+}
+
+// Played returns the sounds played so far, oldest first.
+func (r *Recorder) Played() []Sound {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Sound(nil), r.played...)
+}
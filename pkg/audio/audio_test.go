@@ -0,0 +1,66 @@
+package audio
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"testing"
+)
+
+func TestAlertIsWAV(t *testing.T) {
+	data, err := Alert.Data()
+	if err != nil {
+		t.Fatalf("Alert.Data() failed: %v", err)
+	}
+	if len(data) < 44 || !bytes.Equal(data[0:4], []byte("RIFF")) || !bytes.Equal(data[8:12], []byte("WAVE")) {
+		t.Error("alert sound is not a RIFF/WAVE file")
+	}
+}
+
+func TestDetect(t *testing.T) {
+	installed := func(names ...string) func(string) (string, error) {
+		return func(file string) (string, error) {
+			for _, n := range names {
+				if n == file {
+					return "/usr/bin/" + n, nil
+				}
+			}
+			return "", exec.ErrNotFound
+		}
+	}
+
+	tests := []struct {
+		goos      string
+		installed []string
+		want      string
+	}{
+		{"darwin", []string{"afplay"}, "afplay"},
+		{"linux", []string{"paplay", "aplay"}, "paplay"},
+		{"linux", []string{"aplay"}, "aplay"},
+		{"windows", []string{"powershell"}, "powershell"},
+		{"linux", nil, "none"},
+		{"plan9", []string{"aplay"}, "none"},
+	}
+	for _, tt := range tests {
+		p, err := detect(tt.goos, installed(tt.installed...))
+		if p.Name() != tt.want {
+			t.Errorf("detect(%s, %v) = %s, want %s", tt.goos, tt.installed, p.Name(), tt.want)
+		}
+		if (tt.want == "none") != errors.Is(err, ErrNoPlayer) {
+			t.Errorf("detect(%s, %v) error = %v", tt.goos, tt.installed, err)
+		}
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	var r Recorder
+	if err := r.Play(Alert); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Play(Sound("missing")); err == nil {
+		t.Error("expected an error for an unknown sound")
+	}
+	if got := r.Played(); len(got) != 1 || got[0] != Alert {
+		t.Errorf("Played() = %v, want [alert]", got)
+	}
+}
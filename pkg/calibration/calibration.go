@@ -0,0 +1,81 @@
+package calibration
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+)
+
+// Calibration records where the board's corners appear in the camera image,
+// so a fixed camera need only be calibrated once.
+type Calibration struct {
+	Device  int            `json:"device"`  // camera the corners were picked on
+	Corners [4]image.Point `json:"corners"` // top-left, top-right, bottom-right, bottom-left
+}
+
+// ErrNotCalibrated is returned by Load when no calibration has been saved.
+var ErrNotCalibrated = errors.New("calibration: board has not been calibrated")
+
+// DefaultPath returns the per-user location of the saved calibration.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "nayan", "calibration.json"), nil
+}
+
+// New returns a calibration from corners in the order vision.ReorderPoints
+// gives them.
+func New(device int, corners []image.Point) (*Calibration, error) {
+	if len(corners) != 4 {
+		return nil, fmt.Errorf("calibration: need 4 corners, got %d", len(corners))
+	}
+	c := &Calibration{Device: device}
+	copy(c.Corners[:], corners)
+	return c, nil
+}
+
+// Points returns the corners as a slice, as vision.WarpBoard takes them.
+func (c *Calibration) Points() []image.Point {
+	return append([]image.Point(nil), c.Corners[:]...)
+}
+
+// Load reads the calibration at path, returning ErrNotCalibrated if there is
+// none.
+func Load(path string) (*Calibration, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotCalibrated
+	}
+	if err != nil {
+		return nil, err
+	}
+	var c Calibration
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for i, p := range c.Corners {
+		for _, q := range c.Corners[i+1:] {
+			if p == q {
+				return nil, fmt.Errorf("%s: corners must be distinct", path)
+			}
+		}
+	}
+	return &c, nil
+}
+
+// Save writes c to path, creating its directory if needed.
+func (c *Calibration) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
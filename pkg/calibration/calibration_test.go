@@ -0,0 +1,52 @@
+package calibration
+
+import (
+	"errors"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nayan", "calibration.json")
+	if _, err := Load(path); !errors.Is(err, ErrNotCalibrated) {
+		t.Fatalf("Load before saving = %v, want ErrNotCalibrated", err)
+	}
+
+	corners := []image.Point{{10, 12}, {600, 8}, {610, 470}, {5, 460}}
+	c, err := New(1, corners)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != *c {
+		t.Errorf("loaded %+v, want %+v", got, c)
+	}
+	if pts := got.Points(); len(pts) != 4 || pts[2] != corners[2] {
+		t.Errorf("Points() = %v, want %v", pts, corners)
+	}
+}
+
+func TestNewNeedsFourCorners(t *testing.T) {
+	if _, err := New(0, []image.Point{{1, 1}, {2, 2}, {3, 3}}); err == nil {
+		t.Error("New with 3 corners succeeded")
+	}
+}
+
+func TestLoadRejectsDuplicateCorners(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calibration.json")
+	data := `{"device": 0, "corners": [{"X": 1, "Y": 1}, {"X": 1, "Y": 1}, {"X": 5, "Y": 5}, {"X": 0, "Y": 5}]}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Load accepted duplicate corners")
+	}
+}
@@ -3,6 +3,7 @@ package camera
 import (
 	"fmt"
 	"image"
+	"io"
 
 	"gocv.io/x/gocv"
 )
@@ -10,20 +11,28 @@ import (
 // VideoStream manages the webcam connection
 type VideoStream struct {
 	deviceID int
+	file     bool // a recorded session rather than a live camera
 	webcam   *gocv.VideoCapture
 	frame    *gocv.Mat // Keep a reusable matrix to save memory
+	recorder *gocv.VideoWriter
 }
 
-// NewVideoStream initializes the camera
+// NewVideoStream initializes the camera at 640x480
 func NewVideoStream(id int) (*VideoStream, error) {
+	return NewVideoStreamSize(id, 640, 480)
+}
+
+// NewVideoStreamSize initializes the camera, requesting the given frame
+// size. Cameras that do not support it pick the nearest they do.
+func NewVideoStreamSize(id, width, height int) (*VideoStream, error) {
 	cam, err := gocv.VideoCaptureDevice(id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open device: %v", err)
 	}
 
-	// Optional: Set resolution (keeps processing fast)
-	cam.Set(gocv.VideoCaptureFrameWidth, 640)
-	cam.Set(gocv.VideoCaptureFrameHeight, 480)
+	// Smaller frames keep processing fast
+	cam.Set(gocv.VideoCaptureFrameWidth, float64(width))
+	cam.Set(gocv.VideoCaptureFrameHeight, float64(height))
 
 	mat := gocv.NewMat()
 	return &VideoStream{
@@ -33,6 +42,45 @@ func NewVideoStream(id int) (*VideoStream, error) {
 	}, nil
 }
 
+// NewVideoFile opens a session recorded with Record, for replaying through
+// the vision pipeline. ReadRaw returns io.EOF after the last frame.
+func NewVideoFile(path string) (*VideoStream, error) {
+	cam, err := gocv.VideoCaptureFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	mat := gocv.NewMat()
+	return &VideoStream{
+		deviceID: -1,
+		file:     true,
+		webcam:   cam,
+		frame:    &mat,
+	}, nil
+}
+
+// FPS returns the frame rate reported by the camera or file, or 0 if it is
+// not known.
+func (vs *VideoStream) FPS() float64 {
+	return vs.webcam.Get(gocv.VideoCaptureFPS)
+}
+
+// Record saves every frame read from now on to path as Motion JPEG (use an
+// .avi name), so the session can be replayed with NewVideoFile.
+func (vs *VideoStream) Record(path string) error {
+	fps := vs.FPS()
+	if fps <= 0 {
+		fps = 30
+	}
+	width := int(vs.webcam.Get(gocv.VideoCaptureFrameWidth))
+	height := int(vs.webcam.Get(gocv.VideoCaptureFrameHeight))
+	w, err := gocv.VideoWriterFile(path, "MJPG", fps, width, height, true)
+	if err != nil {
+		return fmt.Errorf("failed to record to %s: %v", path, err)
+	}
+	vs.recorder = w
+	return nil
+}
+
 // Read returns the current frame as a standard Go image
 // This is crucial for Fyne compatibility!
 func (vs *VideoStream) Read() (image.Image, error) {
@@ -54,12 +102,23 @@ func (vs *VideoStream) Read() (image.Image, error) {
 // ReadRaw reads the current frame as a gocv.Mat
 func (vs *VideoStream) ReadRaw() (*gocv.Mat, error) {
 	if !vs.webcam.Read(vs.frame) {
+		if vs.file {
+			return nil, io.EOF
+		}
 		return nil, fmt.Errorf("cannot read frame")
 	}
+	if vs.recorder != nil && !vs.frame.Empty() {
+		if err := vs.recorder.Write(*vs.frame); err != nil {
+			return nil, err
+		}
+	}
 	return vs.frame, nil
 }
 
 func (vs *VideoStream) Close() {
+	if vs.recorder != nil {
+		vs.recorder.Close()
+	}
 	vs.webcam.Close()
 	vs.frame.Close()
 }
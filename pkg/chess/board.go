@@ -2,6 +2,8 @@ package chess
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/notnil/chess"
 )
@@ -48,6 +50,11 @@ func (gs *GameState) Game() *chess.Game {
 	return gs.game
 }
 
+// Clone returns a copy of the game state that later moves leave unchanged.
+func (gs *GameState) Clone() *GameState {
+	return &GameState{game: gs.game.Clone(), HumanColor: gs.HumanColor}
+}
+
 // FEN returns the FEN string of the current position.
 func (gs *GameState) FEN() string {
 	return gs.game.FEN()
@@ -83,6 +90,17 @@ func (gs *GameState) Outcome() string {
 	}
 }
 
+// ClaimableDraw reports whether the side to move may claim a draw, and on
+// what grounds: threefold repetition or the fifty-move rule.
+func (gs *GameState) ClaimableDraw() (chess.Method, bool) {
+	for _, m := range gs.game.EligibleDraws() {
+		if m == chess.ThreefoldRepetition || m == chess.FiftyMoveRule {
+			return m, true
+		}
+	}
+	return chess.NoMethod, false
+}
+
 // MoveToAlgebraic returns standard algebraic notation for a move.
 func (gs *GameState) MoveToAlgebraic(m *chess.Move) string {
 	return chess.AlgebraicNotation{}.Encode(gs.game.Position(), m)
@@ -219,11 +237,69 @@ func (gs *GameState) OccupancyAfterMove(m *chess.Move) [8][8]bool {
 	return occupancyFromBoard(simPos.Board())
 }
 
+// MovesBetween returns the legal moves from one square to another, given in
+// vision grid coordinates. A pawn reaching the last rank yields one move per
+// promotion piece; otherwise there is at most one.
+func (gs *GameState) MovesBetween(fromRow, fromCol, toRow, toCol int) []*chess.Move {
+	from := SquareFromRowCol(fromRow, fromCol)
+	to := SquareFromRowCol(toRow, toCol)
+	var moves []*chess.Move
+	for _, m := range gs.game.ValidMoves() {
+		if m.S1() == from && m.S2() == to {
+			moves = append(moves, m)
+		}
+	}
+	return moves
+}
+
 // ApplyMove applies a move to the game state.
 func (gs *GameState) ApplyMove(m *chess.Move) error {
 	return gs.game.Move(m)
 }
 
+// TakeBack undoes the last n moves by replaying the game without them from
+// its starting position.
+func (gs *GameState) TakeBack(n int) error {
+	moves := gs.game.Moves()
+	if n < 0 || n > len(moves) {
+		return fmt.Errorf("cannot take back %d of %d moves", n, len(moves))
+	}
+	opt, err := chess.FEN(gs.game.Positions()[0].String())
+	if err != nil {
+		return err
+	}
+	game := chess.NewGame(opt)
+	for _, m := range moves[:len(moves)-n] {
+		if err := game.Move(m); err != nil {
+			return err
+		}
+	}
+	gs.game = game
+	return nil
+}
+
+// Resign ends the game with c resigning.
+func (gs *GameState) Resign(c chess.Color) {
+	gs.game.Resign(c)
+}
+
+// SANMoves returns the moves played so far in standard algebraic notation.
+func (gs *GameState) SANMoves() []string {
+	positions := gs.game.Positions()
+	moves := gs.game.Moves()
+	sans := make([]string, len(moves))
+	for i, m := range moves {
+		sans[i] = chess.AlgebraicNotation{}.Encode(positions[i], m)
+	}
+	return sans
+}
+
+// StartPly returns the number of plies played before the game's first move,
+// as StartPly does for its starting position.
+func (gs *GameState) StartPly() int {
+	return StartPly(gs.game.Positions()[0])
+}
+
 // PieceGrid returns the current board as an 8x8 grid of chess.Piece values.
 // Row 0 = rank 8 (top), col 0 = file a (left).
 func (gs *GameState) PieceGrid() [8][8]chess.Piece {
@@ -271,6 +347,28 @@ func PieceGridFromPosition(pos *chess.Position) [8][8]chess.Piece {
 	return grid
 }
 
+// StartPly returns the number of plies that lead to pos from the standard
+// starting position, going by its side to move and full-move number: 0 for
+// White to move on move 1, 1 for Black to move on move 1, and so on. A game
+// from pos numbers its move at ply i (0-based) as (StartPly+i)/2+1.
+func StartPly(pos *chess.Position) int {
+	ply := 2 * (fullMoveNumber(pos) - 1)
+	if pos.Turn() == chess.Black {
+		ply++
+	}
+	return ply
+}
+
+// fullMoveNumber returns pos's full-move number, the last field of its FEN.
+func fullMoveNumber(pos *chess.Position) int {
+	fields := strings.Fields(pos.String())
+	n, err := strconv.Atoi(fields[len(fields)-1])
+	if err != nil || n < 1 {
+		return 1
+	}
+	return n
+}
+
 // occupancyFromBoard generates an occupancy grid from a chess.Board.
 func occupancyFromBoard(board *chess.Board) [8][8]bool {
 	var occ [8][8]bool
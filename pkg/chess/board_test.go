@@ -208,3 +208,137 @@ func TestInferMoveWithColorUnambiguous(t *testing.T) {
 		t.Errorf("expected e2e4, got %s%s", move.S1(), move.S2())
 	}
 }
+
+func TestSANMoves(t *testing.T) {
+	gs := NewGame(White)
+	for _, san := range []string{"e4", "e5", "Nf3", "Nc6", "Bb5", "a6"} {
+		if err := gs.Game().MoveStr(san); err != nil {
+			t.Fatalf("MoveStr(%s) failed: %v", san, err)
+		}
+	}
+
+	got := gs.SANMoves()
+	want := []string{"e4", "e5", "Nf3", "Nc6", "Bb5", "a6"}
+	if len(got) != len(want) {
+		t.Fatalf("SANMoves() returned %d moves, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("move %d = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestMovesBetween(t *testing.T) {
+	gs := NewGame(White)
+
+	// e2→e4
+	if moves := gs.MovesBetween(6, 4, 4, 4); len(moves) != 1 || moves[0].String() != "e2e4" {
+		t.Errorf("MovesBetween(e2, e4) = %v, want [e2e4]", moves)
+	}
+	// e2→e5 is illegal
+	if moves := gs.MovesBetween(6, 4, 3, 4); len(moves) != 0 {
+		t.Errorf("MovesBetween(e2, e5) = %v, want none", moves)
+	}
+
+	// White pawn on b7 can capture on a8 and promote.
+	fen, err := chess.FEN("r3k3/1P6/8/8/8/8/8/4K3 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs.game = chess.NewGame(fen)
+	if moves := gs.MovesBetween(1, 1, 0, 0); len(moves) != 4 {
+		t.Errorf("MovesBetween(b7, a8) returned %d moves, want 4 promotions", len(moves))
+	}
+}
+
+func TestClaimableDraw(t *testing.T) {
+	gs := NewGame(White)
+	if _, ok := gs.ClaimableDraw(); ok {
+		t.Error("no draw should be claimable at the start")
+	}
+	// Shuffle the knights out and back twice: the start position occurs
+	// for the third time.
+	for i := 0; i < 2; i++ {
+		for _, san := range []string{"Nf3", "Nf6", "Ng1", "Ng8"} {
+			if err := gs.Game().MoveStr(san); err != nil {
+				t.Fatalf("MoveStr(%s) failed: %v", san, err)
+			}
+		}
+	}
+	if m, ok := gs.ClaimableDraw(); !ok || m != chess.ThreefoldRepetition {
+		t.Errorf("ClaimableDraw() = %s, %v, want threefold repetition", m, ok)
+	}
+}
+
+func TestTakeBack(t *testing.T) {
+	gs := NewGame(White)
+	for _, san := range []string{"e4", "e5", "Nf3", "Nc6"} {
+		if err := gs.Game().MoveStr(san); err != nil {
+			t.Fatalf("MoveStr(%s) failed: %v", san, err)
+		}
+	}
+	if err := gs.TakeBack(2); err != nil {
+		t.Fatalf("TakeBack(2) failed: %v", err)
+	}
+	if got := gs.SANMoves(); len(got) != 2 || got[1] != "e5" {
+		t.Errorf("after TakeBack(2) moves = %v, want [e4 e5]", got)
+	}
+	if !gs.IsHumanTurn() {
+		t.Error("White should be to move after taking back two moves")
+	}
+	if err := gs.TakeBack(3); err == nil {
+		t.Error("TakeBack(3) with two moves played should fail")
+	}
+
+	// Games set up from a position keep their starting position.
+	setup, err := SetupFromFEN("4k3/8/8/8/8/8/4P3/4K3 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs, err = setup.NewGame(White)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gs.Game().MoveStr("e4"); err != nil {
+		t.Fatal(err)
+	}
+	if err := gs.TakeBack(1); err != nil {
+		t.Fatalf("TakeBack(1) failed: %v", err)
+	}
+	if got := gs.FEN(); got != "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1" {
+		t.Errorf("FEN after TakeBack = %s", got)
+	}
+}
+
+func TestResign(t *testing.T) {
+	gs := NewGame(White)
+	gs.Resign(chess.White)
+	if !gs.IsGameOver() || gs.Game().Outcome() != chess.BlackWon || gs.Game().Method() != chess.Resignation {
+		t.Errorf("after White resigns: %s", gs.Outcome())
+	}
+}
+
+func TestStartPly(t *testing.T) {
+	for _, tt := range []struct {
+		fen  string
+		want int
+	}{
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", 0},
+		{"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1", 1},
+		{"4k3/8/8/8/8/8/4P3/4K3 w - - 0 12", 22},
+		{"4k3/8/8/8/8/8/4P3/4K3 b - - 3 40", 79},
+	} {
+		setup, err := SetupFromFEN(tt.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gs, err := setup.NewGame(White)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := gs.StartPly(); got != tt.want {
+			t.Errorf("StartPly(%s) = %d, want %d", tt.fen, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package chess
+
+import (
+	"sort"
+
+	"github.com/notnil/chess"
+)
+
+// materialValue is the conventional value of each piece type in pawns.
+var materialValue = map[chess.PieceType]int{
+	chess.Pawn:   1,
+	chess.Knight: 3,
+	chess.Bishop: 3,
+	chess.Rook:   5,
+	chess.Queen:  9,
+}
+
+// Captures lists the pieces each side has taken, most valuable first.
+type Captures struct {
+	ByWhite []chess.Piece // black pieces captured by White
+	ByBlack []chess.Piece // white pieces captured by Black
+}
+
+// Captures returns the pieces captured so far.
+func (gs *GameState) Captures() Captures {
+	return gs.CapturesAt(len(gs.game.Moves()))
+}
+
+// CapturesAt returns the pieces captured in the first ply moves of the
+// game, found by replaying the position history.
+func (gs *GameState) CapturesAt(ply int) Captures {
+	positions := gs.game.Positions()
+	moves := gs.game.Moves()
+	if ply > len(moves) {
+		ply = len(moves)
+	}
+
+	var c Captures
+	for i := 0; i < ply; i++ {
+		m := moves[i]
+		// notnil/chess tags en passant separately from ordinary captures
+		if !m.HasTag(chess.Capture) && !m.HasTag(chess.EnPassant) {
+			continue
+		}
+		pos := positions[i]
+		captured := pos.Board().Piece(m.S2())
+		if m.HasTag(chess.EnPassant) {
+			// The captured pawn is not on the destination square
+			captured = chess.NewPiece(chess.Pawn, pos.Turn().Other())
+		}
+		if pos.Turn() == chess.White {
+			c.ByWhite = append(c.ByWhite, captured)
+		} else {
+			c.ByBlack = append(c.ByBlack, captured)
+		}
+	}
+	sortByValue(c.ByWhite)
+	sortByValue(c.ByBlack)
+	return c
+}
+
+// sortByValue orders pieces most valuable first (queens, rooks, bishops,
+// knights, pawns).
+func sortByValue(pieces []chess.Piece) {
+	sort.SliceStable(pieces, func(i, j int) bool {
+		vi, vj := materialValue[pieces[i].Type()], materialValue[pieces[j].Type()]
+		if vi != vj {
+			return vi > vj
+		}
+		// Bishops before knights
+		return pieces[i].Type() < pieces[j].Type()
+	})
+}
+
+// MaterialBalance returns White's material minus Black's in pawns, counted
+// from the pieces on the board so promotions are included.
+func MaterialBalance(pos *chess.Position) int {
+	board := pos.Board()
+	balance := 0
+	for sq := chess.A1; sq <= chess.H8; sq++ {
+		p := board.Piece(sq)
+		if p == chess.NoPiece {
+			continue
+		}
+		if p.Color() == chess.White {
+			balance += materialValue[p.Type()]
+		} else {
+			balance -= materialValue[p.Type()]
+		}
+	}
+	return balance
+}
@@ -0,0 +1,50 @@
+package chess
+
+import (
+	"testing"
+
+	"github.com/notnil/chess"
+)
+
+// playSAN plays the given moves on a new game.
+func playSAN(t *testing.T, sans ...string) *GameState {
+	t.Helper()
+	gs := NewGame(White)
+	for _, san := range sans {
+		if err := gs.Game().MoveStr(san); err != nil {
+			t.Fatalf("MoveStr(%s) failed: %v", san, err)
+		}
+	}
+	return gs
+}
+
+func TestCaptures(t *testing.T) {
+	// 3.Bxc6 dxc6 4.Nxe5: White takes a knight and a pawn, Black a bishop
+	gs := playSAN(t, "e4", "e5", "Nf3", "Nc6", "Bb5", "a6", "Bxc6", "dxc6", "Nxe5")
+
+	c := gs.Captures()
+	if len(c.ByWhite) != 2 || c.ByWhite[0] != chess.BlackKnight || c.ByWhite[1] != chess.BlackPawn {
+		t.Errorf("ByWhite = %v, want [n p]", c.ByWhite)
+	}
+	if len(c.ByBlack) != 1 || c.ByBlack[0] != chess.WhiteBishop {
+		t.Errorf("ByBlack = %v, want [B]", c.ByBlack)
+	}
+	if got := MaterialBalance(gs.Game().Position()); got != 1 {
+		t.Errorf("MaterialBalance = %d, want 1", got)
+	}
+
+	// Before 4.Nxe5 the material is level
+	c = gs.CapturesAt(8)
+	if len(c.ByWhite) != 1 || len(c.ByBlack) != 1 {
+		t.Errorf("CapturesAt(8) = %v, want one capture each", c)
+	}
+}
+
+func TestCapturesEnPassant(t *testing.T) {
+	gs := playSAN(t, "e4", "a6", "e5", "d5", "exd6")
+
+	c := gs.Captures()
+	if len(c.ByWhite) != 1 || c.ByWhite[0] != chess.BlackPawn {
+		t.Errorf("ByWhite = %v, want [p]", c.ByWhite)
+	}
+}
@@ -0,0 +1,273 @@
+package chess
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/notnil/chess"
+)
+
+// Setup is an arbitrary position being composed in the position editor.
+// Unlike a GameState it may be illegal; call Validate before playing it.
+type Setup struct {
+	Board [8][8]chess.Piece // row 0 = rank 8, col 0 = file a
+	Turn  chess.Color
+
+	WhiteKingside, WhiteQueenside bool
+	BlackKingside, BlackQueenside bool
+
+	EnPassant chess.Square // target square, chess.NoSquare if none
+
+	HalfMoveClock int // plies since the last capture or pawn move
+	MoveNumber    int // full-move number, 1 if unset
+}
+
+// StartingSetup returns the standard starting position.
+func StartingSetup() Setup {
+	s, _ := SetupFromFEN(chess.StartingPosition().String())
+	return s
+}
+
+// SetupFromFEN parses a FEN string into a Setup. Only the syntax is checked.
+func SetupFromFEN(fen string) (Setup, error) {
+	opt, err := chess.FEN(fen)
+	if err != nil {
+		return Setup{}, err
+	}
+	pos := chess.NewGame(opt).Position()
+	rights := pos.CastleRights()
+	return Setup{
+		Board:          PieceGridFromPosition(pos),
+		Turn:           pos.Turn(),
+		WhiteKingside:  rights.CanCastle(chess.White, chess.KingSide),
+		WhiteQueenside: rights.CanCastle(chess.White, chess.QueenSide),
+		BlackKingside:  rights.CanCastle(chess.Black, chess.KingSide),
+		BlackQueenside: rights.CanCastle(chess.Black, chess.QueenSide),
+		EnPassant:      pos.EnPassantSquare(),
+		HalfMoveClock:  pos.HalfMoveClock(),
+		MoveNumber:     fullMoveNumber(pos),
+	}, nil
+}
+
+// FEN returns the position in Forsyth-Edwards Notation.
+func (s Setup) FEN() string {
+	var b strings.Builder
+	for row := 0; row < 8; row++ {
+		empty := 0
+		for col := 0; col < 8; col++ {
+			p := s.Board[row][col]
+			if p == chess.NoPiece {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				fmt.Fprint(&b, empty)
+				empty = 0
+			}
+			letter := p.Type().String()
+			if p.Color() == chess.White {
+				letter = strings.ToUpper(letter)
+			}
+			b.WriteString(letter)
+		}
+		if empty > 0 {
+			fmt.Fprint(&b, empty)
+		}
+		if row < 7 {
+			b.WriteByte('/')
+		}
+	}
+
+	turn := "w"
+	if s.Turn == chess.Black {
+		turn = "b"
+	}
+
+	castling := ""
+	for _, r := range []struct {
+		ok     bool
+		letter string
+	}{
+		{s.WhiteKingside, "K"}, {s.WhiteQueenside, "Q"},
+		{s.BlackKingside, "k"}, {s.BlackQueenside, "q"},
+	} {
+		if r.ok {
+			castling += r.letter
+		}
+	}
+	if castling == "" {
+		castling = "-"
+	}
+
+	ep := "-"
+	if s.EnPassant != chess.NoSquare {
+		ep = s.EnPassant.String()
+	}
+
+	return fmt.Sprintf("%s %s %s %s %d %d", b.String(), turn, castling, ep, s.HalfMoveClock, max(s.MoveNumber, 1))
+}
+
+// Validate reports why the position cannot be played, or nil if it can.
+func (s Setup) Validate() error {
+	if s.Turn != chess.White && s.Turn != chess.Black {
+		return errors.New("side to move is not set")
+	}
+
+	var kings [3]int // indexed by chess.Color
+	var kingSq [3][2]int
+	for row := 0; row < 8; row++ {
+		for col := 0; col < 8; col++ {
+			p := s.Board[row][col]
+			switch {
+			case p.Type() == chess.King:
+				kings[p.Color()]++
+				kingSq[p.Color()] = [2]int{row, col}
+			case p.Type() == chess.Pawn && (row == 0 || row == 7):
+				return fmt.Errorf("pawn on %s: pawns cannot stand on the first or last rank", SquareFromRowCol(row, col))
+			}
+		}
+	}
+	for _, c := range []chess.Color{chess.White, chess.Black} {
+		if kings[c] != 1 {
+			return fmt.Errorf("%s must have exactly one king (has %d)", colorName(c), kings[c])
+		}
+	}
+
+	waiting := s.Turn.Other()
+	if s.attacked(kingSq[waiting][0], kingSq[waiting][1], s.Turn) {
+		return fmt.Errorf("%s is in check but it is %s's move", colorName(waiting), colorName(s.Turn))
+	}
+
+	castles := []struct {
+		ok        bool
+		color     chess.Color
+		rookCol   int
+		homeRow   int
+		rightName string
+	}{
+		{s.WhiteKingside, chess.White, 7, 7, "White O-O"},
+		{s.WhiteQueenside, chess.White, 0, 7, "White O-O-O"},
+		{s.BlackKingside, chess.Black, 7, 0, "Black O-O"},
+		{s.BlackQueenside, chess.Black, 0, 0, "Black O-O-O"},
+	}
+	for _, c := range castles {
+		if !c.ok {
+			continue
+		}
+		if s.Board[c.homeRow][4] != chess.NewPiece(chess.King, c.color) ||
+			s.Board[c.homeRow][c.rookCol] != chess.NewPiece(chess.Rook, c.color) {
+			return fmt.Errorf("%s needs the king and rook on their starting squares", c.rightName)
+		}
+	}
+
+	if s.EnPassant != chess.NoSquare {
+		if err := s.validateEnPassant(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateEnPassant checks that the en passant target square could follow a
+// double pawn push by the side that just moved.
+func (s Setup) validateEnPassant() error {
+	row, col := RowColFromSquare(s.EnPassant)
+	// Target square is on rank 6 when White is to move (row 2), rank 3
+	// when Black is (row 5); the pushed pawn is one row beyond it.
+	targetRow, pawnRow, originRow := 2, 3, 1
+	if s.Turn == chess.Black {
+		targetRow, pawnRow, originRow = 5, 4, 6
+	}
+	pushed := chess.NewPiece(chess.Pawn, s.Turn.Other())
+	switch {
+	case row != targetRow:
+		return fmt.Errorf("en passant square %s is on the wrong rank for %s to move", s.EnPassant, colorName(s.Turn))
+	case s.Board[pawnRow][col] != pushed:
+		return fmt.Errorf("en passant square %s has no %s pawn in front of it", s.EnPassant, colorName(s.Turn.Other()))
+	case s.Board[row][col] != chess.NoPiece || s.Board[originRow][col] != chess.NoPiece:
+		return fmt.Errorf("en passant square %s: the pawn cannot have just moved two squares", s.EnPassant)
+	}
+	return nil
+}
+
+// NewGame validates the position and starts a game from it.
+func (s Setup) NewGame(humanColor Color) (*GameState, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	opt, err := chess.FEN(s.FEN())
+	if err != nil {
+		return nil, err
+	}
+	return &GameState{game: chess.NewGame(opt), HumanColor: humanColor}, nil
+}
+
+// attacked reports whether the square at (row, col) is attacked by a piece
+// of colour by.
+func (s Setup) attacked(row, col int, by chess.Color) bool {
+	at := func(r, c int) chess.Piece {
+		if r < 0 || r > 7 || c < 0 || c > 7 {
+			return chess.NoPiece
+		}
+		return s.Board[r][c]
+	}
+	is := func(p chess.Piece, types ...chess.PieceType) bool {
+		if p.Color() != by {
+			return false
+		}
+		for _, t := range types {
+			if p.Type() == t {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Pawns attack diagonally forwards: White pawns sit one row below
+	// (higher row index) the squares they attack.
+	pawnRow := row + 1
+	if by == chess.Black {
+		pawnRow = row - 1
+	}
+	if is(at(pawnRow, col-1), chess.Pawn) || is(at(pawnRow, col+1), chess.Pawn) {
+		return true
+	}
+
+	for _, d := range [][2]int{{1, 2}, {2, 1}, {-1, 2}, {-2, 1}, {1, -2}, {2, -1}, {-1, -2}, {-2, -1}} {
+		if is(at(row+d[0], col+d[1]), chess.Knight) {
+			return true
+		}
+	}
+	for dr := -1; dr <= 1; dr++ {
+		for dc := -1; dc <= 1; dc++ {
+			if (dr != 0 || dc != 0) && is(at(row+dr, col+dc), chess.King) {
+				return true
+			}
+		}
+	}
+
+	slide := func(dirs [][2]int, types ...chess.PieceType) bool {
+		for _, d := range dirs {
+			for r, c := row+d[0], col+d[1]; r >= 0 && r < 8 && c >= 0 && c < 8; r, c = r+d[0], c+d[1] {
+				if p := s.Board[r][c]; p != chess.NoPiece {
+					if is(p, types...) {
+						return true
+					}
+					break
+				}
+			}
+		}
+		return false
+	}
+	return slide([][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}}, chess.Rook, chess.Queen) ||
+		slide([][2]int{{1, 1}, {1, -1}, {-1, 1}, {-1, -1}}, chess.Bishop, chess.Queen)
+}
+
+// colorName returns "White" or "Black".
+func colorName(c chess.Color) string {
+	if c == chess.Black {
+		return "Black"
+	}
+	return "White"
+}
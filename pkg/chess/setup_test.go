@@ -0,0 +1,82 @@
+package chess
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/notnil/chess"
+)
+
+func TestSetupFENRoundTrip(t *testing.T) {
+	for _, fen := range []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"r3k2r/8/8/3pP3/8/8/8/R3K2R w Kq d6 0 1",
+		"8/8/4k3/8/8/4K3/8/8 b - - 0 1",
+		"8/8/4k3/8/8/4K3/8/8 b - - 17 52",
+	} {
+		s, err := SetupFromFEN(fen)
+		if err != nil {
+			t.Fatalf("SetupFromFEN(%q) failed: %v", fen, err)
+		}
+		if got := s.FEN(); got != fen {
+			t.Errorf("FEN() = %q, want %q", got, fen)
+		}
+	}
+}
+
+func TestSetupValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		fen     string
+		wantErr string // substring, "" = valid
+	}{
+		{"starting position", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", ""},
+		{"missing black king", "8/8/8/8/8/8/8/4K3 w - - 0 1", "Black must have exactly one king"},
+		{"two white kings", "4k3/8/8/8/8/8/8/3KK3 w - - 0 1", "White must have exactly one king"},
+		{"pawn on last rank", "P3k3/8/8/8/8/8/8/4K3 w - - 0 1", "pawn on a8"},
+		{"rook beside own king", "4k3/8/8/8/8/8/8/4KR2 w - - 0 1", ""},
+		{"side not to move in check", "4k3/4R3/8/8/8/8/8/4K3 w - - 0 1", "Black is in check"},
+		{"knight check", "4k3/8/3N4/8/8/8/8/4K3 w - - 0 1", "Black is in check"},
+		{"pawn check", "4k3/8/8/8/8/8/5p2/4K3 b - - 0 1", "White is in check"},
+		{"castling without rook", "4k3/8/8/8/8/8/8/4K3 w K - 0 1", "White O-O"},
+		{"castling with rook", "4k3/8/8/8/8/8/8/4K2R w K - 0 1", ""},
+		{"valid en passant", "4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", ""},
+		{"en passant wrong rank", "4k3/8/8/3pP3/8/8/8/4K3 w - d3 0 1", "wrong rank"},
+		{"en passant without pawn", "4k3/8/8/4P3/8/8/8/4K3 w - d6 0 1", "no Black pawn"},
+	}
+	for _, tt := range tests {
+		s, err := SetupFromFEN(tt.fen)
+		if err != nil {
+			t.Fatalf("%s: SetupFromFEN failed: %v", tt.name, err)
+		}
+		err = s.Validate()
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: Validate() = %v, want nil", tt.name, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: Validate() = %v, want error containing %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSetupNewGame(t *testing.T) {
+	s := StartingSetup()
+	s.Board[6][4] = chess.NoPiece // remove e2
+	s.Turn = chess.Black
+
+	gs, err := s.NewGame(White)
+	if err != nil {
+		t.Fatalf("NewGame failed: %v", err)
+	}
+	if gs.IsHumanTurn() {
+		t.Error("expected Black (the engine) to move first")
+	}
+	if want := "rnbqkbnr/pppppppp/8/8/8/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1"; gs.FEN() != want {
+		t.Errorf("FEN() = %q, want %q", gs.FEN(), want)
+	}
+
+	s.Board[0][4] = chess.NoPiece // remove Black's king
+	if _, err := s.NewGame(White); err == nil {
+		t.Error("expected NewGame to reject a position without a black king")
+	}
+}
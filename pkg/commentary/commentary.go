@@ -0,0 +1,252 @@
+package commentary
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/notnil/chess"
+)
+
+// Language holds the phrases used to describe moves in one language.
+//
+// Templates may use the placeholders {color}, {piece}, {square} and, in
+// captures, {captured}, so each language can order the words its own way.
+type Language struct {
+	Code string // ISO 639-1 code, e.g. "de"
+	Name string // name of the language in itself, e.g. "Deutsch"
+
+	White, Black string
+	Pieces       map[chess.PieceType]string
+
+	// Moves already played (past or present tense).
+	Move, Capture           string
+	CastleKing, CastleQueen string
+
+	// Moves about to be played: engine recommendations and CPU moves.
+	MovePre, CapturePre           string
+	CastleKingPre, CastleQueenPre string
+
+	// Captures naming the captured piece as {captured}.
+	CaptureNamed, CaptureNamedPre string
+
+	// Appended to a move: after a space for en passant, after a comma for
+	// a promotion ({piece} = the new piece), and after a sentence break
+	// when the move checks, mates or stalemates.
+	EnPassant string
+	Promotion string
+	Check     string
+	Checkmate string
+	Stalemate string
+
+	// Game endings: {color} is the winner and {method} one of Methods.
+	Win, Draw     string
+	Methods       map[chess.Method]string
+	DrawClaimable string // a draw may be claimed {method}
+
+	InvalidMove string
+	BetterWas   string // suggested alternative after a poor move
+}
+
+// Options adjusts how moves are described.
+type Options struct {
+	NameCaptured bool // "bishop takes knight on f 6" rather than "bishop takes f 6"
+}
+
+// English is the default language.
+var English = Language{
+	Code:  "en",
+	Name:  "English",
+	White: "White",
+	Black: "Black",
+	Pieces: map[chess.PieceType]string{
+		chess.King: "king", chess.Queen: "queen", chess.Rook: "rook",
+		chess.Bishop: "bishop", chess.Knight: "knight", chess.Pawn: "pawn",
+	},
+	Move:            "{color} {piece} to {square}",
+	Capture:         "{color} {piece} takes {square}",
+	CastleKing:      "{color} castles king side",
+	CastleQueen:     "{color} castles queen side",
+	MovePre:         "{color} {piece} to move to {square}",
+	CapturePre:      "{color} {piece} to take {square}",
+	CastleKingPre:   "{color} to castle king side",
+	CastleQueenPre:  "{color} to castle queen side",
+	CaptureNamed:    "{color} {piece} takes {captured} on {square}",
+	CaptureNamedPre: "{color} {piece} to take {captured} on {square}",
+	EnPassant:       "en passant",
+	Promotion:       "promoting to {piece}",
+	Check:           "Check!",
+	Checkmate:       "Checkmate!",
+	Stalemate:       "Stalemate!",
+	Win:             "{color} wins {method}",
+	Draw:            "Draw {method}",
+	Methods: map[chess.Method]string{
+		chess.Checkmate:            "by checkmate",
+		chess.Resignation:          "by resignation",
+		chess.DrawOffer:            "by agreement",
+		chess.Stalemate:            "by stalemate",
+		chess.ThreefoldRepetition:  "by threefold repetition",
+		chess.FivefoldRepetition:   "by fivefold repetition",
+		chess.FiftyMoveRule:        "by the fifty-move rule",
+		chess.SeventyFiveMoveRule:  "by the seventy-five-move rule",
+		chess.InsufficientMaterial: "by insufficient material",
+	},
+	DrawClaimable: "A draw can be claimed {method}",
+	InvalidMove:   "Invalid move",
+	BetterWas:     "Better was {piece} {square}",
+}
+
+// Languages lists the supported languages, English first.
+var Languages = []*Language{&English, &Hindi, &German, &Spanish}
+
+// ByCode returns the language with the given code, English if unknown.
+func ByCode(code string) *Language {
+	for _, l := range Languages {
+		if l.Code == code {
+			return l
+		}
+	}
+	return &English
+}
+
+// ForLocale returns the language matching a voice locale such as "de_DE"
+// or "es-419", and false if none does.
+func ForLocale(locale string) (*Language, bool) {
+	code, _, _ := strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-")
+	code = strings.ToLower(code)
+	for _, l := range Languages {
+		if l.Code == code {
+			return l, true
+		}
+	}
+	return nil, false
+}
+
+// SquareName returns a spoken-friendly name like "e 4", so text-to-speech
+// reads the file and rank separately.
+func SquareName(sq chess.Square) string {
+	return fmt.Sprintf("%c %d", rune('a')+rune(sq.File()), int(sq.Rank())+1)
+}
+
+// ColorName returns the name of a side.
+func (l *Language) ColorName(c chess.Color) string {
+	if c == chess.Black {
+		return l.Black
+	}
+	return l.White
+}
+
+// Describe builds a phrase describing move, played by the side to move in
+// pos. When preMove is true the move is about to be played (CPU
+// announcements); otherwise it has just been played.
+func (l *Language) Describe(move *chess.Move, pos *chess.Position, preMove bool, opts Options) string {
+	captured := capturedPiece(move, pos)
+	tmpl := l.Move
+	switch {
+	case move.HasTag(chess.KingSideCastle):
+		tmpl = pick(preMove, l.CastleKingPre, l.CastleKing)
+	case move.HasTag(chess.QueenSideCastle):
+		tmpl = pick(preMove, l.CastleQueenPre, l.CastleQueen)
+	case captured != chess.NoPiece && opts.NameCaptured:
+		tmpl = pick(preMove, l.CaptureNamedPre, l.CaptureNamed)
+	case captured != chess.NoPiece:
+		tmpl = pick(preMove, l.CapturePre, l.Capture)
+	case preMove:
+		tmpl = l.MovePre
+	}
+
+	text := strings.ReplaceAll(tmpl, "{captured}", l.Pieces[captured.Type()])
+	text = l.fill(text, pos.Turn(), pos.Board().Piece(move.S1()), move.S2())
+	if move.HasTag(chess.EnPassant) {
+		text += " " + l.EnPassant
+	}
+	if move.Promo() != chess.NoPieceType {
+		text += ", " + strings.ReplaceAll(l.Promotion, "{piece}", l.Pieces[move.Promo()])
+	}
+
+	switch pos.Update(move).Status() {
+	case chess.Checkmate:
+		text += ". " + l.Checkmate
+	case chess.Stalemate:
+		text += ". " + l.Stalemate
+	default:
+		if move.HasTag(chess.Check) {
+			text += ". " + l.Check
+		}
+	}
+	return text
+}
+
+// Outcome describes how game ended, "" if it is still in progress.
+func (l *Language) Outcome(game *chess.Game) string {
+	var tmpl, winner string
+	switch game.Outcome() {
+	case chess.WhiteWon:
+		tmpl, winner = l.Win, l.White
+	case chess.BlackWon:
+		tmpl, winner = l.Win, l.Black
+	case chess.Draw:
+		tmpl = l.Draw
+	default:
+		return ""
+	}
+	return strings.TrimSpace(strings.NewReplacer(
+		"{color}", winner,
+		"{method}", l.Methods[game.Method()],
+	).Replace(tmpl))
+}
+
+// Claimable announces that a draw may be claimed by method.
+func (l *Language) Claimable(method chess.Method) string {
+	return strings.ReplaceAll(l.DrawClaimable, "{method}", l.Methods[method])
+}
+
+// Suggest names the move the engine preferred, for move feedback.
+func (l *Language) Suggest(move *chess.Move, pos *chess.Position) string {
+	return l.fill(l.BetterWas, pos.Turn(), pos.Board().Piece(move.S1()), move.S2())
+}
+
+// fill substitutes the placeholders in tmpl.
+func (l *Language) fill(tmpl string, c chess.Color, p chess.Piece, sq chess.Square) string {
+	return strings.NewReplacer(
+		"{color}", l.ColorName(c),
+		"{piece}", l.Pieces[p.Type()],
+		"{square}", SquareName(sq),
+	).Replace(tmpl)
+}
+
+// Join combines phrases into one utterance, adding a full stop between
+// them unless a phrase already ends a sentence.
+func Join(phrases ...string) string {
+	var b strings.Builder
+	for _, p := range phrases {
+		if p == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			if !strings.ContainsAny(b.String()[b.Len()-1:], ".!?") {
+				b.WriteByte('.')
+			}
+			b.WriteByte(' ')
+		}
+		b.WriteString(p)
+	}
+	return b.String()
+}
+
+// capturedPiece returns the piece move captures in pos, NoPiece if none.
+func capturedPiece(move *chess.Move, pos *chess.Position) chess.Piece {
+	if move.HasTag(chess.EnPassant) {
+		return chess.NewPiece(chess.Pawn, pos.Turn().Other())
+	}
+	if move.HasTag(chess.Capture) {
+		return pos.Board().Piece(move.S2())
+	}
+	return chess.NoPiece
+}
+
+func pick(cond bool, a, b string) string {
+	if cond {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,215 @@
+package commentary
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/notnil/chess"
+)
+
+// play returns the move san in the position after moves, and that position.
+func play(t *testing.T, moves []string, san string) (*chess.Move, *chess.Position) {
+	t.Helper()
+	g := chess.NewGame()
+	for _, m := range moves {
+		if err := g.MoveStr(m); err != nil {
+			t.Fatalf("MoveStr(%s) failed: %v", m, err)
+		}
+	}
+	pos := g.Position()
+	m, err := chess.AlgebraicNotation{}.Decode(pos, san)
+	if err != nil {
+		t.Fatalf("Decode(%s) failed: %v", san, err)
+	}
+	return m, pos
+}
+
+func TestDescribeEnglish(t *testing.T) {
+	italian := []string{"e4", "e5", "Nf3", "Nc6", "Bc4", "Bc5"}
+	tests := []struct {
+		moves   []string
+		san     string
+		preMove bool
+		want    string
+	}{
+		{nil, "e4", false, "White pawn to e 4"},
+		{nil, "Nf3", true, "White knight to move to f 3"},
+		{[]string{"e4", "d5"}, "exd5", false, "White pawn takes d 5"},
+		{[]string{"e4", "d5"}, "exd5", true, "White pawn to take d 5"},
+		{italian, "O-O", false, "White castles king side"},
+		{italian, "O-O", true, "White to castle king side"},
+		{italian, "Bxf7+", false, "White bishop takes f 7. Check!"},
+		{[]string{"e4"}, "e5", false, "Black pawn to e 5"},
+	}
+	for _, tt := range tests {
+		m, pos := play(t, tt.moves, tt.san)
+		if got := English.Describe(m, pos, tt.preMove, Options{}); got != tt.want {
+			t.Errorf("Describe(%s, preMove=%v) = %q, want %q", tt.san, tt.preMove, got, tt.want)
+		}
+	}
+}
+
+// decode returns the move san in the position given by fen.
+func decode(t *testing.T, fen, san string) (*chess.Move, *chess.Position) {
+	t.Helper()
+	opt, err := chess.FEN(fen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pos := chess.NewGame(opt).Position()
+	m, err := chess.AlgebraicNotation{}.Decode(pos, san)
+	if err != nil {
+		t.Fatalf("Decode(%s) failed: %v", san, err)
+	}
+	return m, pos
+}
+
+func TestDescribeSpecialMoves(t *testing.T) {
+	tests := []struct {
+		name, fen, san string
+		opts           Options
+		want           string
+	}{
+		{"promotion", "8/4P3/8/8/8/2k5/8/4K3 w - - 0 1", "e8=Q",
+			Options{}, "White pawn to e 8, promoting to queen"},
+		{"under-promotion with capture", "3r4/4P3/8/8/8/2k5/8/4K3 w - - 0 1", "exd8=N",
+			Options{NameCaptured: true}, "White pawn takes rook on d 8, promoting to knight"},
+		{"en passant", "4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "exd6",
+			Options{}, "White pawn takes d 6 en passant"},
+		{"en passant naming the pawn", "4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "exd6",
+			Options{NameCaptured: true}, "White pawn takes pawn on d 6 en passant"},
+		{"named capture", "4k3/8/5n2/8/8/2B5/8/4K3 w - - 0 1", "Bxf6",
+			Options{NameCaptured: true}, "White bishop takes knight on f 6"},
+		{"checkmate", "6k1/5ppp/8/8/8/8/8/R3K3 w - - 0 1", "Ra8#",
+			Options{}, "White rook to a 8. Checkmate!"},
+		{"stalemate", "7k/8/6K1/8/8/8/8/5Q2 w - - 0 1", "Qf7",
+			Options{}, "White queen to f 7. Stalemate!"},
+	}
+	for _, tt := range tests {
+		m, pos := decode(t, tt.fen, tt.san)
+		if got := English.Describe(m, pos, false, tt.opts); got != tt.want {
+			t.Errorf("%s: Describe() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestOutcome(t *testing.T) {
+	g := chess.NewGame()
+	for _, m := range []string{"f3", "e5", "g4", "Qh4"} {
+		if err := g.MoveStr(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := English.Outcome(g), "Black wins by checkmate"; got != want {
+		t.Errorf("Outcome() = %q, want %q", got, want)
+	}
+	if got, want := Spanish.Outcome(g), "Ganan negras por jaque mate"; got != want {
+		t.Errorf("Outcome() = %q, want %q", got, want)
+	}
+
+	g = chess.NewGame()
+	if got := English.Outcome(g); got != "" {
+		t.Errorf("Outcome() of a game in progress = %q, want empty", got)
+	}
+	if err := g.Draw(chess.DrawOffer); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := German.Outcome(g), "Remis durch Einigung"; got != want {
+		t.Errorf("Outcome() = %q, want %q", got, want)
+	}
+	if got, want := English.Claimable(chess.ThreefoldRepetition), "A draw can be claimed by threefold repetition"; got != want {
+		t.Errorf("Claimable() = %q, want %q", got, want)
+	}
+}
+
+func TestJoin(t *testing.T) {
+	tests := []struct {
+		phrases []string
+		want    string
+	}{
+		{[]string{"White rook to a 8. Checkmate!", "White wins by checkmate"}, "White rook to a 8. Checkmate! White wins by checkmate"},
+		{[]string{"White pawn to e 4", "A draw can be claimed by threefold repetition"}, "White pawn to e 4. A draw can be claimed by threefold repetition"},
+		{[]string{"", "Draw by stalemate"}, "Draw by stalemate"},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := Join(tt.phrases...); got != tt.want {
+			t.Errorf("Join(%q) = %q, want %q", tt.phrases, got, tt.want)
+		}
+	}
+}
+
+func TestDescribeGerman(t *testing.T) {
+	m, pos := play(t, []string{"e4", "d5"}, "exd5")
+	if got, want := German.Describe(m, pos, false, Options{}), "Weiß: Bauer schlägt auf d 5"; got != want {
+		t.Errorf("Describe() = %q, want %q", got, want)
+	}
+	m, pos = play(t, nil, "Nf3")
+	if got, want := German.Suggest(m, pos), "Besser war Springer nach f 3"; got != want {
+		t.Errorf("Suggest() = %q, want %q", got, want)
+	}
+}
+
+// TestLanguagesComplete checks that every language fills in every phrase
+// and leaves no placeholder unreplaced.
+func TestLanguagesComplete(t *testing.T) {
+	italian := []string{"e4", "e5", "Nf3", "Nc6", "Bc4", "Bc5"}
+	cases := []struct {
+		moves []string
+		san   string
+	}{
+		{nil, "e4"}, {[]string{"e4", "d5"}, "exd5"}, {italian, "O-O"}, {italian, "Bxf7+"},
+	}
+	for _, l := range Languages {
+		v := reflect.ValueOf(*l)
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Field(i); f.Kind() == reflect.String && f.String() == "" {
+				t.Errorf("%s: %s is empty", l.Code, v.Type().Field(i).Name)
+			}
+		}
+		for _, pt := range []chess.PieceType{chess.King, chess.Queen, chess.Rook, chess.Bishop, chess.Knight, chess.Pawn} {
+			if l.Pieces[pt] == "" {
+				t.Errorf("%s: no name for %s", l.Code, pt)
+			}
+		}
+		for m := chess.Checkmate; m <= chess.InsufficientMaterial; m++ {
+			if l.Methods[m] == "" {
+				t.Errorf("%s: no phrase for %s", l.Code, m)
+			}
+		}
+		for _, c := range cases {
+			m, pos := play(t, c.moves, c.san)
+			for _, text := range []string{
+				l.Describe(m, pos, false, Options{}), l.Describe(m, pos, true, Options{}),
+				l.Describe(m, pos, false, Options{NameCaptured: true}), l.Describe(m, pos, true, Options{NameCaptured: true}),
+				l.Suggest(m, pos), l.Claimable(chess.FiftyMoveRule),
+			} {
+				if strings.ContainsAny(text, "{}") {
+					t.Errorf("%s: unreplaced placeholder in %q", l.Code, text)
+				}
+			}
+		}
+	}
+}
+
+func TestForLocale(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   string // "" = no match
+	}{
+		{"de_DE", "de"}, {"es-419", "es"}, {"hi", "hi"}, {"en-gb", "en"}, {"EN_us", "en"}, {"fr_FR", ""}, {"", ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if l, ok := ForLocale(tt.locale); ok {
+			got = l.Code
+		}
+		if got != tt.want {
+			t.Errorf("ForLocale(%q) = %q, want %q", tt.locale, got, tt.want)
+		}
+	}
+	if ByCode("xx") != &English {
+		t.Error("ByCode of an unknown code should fall back to English")
+	}
+}
@@ -0,0 +1,135 @@
+package commentary
+
+import "github.com/notnil/chess"
+
+// Hindi uses the Devanagari piece names common in Indian chess. Every
+// piece name is masculine, so the verbs agree without per-piece forms.
+var Hindi = Language{
+	Code:  "hi",
+	Name:  "हिन्दी",
+	White: "सफ़ेद",
+	Black: "काला",
+	Pieces: map[chess.PieceType]string{
+		chess.King: "राजा", chess.Queen: "वज़ीर", chess.Rook: "हाथी",
+		chess.Bishop: "ऊँट", chess.Knight: "घोड़ा", chess.Pawn: "प्यादा",
+	},
+	Move:            "{color}: {piece} {square} पर",
+	Capture:         "{color}: {piece} {square} पर काटता है",
+	CastleKing:      "{color}: राजा की ओर कैसलिंग",
+	CastleQueen:     "{color}: वज़ीर की ओर कैसलिंग",
+	MovePre:         "{color}: {piece} {square} पर चलेगा",
+	CapturePre:      "{color}: {piece} {square} पर काटेगा",
+	CastleKingPre:   "{color}: राजा की ओर कैसलिंग करेगा",
+	CastleQueenPre:  "{color}: वज़ीर की ओर कैसलिंग करेगा",
+	CaptureNamed:    "{color}: {piece} {square} पर {captured} काटता है",
+	CaptureNamedPre: "{color}: {piece} {square} पर {captured} काटेगा",
+	EnPassant:       "एन पासां",
+	Promotion:       "{piece} में पदोन्नति",
+	Check:           "शह!",
+	Checkmate:       "शह और मात!",
+	Stalemate:       "स्टेलमेट!",
+	Win:             "{color} जीता, {method}",
+	Draw:            "ड्रॉ, {method}",
+	Methods: map[chess.Method]string{
+		chess.Checkmate:            "शह और मात से",
+		chess.Resignation:          "हार मानने से",
+		chess.DrawOffer:            "सहमति से",
+		chess.Stalemate:            "स्टेलमेट से",
+		chess.ThreefoldRepetition:  "तीन बार दोहराव से",
+		chess.FivefoldRepetition:   "पाँच बार दोहराव से",
+		chess.FiftyMoveRule:        "पचास चालों के नियम से",
+		chess.SeventyFiveMoveRule:  "पचहत्तर चालों के नियम से",
+		chess.InsufficientMaterial: "अपर्याप्त मोहरों से",
+	},
+	DrawClaimable: "{method} ड्रॉ का दावा किया जा सकता है",
+	InvalidMove:   "गलत चाल",
+	BetterWas:     "बेहतर था {piece} {square} पर",
+}
+
+// German phrases avoid articles, whose case and gender would otherwise
+// depend on the piece.
+var German = Language{
+	Code:  "de",
+	Name:  "Deutsch",
+	White: "Weiß",
+	Black: "Schwarz",
+	Pieces: map[chess.PieceType]string{
+		chess.King: "König", chess.Queen: "Dame", chess.Rook: "Turm",
+		chess.Bishop: "Läufer", chess.Knight: "Springer", chess.Pawn: "Bauer",
+	},
+	Move:            "{color}: {piece} nach {square}",
+	Capture:         "{color}: {piece} schlägt auf {square}",
+	CastleKing:      "{color} rochiert kurz",
+	CastleQueen:     "{color} rochiert lang",
+	MovePre:         "{color} am Zug: {piece} nach {square}",
+	CapturePre:      "{color} am Zug: {piece} schlägt auf {square}",
+	CastleKingPre:   "{color} am Zug: kurze Rochade",
+	CastleQueenPre:  "{color} am Zug: lange Rochade",
+	CaptureNamed:    "{color}: {piece} schlägt {captured} auf {square}",
+	CaptureNamedPre: "{color} am Zug: {piece} schlägt {captured} auf {square}",
+	EnPassant:       "en passant",
+	Promotion:       "Umwandlung in {piece}",
+	Check:           "Schach!",
+	Checkmate:       "Schachmatt!",
+	Stalemate:       "Patt!",
+	Win:             "{color} gewinnt {method}",
+	Draw:            "Remis {method}",
+	Methods: map[chess.Method]string{
+		chess.Checkmate:            "durch Schachmatt",
+		chess.Resignation:          "durch Aufgabe",
+		chess.DrawOffer:            "durch Einigung",
+		chess.Stalemate:            "durch Patt",
+		chess.ThreefoldRepetition:  "durch dreifache Stellungswiederholung",
+		chess.FivefoldRepetition:   "durch fünffache Stellungswiederholung",
+		chess.FiftyMoveRule:        "durch die 50-Züge-Regel",
+		chess.SeventyFiveMoveRule:  "durch die 75-Züge-Regel",
+		chess.InsufficientMaterial: "durch ungenügendes Material",
+	},
+	DrawClaimable: "Remis {method} kann beansprucht werden",
+	InvalidMove:   "Ungültiger Zug",
+	BetterWas:     "Besser war {piece} nach {square}",
+}
+
+// Spanish names the sides in the plural, as Spanish chess commentary does
+// ("juegan blancas").
+var Spanish = Language{
+	Code:  "es",
+	Name:  "Español",
+	White: "blancas",
+	Black: "negras",
+	Pieces: map[chess.PieceType]string{
+		chess.King: "rey", chess.Queen: "dama", chess.Rook: "torre",
+		chess.Bishop: "alfil", chess.Knight: "caballo", chess.Pawn: "peón",
+	},
+	Move:            "{color}: {piece} a {square}",
+	Capture:         "{color}: {piece} captura en {square}",
+	CastleKing:      "{color}: enroque corto",
+	CastleQueen:     "{color}: enroque largo",
+	MovePre:         "Juegan {color}: {piece} a {square}",
+	CapturePre:      "Juegan {color}: {piece} captura en {square}",
+	CastleKingPre:   "Juegan {color}: enroque corto",
+	CastleQueenPre:  "Juegan {color}: enroque largo",
+	CaptureNamed:    "{color}: {piece} captura {captured} en {square}",
+	CaptureNamedPre: "Juegan {color}: {piece} captura {captured} en {square}",
+	EnPassant:       "al paso",
+	Promotion:       "promoción a {piece}",
+	Check:           "¡Jaque!",
+	Checkmate:       "¡Jaque mate!",
+	Stalemate:       "¡Ahogado!",
+	Win:             "Ganan {color} {method}",
+	Draw:            "Tablas {method}",
+	Methods: map[chess.Method]string{
+		chess.Checkmate:            "por jaque mate",
+		chess.Resignation:          "por abandono",
+		chess.DrawOffer:            "por acuerdo",
+		chess.Stalemate:            "por ahogado",
+		chess.ThreefoldRepetition:  "por triple repetición",
+		chess.FivefoldRepetition:   "por quíntuple repetición",
+		chess.FiftyMoveRule:        "por la regla de los cincuenta movimientos",
+		chess.SeventyFiveMoveRule:  "por la regla de los setenta y cinco movimientos",
+		chess.InsufficientMaterial: "por material insuficiente",
+	},
+	DrawClaimable: "Se pueden reclamar tablas {method}",
+	InvalidMove:   "Movimiento inválido",
+	BetterWas:     "Era mejor {piece} a {square}",
+}
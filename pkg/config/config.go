@@ -0,0 +1,373 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image/color"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/intothevoid/nayan/pkg/calibration"
+	"github.com/intothevoid/nayan/pkg/commentary"
+)
+
+// Config holds the settings shared by the GUI and the command-line tools.
+// It is stored as TOML with one table per section; docs/config.example.toml
+// documents every setting with its default and valid range. Settings
+// missing from a file keep their defaults.
+type Config struct {
+	// Profile names the profile the config was loaded from, "" for the
+	// default config file. It is not stored in the file.
+	Profile string `toml:"-"`
+
+	Camera  Camera  `toml:"camera"`
+	Vision  Vision  `toml:"vision"`
+	Game    Game    `toml:"game"`
+	Engine  Engine  `toml:"engine"`
+	Voice   Voice   `toml:"voice"`
+	Server  Server  `toml:"server"`
+	Overlay Overlay `toml:"overlay"`
+	Relay   Relay   `toml:"relay"`
+}
+
+// Camera selects the camera, its frame size and its calibration.
+type Camera struct {
+	Device      int    `toml:"device"`
+	Width       int    `toml:"width"`
+	Height      int    `toml:"height"`
+	Calibration string `toml:"calibration"` // saved corners; see Config.CalibrationPath
+}
+
+// Vision tunes board warping and piece detection. Its fields match
+// vision.Params one for one, so callers convert it with vision.Params(v).
+type Vision struct {
+	WarpSize            int     `toml:"warp_size"`             // side of the top-down board image, pixels
+	SquareInset         float64 `toml:"square_inset"`          // fraction of a square cropped from each edge
+	VarianceThreshold   float64 `toml:"variance_threshold"`    // greyscale stddev above which a square is occupied
+	EdgeThreshold       float64 `toml:"edge_threshold"`        // edge pixel percentage above which a square is occupied
+	CombinedVarianceMin float64 `toml:"combined_variance_min"` // occupied if both the stddev...
+	CombinedEdgeMin     float64 `toml:"combined_edge_min"`     // ...and the edge percentage exceed these
+}
+
+// Game tunes move detection and alerts.
+type Game struct {
+	StabilityFrames int           `toml:"stability_frames"` // identical readings before a board counts as still
+	SettleTime      time.Duration `toml:"settle_time"`      // how long it must stay still before a move is read
+	AlertInterval   time.Duration `toml:"alert_interval"`   // repeat of the invalid board alert
+}
+
+// Engine selects the engine and how hard it plays.
+type Engine struct {
+	Path  string `toml:"path"`
+	Depth int    `toml:"depth"` // the GUI's difficulty N plays at depth 2N
+}
+
+// Voice configures the voiceover.
+type Voice struct {
+	Enabled  bool   `toml:"enabled"`
+	Backend  string `toml:"backend"`  // text-to-speech program, e.g. "espeak-ng"; auto-detected if empty
+	Name     string `toml:"name"`     // voice; the backend's default if empty
+	Language string `toml:"language"` // commentary language code; the last one used if empty
+}
+
+// Server configures the HTTP and WebSocket API (see package server).
+type Server struct {
+	Enabled bool   `toml:"enabled"`
+	Addr    string `toml:"addr"` // host:port to listen on; an empty host means every interface
+}
+
+// Overlay configures the broadcast overlay of the virtual board (see
+// package overlay). It is off unless PNG or MJPEG is set.
+type Overlay struct {
+	Width      int    `toml:"width"`      // frame width in pixels; the board is as large as fits
+	Height     int    `toml:"height"`     // frame height in pixels
+	EvalBar    bool   `toml:"eval_bar"`   // draw an eval bar beside the board
+	PNG        string `toml:"png"`        // file rewritten after every change; a %d verb numbers the frames instead
+	MJPEG      string `toml:"mjpeg"`      // host:port serving /board.mjpg and /board.png
+	Background string `toml:"background"` // #rrggbb behind MJPEG frames, which cannot be transparent
+	FPS        int    `toml:"fps"`        // MJPEG frame rate
+}
+
+// Enabled reports whether any overlay output is configured.
+func (o Overlay) Enabled() bool {
+	return o.PNG != "" || o.MJPEG != ""
+}
+
+// BackgroundColor parses Background.
+func (o Overlay) BackgroundColor() (color.NRGBA, error) {
+	c := color.NRGBA{A: 0xff}
+	if len(o.Background) != 7 {
+		return c, fmt.Errorf("want #rrggbb, got %q", o.Background)
+	}
+	if _, err := fmt.Sscanf(o.Background, "#%02x%02x%02x", &c.R, &c.G, &c.B); err != nil {
+		return c, fmt.Errorf("want #rrggbb, got %q", o.Background)
+	}
+	return c, nil
+}
+
+// Relay configures the live PGN broadcast for relay tools (see package
+// relay). It is off unless Dir is set.
+type Relay struct {
+	Dir         string `toml:"dir"`          // directory holding live.pgn, shared by every board
+	Board       int    `toml:"board"`        // this board's number; orders the games in live.pgn
+	TimeControl string `toml:"time_control"` // seconds[+increment]; %clk comments count down from it
+
+	// PGN headers, "?" if empty.
+	Event string `toml:"event"`
+	Site  string `toml:"site"`
+	Round string `toml:"round"`
+	White string `toml:"white"`
+	Black string `toml:"black"`
+}
+
+// Enabled reports whether the relay is configured.
+func (r Relay) Enabled() bool {
+	return r.Dir != ""
+}
+
+// timeControlPattern matches a PGN TimeControl of one period.
+var timeControlPattern = regexp.MustCompile(`^([0-9]+)(?:\+([0-9]+))?$`)
+
+// Clock parses TimeControl into the starting time and the increment per
+// move. Both are zero if TimeControl is empty.
+func (r Relay) Clock() (base, increment time.Duration, err error) {
+	if r.TimeControl == "" {
+		return 0, 0, nil
+	}
+	m := timeControlPattern.FindStringSubmatch(r.TimeControl)
+	if m == nil {
+		return 0, 0, fmt.Errorf("want seconds or seconds+increment, got %q", r.TimeControl)
+	}
+	b, _ := strconv.Atoi(m[1])
+	i, _ := strconv.Atoi(m[2]) // 0 if there is no increment
+	if b == 0 {
+		return 0, 0, fmt.Errorf("the starting time must be positive, got %q", r.TimeControl)
+	}
+	return time.Duration(b) * time.Second, time.Duration(i) * time.Second, nil
+}
+
+// Default returns the built-in settings.
+func Default() Config {
+	return Config{
+		Camera: Camera{Device: 1, Width: 640, Height: 480},
+		Vision: Vision{
+			WarpSize:            800,
+			SquareInset:         0.2,
+			VarianceThreshold:   20,
+			EdgeThreshold:       7,
+			CombinedVarianceMin: 16,
+			CombinedEdgeMin:     3,
+		},
+		Game: Game{
+			StabilityFrames: 5,
+			SettleTime:      2 * time.Second,
+			AlertInterval:   4 * time.Second,
+		},
+		Engine: Engine{Depth: 10},
+		Voice:  Voice{Enabled: true},
+		Server: Server{Addr: ":8734"},
+		Overlay: Overlay{
+			Width:      864,
+			Height:     800,
+			EvalBar:    true,
+			Background: "#00ff00",
+			FPS:        10,
+		},
+		Relay: Relay{Board: 1},
+	}
+}
+
+// dir returns the per-user config directory.
+func dir() (string, error) {
+	d, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(d, "nayan"), nil
+}
+
+// DefaultPath returns the per-user location of the config file.
+func DefaultPath() (string, error) {
+	d, err := dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(d, "config.toml"), nil
+}
+
+var profileName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ProfilePath returns where the profile called name is stored. Profiles
+// keep one config per board setup; "" is the default config file.
+func ProfilePath(name string) (string, error) {
+	if name == "" {
+		return DefaultPath()
+	}
+	if !profileName.MatchString(name) {
+		return "", fmt.Errorf("profile name %q: use letters, digits, - and _", name)
+	}
+	d, err := dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(d, "profiles", name+".toml"), nil
+}
+
+// Profiles lists the saved profiles by name.
+func Profiles() ([]string, error) {
+	d, err := dir()
+	if err != nil {
+		return nil, err
+	}
+	paths, err := filepath.Glob(filepath.Join(d, "profiles", "*.toml"))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, p := range paths {
+		names = append(names, strings.TrimSuffix(filepath.Base(p), ".toml"))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// LoadProfile reads the profile called name ("" for the default config).
+func LoadProfile(name string) (Config, error) {
+	path, err := ProfilePath(name)
+	if err != nil {
+		return Config{}, err
+	}
+	c, err := Load(path)
+	c.Profile = name
+	return c, err
+}
+
+// CalibrationPath returns where the board corners are saved: the camera's
+// calibration setting if set, otherwise a file beside the profile.
+func (c Config) CalibrationPath() (string, error) {
+	if c.Camera.Calibration != "" {
+		return c.Camera.Calibration, nil
+	}
+	if c.Profile == "" {
+		return calibration.DefaultPath()
+	}
+	path, err := ProfilePath(c.Profile)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(path, ".toml") + ".calibration.json", nil
+}
+
+// Load reads the config at path. Settings missing from the file keep their
+// defaults, and a missing file yields the defaults.
+func Load(path string) (Config, error) {
+	c := Default()
+	md, err := toml.DecodeFile(path, &c)
+	if errors.Is(err, os.ErrNotExist) {
+		return Default(), nil
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return Config{}, fmt.Errorf("%s: unknown setting %s", path, undecoded[0])
+	}
+	if err := c.Validate(); err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Save validates c and writes it to path, creating its directory if needed.
+func (c Config) Save(path string) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	buf.WriteString("# Nayan settings. Every setting is described in docs/config.example.toml.\n\n")
+	if err := toml.NewEncoder(&buf).Encode(c); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// Validate reports every setting that is out of range.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	cam := c.Camera
+	check(cam.Device >= 0, "camera.device must not be negative, got %d", cam.Device)
+	check(cam.Width >= 160 && cam.Width <= 3840, "camera.width must be 160-3840, got %d", cam.Width)
+	check(cam.Height >= 120 && cam.Height <= 2160, "camera.height must be 120-2160, got %d", cam.Height)
+
+	v := c.Vision
+	check(v.WarpSize >= 160 && v.WarpSize <= 1600 && v.WarpSize%8 == 0,
+		"vision.warp_size must be a multiple of 8 from 160 to 1600, got %d", v.WarpSize)
+	check(v.SquareInset >= 0 && v.SquareInset < 0.45, "vision.square_inset must be 0-0.45, got %g", v.SquareInset)
+	check(v.VarianceThreshold > 0, "vision.variance_threshold must be positive, got %g", v.VarianceThreshold)
+	check(v.EdgeThreshold > 0 && v.EdgeThreshold <= 100, "vision.edge_threshold must be 0-100, got %g", v.EdgeThreshold)
+	check(v.CombinedVarianceMin > 0 && v.CombinedVarianceMin <= v.VarianceThreshold,
+		"vision.combined_variance_min must be positive and at most variance_threshold, got %g", v.CombinedVarianceMin)
+	check(v.CombinedEdgeMin > 0 && v.CombinedEdgeMin <= v.EdgeThreshold,
+		"vision.combined_edge_min must be positive and at most edge_threshold, got %g", v.CombinedEdgeMin)
+
+	g := c.Game
+	check(g.StabilityFrames >= 1 && g.StabilityFrames <= 100, "game.stability_frames must be 1-100, got %d", g.StabilityFrames)
+	check(g.SettleTime >= 0 && g.SettleTime <= 30*time.Second, "game.settle_time must be 0-30s, got %s", g.SettleTime)
+	check(g.AlertInterval >= time.Second && g.AlertInterval <= time.Minute,
+		"game.alert_interval must be 1s-1m, got %s", g.AlertInterval)
+
+	check(c.Engine.Depth >= 1 && c.Engine.Depth <= 40, "engine.depth must be 1-40, got %d", c.Engine.Depth)
+
+	if code := c.Voice.Language; code != "" {
+		known := false
+		for _, l := range commentary.Languages {
+			known = known || l.Code == code
+		}
+		check(known, "voice.language %q is not one of the commentary languages", code)
+	}
+
+	if c.Server.Enabled || c.Server.Addr != "" {
+		_, port, err := net.SplitHostPort(c.Server.Addr)
+		check(err == nil && port != "", "server.addr must be host:port or :port, got %q", c.Server.Addr)
+	}
+
+	o := c.Overlay
+	check(o.Width >= 64 && o.Width <= 3840, "overlay.width must be 64-3840, got %d", o.Width)
+	check(o.Height >= 64 && o.Height <= 2160, "overlay.height must be 64-2160, got %d", o.Height)
+	check(o.FPS >= 1 && o.FPS <= 60, "overlay.fps must be 1-60, got %d", o.FPS)
+	if _, err := o.BackgroundColor(); err != nil {
+		check(false, "overlay.background: %v", err)
+	}
+	if strings.Contains(o.PNG, "%") {
+		name := fmt.Sprintf(o.PNG, 1)
+		check(!strings.Contains(name, "%!") && name != fmt.Sprintf(o.PNG, 2),
+			"overlay.png may only contain a single %%d verb, got %q", o.PNG)
+	}
+	if o.MJPEG != "" {
+		_, port, err := net.SplitHostPort(o.MJPEG)
+		check(err == nil && port != "", "overlay.mjpeg must be host:port or :port, got %q", o.MJPEG)
+	}
+
+	check(c.Relay.Board >= 1 && c.Relay.Board <= 999, "relay.board must be 1-999, got %d", c.Relay.Board)
+	if _, _, err := c.Relay.Clock(); err != nil {
+		check(false, "relay.time_control: %v", err)
+	}
+	return errors.Join(errs...)
+}
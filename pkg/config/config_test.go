@@ -0,0 +1,219 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, text string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDefaultIsValid(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// The documented example must list the defaults, so it stays in step with
+// Default.
+func TestExampleMatchesDefault(t *testing.T) {
+	c, err := Load(filepath.Join("..", "..", "docs", "config.example.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(c, Default()) {
+		t.Errorf("docs/config.example.toml = %+v, want the defaults %+v", c, Default())
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	c, err := Load(filepath.Join(t.TempDir(), "config.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(c, Default()) {
+		t.Errorf("Load of a missing file = %+v, want the defaults", c)
+	}
+}
+
+func TestLoadKeepsDefaults(t *testing.T) {
+	c, err := Load(writeConfig(t, "[engine]\npath = \"/usr/games/stockfish\"\n\n[game]\nsettle_time = \"1.5s\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Default()
+	want.Engine.Path = "/usr/games/stockfish"
+	want.Game.SettleTime = 1500 * time.Millisecond
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("Load = %+v, want %+v", c, want)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"[engine]\ndepth = 0\n", "engine.depth"},
+		{"[camera]\ndevice = -1\n", "camera.device"},
+		{"[camera]\ndevcie = 2\n", "unknown setting camera.devcie"},
+		{"[vision]\nwarp_size = 801\n", "vision.warp_size"},
+		{"[vision]\ncombined_edge_min = 9.0\n", "vision.combined_edge_min"},
+		{"[game]\nalert_interval = \"10ms\"\n", "game.alert_interval"},
+		{"[voice]\nlanguage = \"fr\"\n", "voice.language"},
+		{"[server]\nenabled = true\naddr = \"localhost\"\n", "server.addr"},
+		{"[overlay]\nbackground = \"green\"\n", "overlay.background"},
+		{"[overlay]\npng = \"board-%d-%d.png\"\n", "overlay.png"},
+		{"[overlay]\nfps = 0\n", "overlay.fps"},
+		{"[relay]\nboard = 0\n", "relay.board"},
+		{"[relay]\ntime_control = \"90 min\"\n", "relay.time_control"},
+		{"[relay]\ntime_control = \"0+30\"\n", "relay.time_control"},
+		{"[camera\n", "config.toml"},
+	}
+	for _, tt := range tests {
+		_, err := Load(writeConfig(t, tt.text))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Load(%q) = %v, want an error mentioning %q", tt.text, err, tt.want)
+		}
+	}
+}
+
+func TestValidateReportsEverything(t *testing.T) {
+	c := Default()
+	c.Engine.Depth = 0
+	c.Game.StabilityFrames = 0
+	err := c.Validate()
+	if err == nil || !strings.Contains(err.Error(), "engine.depth") || !strings.Contains(err.Error(), "game.stability_frames") {
+		t.Errorf("Validate = %v, want both bad settings reported", err)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nayan", "config.toml")
+	c := Default()
+	c.Camera.Device = 0
+	c.Vision.EdgeThreshold = 8.5
+	c.Game.AlertInterval = 6 * time.Second
+	c.Voice.Language = "de"
+	if err := c.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, c) {
+		t.Errorf("loaded %+v, want %+v", got, c)
+	}
+
+	c.Engine.Depth = 99
+	if err := c.Save(path); err == nil {
+		t.Error("Save of an invalid config succeeded")
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir()) // macOS
+
+	if _, err := ProfilePath("../escape"); err == nil {
+		t.Error("ProfilePath accepted a path")
+	}
+	for _, name := range []string{"table-2", "club_1"} {
+		path, err := ProfilePath(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := Default()
+		c.Camera.Device = 2
+		if err := c.Save(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	names, err := Profiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names, []string{"club_1", "table-2"}) {
+		t.Errorf("Profiles() = %v", names)
+	}
+
+	c, err := LoadProfile("club_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Profile != "club_1" || c.Camera.Device != 2 {
+		t.Errorf("LoadProfile = profile %q, device %d", c.Profile, c.Camera.Device)
+	}
+	calib, err := c.CalibrationPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(calib) != "club_1.calibration.json" {
+		t.Errorf("profile calibration path = %s", calib)
+	}
+}
+
+func TestFlagsOverrideFile(t *testing.T) {
+	path := writeConfig(t, "[camera]\ndevice = 2\n\n[engine]\ndepth = 6\n")
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	f := AddFlags(fs)
+	if err := fs.Parse([]string{"-config", path, "-depth", "14", "-serve", "127.0.0.1:9000"}); err != nil {
+		t.Fatal(err)
+	}
+	c, err := f.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// -device was not given, so the file's value stands.
+	if c.Camera.Device != 2 || c.Engine.Depth != 14 {
+		t.Errorf("device %d, depth %d; want 2 from the file and 14 from the flag", c.Camera.Device, c.Engine.Depth)
+	}
+	if !c.Server.Enabled || c.Server.Addr != "127.0.0.1:9000" {
+		t.Errorf("server %+v; -serve should enable it on 127.0.0.1:9000", c.Server)
+	}
+}
+
+func TestFlagsErrors(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	for _, args := range [][]string{
+		{"-config", filepath.Join(t.TempDir(), "missing.toml")},
+		{"-config", writeConfig(t, ""), "-depth", "99"},
+		{"-profile", "nonexistent"},
+		{"-profile", "a", "-config", writeConfig(t, "")},
+	} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		f := AddFlags(fs)
+		if err := fs.Parse(args); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Load(); err == nil {
+			t.Errorf("Load with %v succeeded", args)
+		}
+	}
+}
+
+func TestRelayClock(t *testing.T) {
+	base, inc, err := Relay{TimeControl: "5400+30"}.Clock()
+	if err != nil || base != 90*time.Minute || inc != 30*time.Second {
+		t.Errorf("Clock(5400+30) = %s, %s, %v", base, inc, err)
+	}
+	base, inc, err = Relay{TimeControl: "600"}.Clock()
+	if err != nil || base != 10*time.Minute || inc != 0 {
+		t.Errorf("Clock(600) = %s, %s, %v", base, inc, err)
+	}
+	if base, _, err := (Relay{}).Clock(); err != nil || base != 0 {
+		t.Errorf("Clock without a time control = %s, %v", base, err)
+	}
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// Flags are the command-line flags every command accepts: the config file
+// and overrides for the settings most often changed per run.
+type Flags struct {
+	fs      *flag.FlagSet
+	path    string
+	profile string
+	device  int
+	engine  string
+	depth   int
+	serve   string
+}
+
+// AddFlags registers -config, -profile, -device, -engine, -depth and -serve
+// on fs.
+func AddFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{fs: fs}
+	def := Default()
+	fs.StringVar(&f.path, "config", "", "config file (default: config.toml in the user config directory)")
+	fs.StringVar(&f.profile, "profile", "", "use the saved profile for this board setup instead of -config")
+	fs.IntVar(&f.device, "device", def.Camera.Device, "camera device ID")
+	fs.StringVar(&f.engine, "engine", def.Engine.Path, "UCI engine to run (default: stockfish, else the built-in engine)")
+	fs.IntVar(&f.depth, "depth", def.Engine.Depth, "engine search depth")
+	fs.StringVar(&f.serve, "serve", "", "serve the game API on this address, e.g. :8734")
+	return f
+}
+
+// Load reads the config file or profile, after fs has been parsed, and
+// applies the flags that were set on the command line. A config file named
+// with -config or a profile must exist; the default config is optional.
+func (f *Flags) Load() (Config, error) {
+	var c Config
+	var err error
+	switch {
+	case f.path != "" && f.profile != "":
+		return Config{}, fmt.Errorf("flags: use -config or -profile, not both")
+	case f.path != "":
+		if _, err := os.Stat(f.path); err != nil {
+			return Config{}, err
+		}
+		c, err = Load(f.path)
+	case f.profile != "":
+		path, perr := ProfilePath(f.profile)
+		if perr != nil {
+			return Config{}, perr
+		}
+		if _, err := os.Stat(path); err != nil {
+			return Config{}, fmt.Errorf("profile %s: %w", f.profile, err)
+		}
+		c, err = LoadProfile(f.profile)
+	default:
+		c, err = LoadProfile("")
+	}
+	if err != nil {
+		return Config{}, err
+	}
+
+	f.fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "device":
+			c.Camera.Device = f.device
+		case "engine":
+			c.Engine.Path = f.engine
+		case "depth":
+			c.Engine.Depth = f.depth
+		case "serve":
+			c.Server.Enabled = true
+			c.Server.Addr = f.serve
+		}
+	})
+	if err := c.Validate(); err != nil {
+		return Config{}, fmt.Errorf("flags: %w", err)
+	}
+	return c, nil
+}
@@ -0,0 +1,380 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/notnil/chess"
+)
+
+// BuiltinID is the ID reported by the built-in engine.
+const BuiltinID = "Nayan Built-in"
+
+const (
+	// builtinMaxDepth caps the requested depth. Move generation in
+	// notnil/chess is far slower than a native engine, so the depths used
+	// for Stockfish would never finish.
+	builtinMaxDepth = 4
+	// builtinTimeLimit bounds a single search. Iterative deepening returns
+	// the last completed depth when it runs out.
+	builtinTimeLimit = 3 * time.Second
+	// quiescenceDepth limits how many plies of captures are resolved past
+	// the search horizon.
+	quiescenceDepth = 4
+	// mateThreshold separates mate scores from ordinary evaluations.
+	mateThreshold = MateScore - 200
+)
+
+// BuiltinEngine is a small alpha-beta engine using notnil/chess move
+// generation. It is weak compared to Stockfish but needs no external binary,
+// so the app is playable out of the box. It has no options and does not
+// ponder.
+type BuiltinEngine struct {
+	mu sync.Mutex // serialises searches
+}
+
+// NewBuiltinEngine returns a ready-to-use built-in engine.
+func NewBuiltinEngine() *BuiltinEngine {
+	return &BuiltinEngine{}
+}
+
+// ID returns BuiltinID.
+func (e *BuiltinEngine) ID() string { return BuiltinID }
+
+// Options returns nil; the built-in engine has no settings.
+func (e *BuiltinEngine) Options() []Option { return nil }
+
+// Option always reports false.
+func (e *BuiltinEngine) Option(name string) (Option, bool) { return Option{}, false }
+
+// SetOption always fails; the built-in engine has no settings.
+func (e *BuiltinEngine) SetOption(name, value string) error {
+	return fmt.Errorf("engine has no option %q", name)
+}
+
+// ApplyOptions ignores the overrides, none of which can apply.
+func (e *BuiltinEngine) ApplyOptions(overrides map[string]string) error { return nil }
+
+// Ponder is a no-op; the built-in engine only thinks when asked to move.
+func (e *BuiltinEngine) Ponder(pos *chess.Position, expected *chess.Move, depth int) error {
+	return nil
+}
+
+// StopPondering is a no-op.
+func (e *BuiltinEngine) StopPondering() error { return nil }
+
+// Close is a no-op; there is no process to shut down.
+func (e *BuiltinEngine) Close() {}
+
+// BestMove returns the best move found at the given depth.
+func (e *BuiltinEngine) BestMove(game *chess.Game, depth int) (*chess.Move, error) {
+	res, err := e.Search(game, depth)
+	if err != nil {
+		return nil, err
+	}
+	return res.BestMove, nil
+}
+
+// Search returns the best move at the given depth (capped at
+// builtinMaxDepth) and the reply the engine expects.
+func (e *BuiltinEngine) Search(game *chess.Game, depth int) (*SearchResult, error) {
+	pos := game.Position()
+	if len(pos.ValidMoves()) == 0 {
+		return nil, fmt.Errorf("engine returned no move")
+	}
+
+	e.mu.Lock()
+	score, pv := search(pos, depth)
+	e.mu.Unlock()
+
+	res := &SearchResult{BestMove: pv[0], Score: scoreEvaluation(score)}
+	if len(pv) > 1 {
+		res.Ponder = pv[1]
+	}
+	return res, nil
+}
+
+// Evaluate searches pos to the given depth (capped at builtinMaxDepth) and
+// returns the score together with the preferred line.
+func (e *BuiltinEngine) Evaluate(pos *chess.Position, depth int) (*Evaluation, error) {
+	switch pos.Status() {
+	case chess.Checkmate:
+		return &Evaluation{CP: -MateScore}, nil
+	case chess.Stalemate:
+		return &Evaluation{}, nil
+	}
+
+	e.mu.Lock()
+	score, pv := search(pos, depth)
+	e.mu.Unlock()
+
+	ev := scoreEvaluation(score)
+	ev.BestMove, ev.PV = pv[0], pv
+	return ev, nil
+}
+
+// scoreEvaluation converts a search score to CP or, for forced mates, the
+// number of moves to mate.
+func scoreEvaluation(score int) *Evaluation {
+	ev := &Evaluation{}
+	switch {
+	case score > mateThreshold:
+		ev.Mate = (MateScore - score + 1) / 2
+	case score < -mateThreshold:
+		ev.Mate = -(MateScore + score + 1) / 2
+	default:
+		ev.CP = score
+	}
+	return ev
+}
+
+// search runs an iterative-deepening alpha-beta search on pos, which must
+// have at least one legal move. It returns the score from the side to
+// move's point of view and the principal variation.
+func search(pos *chess.Position, depth int) (int, []*chess.Move) {
+	depth = max(1, min(depth, builtinMaxDepth))
+	s := &searcher{deadline: time.Now().Add(builtinTimeLimit)}
+
+	var score int
+	var pv []*chess.Move
+	for d := 1; d <= depth; d++ {
+		// Depth 1 always completes so there is a move to return.
+		s.canAbort = d > 1
+		sc, line := s.negamax(pos, d, -MateScore-1, MateScore+1, 0, false, pv)
+		if s.aborted {
+			break
+		}
+		score, pv = sc, line
+	}
+	return score, pv
+}
+
+// searcher holds the state of one search.
+type searcher struct {
+	deadline time.Time
+	nodes    int
+	canAbort bool
+	aborted  bool
+}
+
+// timeUp reports whether the search should stop, checking the clock every
+// 1024 nodes.
+func (s *searcher) timeUp() bool {
+	s.nodes++
+	if s.canAbort && !s.aborted && s.nodes%1024 == 0 && time.Now().After(s.deadline) {
+		s.aborted = true
+	}
+	return s.aborted
+}
+
+// negamax returns the score of pos from the side to move's point of view
+// and the line that achieves it. inCheck reports whether the side to move
+// is in check; hint is the previous iteration's principal variation from
+// this node, searched first to improve cut-offs.
+func (s *searcher) negamax(pos *chess.Position, depth, alpha, beta, ply int, inCheck bool, hint []*chess.Move) (int, []*chess.Move) {
+	if s.timeUp() {
+		return 0, nil
+	}
+
+	moves := pos.ValidMoves()
+	if len(moves) == 0 {
+		if inCheck {
+			return -MateScore + ply, nil
+		}
+		return 0, nil
+	}
+	if ply > 0 && pos.HalfMoveClock() >= 100 {
+		return 0, nil
+	}
+	if depth <= 0 {
+		return s.quiesce(pos, alpha, beta, 0), nil
+	}
+
+	var first *chess.Move
+	if len(hint) > 0 {
+		first = hint[0]
+	}
+	orderMoves(pos, moves, first)
+
+	var pv []*chess.Move
+	for i, m := range moves {
+		var childHint []*chess.Move
+		if i == 0 && first != nil && m.String() == first.String() {
+			childHint = hint[1:]
+		}
+		score, line := s.negamax(pos.Update(m), depth-1, -beta, -alpha, ply+1, m.HasTag(chess.Check), childHint)
+		score = -score
+		if s.aborted {
+			return 0, nil
+		}
+		if score > alpha || pv == nil {
+			alpha = max(alpha, score)
+			pv = append([]*chess.Move{m}, line...)
+		}
+		if alpha >= beta {
+			break
+		}
+	}
+	return alpha, pv
+}
+
+// quiesce extends the search through captures and promotions until the
+// position is quiet, so the static evaluation isn't taken mid-exchange.
+func (s *searcher) quiesce(pos *chess.Position, alpha, beta, qply int) int {
+	standPat := evaluate(pos)
+	if standPat >= beta {
+		return beta
+	}
+	alpha = max(alpha, standPat)
+	if qply >= quiescenceDepth || s.timeUp() {
+		return alpha
+	}
+
+	moves := pos.ValidMoves()
+	orderMoves(pos, moves, nil)
+	for _, m := range moves {
+		if !m.HasTag(chess.Capture) && m.Promo() == chess.NoPieceType {
+			// Ordering puts captures and promotions first.
+			break
+		}
+		score := -s.quiesce(pos.Update(m), -beta, -alpha, qply+1)
+		if s.aborted {
+			return 0
+		}
+		if score >= beta {
+			return beta
+		}
+		alpha = max(alpha, score)
+	}
+	return alpha
+}
+
+// orderMoves sorts moves so first (if present) comes first, then captures
+// by most valuable victim / least valuable attacker, then promotions, then
+// quiet moves.
+func orderMoves(pos *chess.Position, moves []*chess.Move, first *chess.Move) {
+	board := pos.Board()
+	key := func(m *chess.Move) int {
+		if first != nil && m.String() == first.String() {
+			return 1 << 20
+		}
+		k := 0
+		if m.HasTag(chess.Capture) {
+			victim := pieceValue[board.Piece(m.S2()).Type()]
+			if m.HasTag(chess.EnPassant) {
+				victim = pieceValue[chess.Pawn]
+			}
+			k += 10000 + victim*10 - pieceValue[board.Piece(m.S1()).Type()]/10
+		}
+		if m.Promo() != chess.NoPieceType {
+			k += 5000 + pieceValue[m.Promo()]
+		}
+		return k
+	}
+	sort.SliceStable(moves, func(i, j int) bool { return key(moves[i]) > key(moves[j]) })
+}
+
+// pieceValue is the material value of each piece type in centipawns.
+var pieceValue = map[chess.PieceType]int{
+	chess.Pawn:   100,
+	chess.Knight: 320,
+	chess.Bishop: 330,
+	chess.Rook:   500,
+	chess.Queen:  900,
+	chess.King:   0,
+}
+
+// Piece-square tables from White's point of view, rank 8 first, after the
+// "simplified evaluation function" on the Chess Programming Wiki.
+var pieceSquare = map[chess.PieceType][64]int{
+	chess.Pawn: {
+		0, 0, 0, 0, 0, 0, 0, 0,
+		50, 50, 50, 50, 50, 50, 50, 50,
+		10, 10, 20, 30, 30, 20, 10, 10,
+		5, 5, 10, 25, 25, 10, 5, 5,
+		0, 0, 0, 20, 20, 0, 0, 0,
+		5, -5, -10, 0, 0, -10, -5, 5,
+		5, 10, 10, -20, -20, 10, 10, 5,
+		0, 0, 0, 0, 0, 0, 0, 0,
+	},
+	chess.Knight: {
+		-50, -40, -30, -30, -30, -30, -40, -50,
+		-40, -20, 0, 0, 0, 0, -20, -40,
+		-30, 0, 10, 15, 15, 10, 0, -30,
+		-30, 5, 15, 20, 20, 15, 5, -30,
+		-30, 0, 15, 20, 20, 15, 0, -30,
+		-30, 5, 10, 15, 15, 10, 5, -30,
+		-40, -20, 0, 5, 5, 0, -20, -40,
+		-50, -40, -30, -30, -30, -30, -40, -50,
+	},
+	chess.Bishop: {
+		-20, -10, -10, -10, -10, -10, -10, -20,
+		-10, 0, 0, 0, 0, 0, 0, -10,
+		-10, 0, 5, 10, 10, 5, 0, -10,
+		-10, 5, 5, 10, 10, 5, 5, -10,
+		-10, 0, 10, 10, 10, 10, 0, -10,
+		-10, 10, 10, 10, 10, 10, 10, -10,
+		-10, 5, 0, 0, 0, 0, 5, -10,
+		-20, -10, -10, -10, -10, -10, -10, -20,
+	},
+	chess.Rook: {
+		0, 0, 0, 0, 0, 0, 0, 0,
+		5, 10, 10, 10, 10, 10, 10, 5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		0, 0, 0, 5, 5, 0, 0, 0,
+	},
+	chess.Queen: {
+		-20, -10, -10, -5, -5, -10, -10, -20,
+		-10, 0, 0, 0, 0, 0, 0, -10,
+		-10, 0, 5, 5, 5, 5, 0, -10,
+		-5, 0, 5, 5, 5, 5, 0, -5,
+		0, 0, 5, 5, 5, 5, 0, -5,
+		-10, 5, 5, 5, 5, 5, 0, -10,
+		-10, 0, 5, 0, 0, 0, 0, -10,
+		-20, -10, -10, -5, -5, -10, -10, -20,
+	},
+	chess.King: {
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-20, -30, -30, -40, -40, -30, -30, -20,
+		-10, -20, -20, -20, -20, -20, -20, -10,
+		20, 20, 0, 0, 0, 0, 20, 20,
+		20, 30, 10, 0, 0, 10, 30, 20,
+	},
+}
+
+// evaluate returns the static evaluation of pos from the side to move's
+// point of view: material plus piece-square bonuses.
+func evaluate(pos *chess.Position) int {
+	board := pos.Board()
+	score := 0
+	for sq := chess.A1; sq <= chess.H8; sq++ {
+		p := board.Piece(sq)
+		if p == chess.NoPiece {
+			continue
+		}
+		rank, file := int(sq)/8, int(sq)%8
+		idx := (7-rank)*8 + file
+		if p.Color() == chess.Black {
+			idx = rank*8 + file // mirror vertically
+		}
+		v := pieceValue[p.Type()] + pieceSquare[p.Type()][idx]
+		if p.Color() == chess.White {
+			score += v
+		} else {
+			score -= v
+		}
+	}
+	if pos.Turn() == chess.Black {
+		return -score
+	}
+	return score
+}
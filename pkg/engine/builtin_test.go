@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/notnil/chess"
+)
+
+// gameFromFEN builds a game starting at fen.
+func gameFromFEN(t *testing.T, fen string) *chess.Game {
+	t.Helper()
+	opt, err := chess.FEN(fen)
+	if err != nil {
+		t.Fatalf("bad FEN %q: %v", fen, err)
+	}
+	return chess.NewGame(opt)
+}
+
+func TestBuiltinFindsMateInOne(t *testing.T) {
+	game := gameFromFEN(t, "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1")
+	eng := NewBuiltinEngine()
+
+	move, err := eng.BestMove(game, 3)
+	if err != nil {
+		t.Fatalf("BestMove failed: %v", err)
+	}
+	if move.String() != "a1a8" {
+		t.Errorf("BestMove = %s, want a1a8", move)
+	}
+
+	ev, err := eng.Evaluate(game.Position(), 3)
+	if err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	if ev.Mate != 1 {
+		t.Errorf("Mate = %d, want 1", ev.Mate)
+	}
+}
+
+func TestBuiltinWinsHangingQueen(t *testing.T) {
+	// Black's queen on d5 is attacked by the knight and defended by nothing.
+	game := gameFromFEN(t, "4k3/8/8/3q4/8/4N3/8/4K3 w - - 0 1")
+	eng := NewBuiltinEngine()
+
+	res, err := eng.Search(game, 2)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if res.BestMove.String() != "e3d5" {
+		t.Errorf("BestMove = %s, want e3d5", res.BestMove)
+	}
+	if res.Ponder == nil {
+		t.Error("expected a ponder move")
+	}
+	if res.Score == nil || res.Score.CP < 200 {
+		t.Errorf("Score = %+v, want White a piece up after winning the queen", res.Score)
+	}
+}
+
+func TestBuiltinEvaluateTerminal(t *testing.T) {
+	eng := NewBuiltinEngine()
+
+	mated := gameFromFEN(t, "R5k1/5ppp/8/8/8/8/8/6K1 b - - 0 1")
+	ev, err := eng.Evaluate(mated.Position(), 3)
+	if err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	if ev.Centipawns() != -MateScore {
+		t.Errorf("checkmate score = %d, want %d", ev.Centipawns(), -MateScore)
+	}
+
+	if _, err := eng.Search(mated, 3); err == nil {
+		t.Error("Search in a checkmated position should fail")
+	}
+}
+
+func TestNewEngineFallsBackToBuiltin(t *testing.T) {
+	eng, err := NewEngine("/nonexistent/stockfish")
+	if err != nil {
+		t.Fatalf("NewEngine failed: %v", err)
+	}
+	defer eng.Close()
+	if eng.ID() != BuiltinID {
+		t.Errorf("ID = %q, want %q", eng.ID(), BuiltinID)
+	}
+}
@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"errors"
+	"io/fs"
+	"os/exec"
+
+	"github.com/notnil/chess"
+)
+
+// MateScore is the centipawn value used to represent a forced mate. Mates
+// further away score slightly lower so that shorter mates are preferred.
+const MateScore = 10000
+
+// Engine is a chess engine the app can play against and analyse with.
+// *UCIEngine drives an external UCI binary; *BuiltinEngine is a small Go
+// engine used when no binary is installed.
+type Engine interface {
+	// ID is the engine's name, used to key persisted option overrides.
+	ID() string
+
+	// Options lists the settings the engine supports.
+	Options() []Option
+	// Option looks up a setting by name (case-insensitive).
+	Option(name string) (Option, bool)
+	// SetOption validates and applies a single setting.
+	SetOption(name, value string) error
+	// ApplyOptions applies saved overrides, skipping unsupported ones.
+	ApplyOptions(overrides map[string]string) error
+
+	// Search returns the best move at the given depth and the expected reply.
+	Search(game *chess.Game, depth int) (*SearchResult, error)
+	// BestMove is Search without the expected reply.
+	BestMove(game *chess.Game, depth int) (*chess.Move, error)
+	// Evaluate scores pos and returns the engine's preferred line.
+	Evaluate(pos *chess.Position, depth int) (*Evaluation, error)
+
+	// Ponder searches in the background on the position after expected is
+	// played in pos. Engines that cannot ponder ignore it.
+	Ponder(pos *chess.Position, expected *chess.Move, depth int) error
+	// StopPondering abandons any running ponder search.
+	StopPondering() error
+
+	// Close releases the engine.
+	Close()
+}
+
+// Evaluation is the engine's assessment of a position, scored from the
+// point of view of the side to move.
+type Evaluation struct {
+	CP       int           // centipawn score (meaningless when Mate != 0)
+	Mate     int           // moves to mate; positive = side to move mates, negative = gets mated
+	BestMove *chess.Move   // nil if the position has no legal moves
+	PV       []*chess.Move // principal variation starting with BestMove
+}
+
+// Centipawns returns the score in centipawns, mapping forced mates to
+// ±MateScore (less the distance to mate).
+func (ev Evaluation) Centipawns() int {
+	switch {
+	case ev.Mate > 0:
+		return MateScore - ev.Mate
+	case ev.Mate < 0:
+		return -MateScore - ev.Mate
+	default:
+		return ev.CP
+	}
+}
+
+// WhiteScore converts cp, a score from side's point of view with mates
+// scored as by Centipawns, to White's point of view. mate is the number of
+// moves to a forced mate, positive when White mates, or 0 if there is none.
+func WhiteScore(cp int, side chess.Color) (whiteCP, mate int) {
+	if side == chess.Black {
+		cp = -cp
+	}
+	switch {
+	case cp > MateScore-1000:
+		mate = MateScore - cp
+	case cp < -MateScore+1000:
+		mate = -MateScore - cp
+	}
+	return cp, mate
+}
+
+// SearchResult is the outcome of a best-move search.
+type SearchResult struct {
+	BestMove *chess.Move
+	// Ponder is the reply the engine expects to BestMove, nil if it gave
+	// none. Pass it to Ponder once BestMove has been played.
+	Ponder *chess.Move
+	// Score is the engine's evaluation of the position from the side to
+	// move's point of view; only CP and Mate are set. It is nil if the
+	// engine reported no score.
+	Score *Evaluation
+}
+
+// NewEngine starts the UCI engine at path ("stockfish" on PATH if omitted).
+// If the executable cannot be found it falls back to the built-in engine,
+// so the app always has an opponent; check ID to tell which one started.
+// Other start-up failures (e.g. a binary that doesn't speak UCI) are
+// returned as errors.
+func NewEngine(path ...string) (Engine, error) {
+	eng, err := NewUCIEngine(path...)
+	if errors.Is(err, exec.ErrNotFound) || errors.Is(err, fs.ErrNotExist) {
+		return NewBuiltinEngine(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return eng, nil
+}
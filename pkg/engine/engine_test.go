@@ -0,0 +1,26 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/notnil/chess"
+)
+
+func TestWhiteScore(t *testing.T) {
+	for _, tt := range []struct {
+		ev       Evaluation
+		side     chess.Color
+		cp, mate int
+	}{
+		{Evaluation{CP: 35}, chess.White, 35, 0},
+		{Evaluation{CP: 35}, chess.Black, -35, 0},
+		{Evaluation{Mate: 3}, chess.White, MateScore - 3, 3},
+		{Evaluation{Mate: 3}, chess.Black, -MateScore + 3, -3},
+		{Evaluation{Mate: -2}, chess.Black, MateScore - 2, 2},
+	} {
+		cp, mate := WhiteScore(tt.ev.Centipawns(), tt.side)
+		if cp != tt.cp || mate != tt.mate {
+			t.Errorf("WhiteScore(%+v, %v) = %d, %d; want %d, %d", tt.ev, tt.side, cp, mate, tt.cp, tt.mate)
+		}
+	}
+}
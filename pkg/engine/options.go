@@ -0,0 +1,283 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// OptionType is the kind of value a UCI option takes.
+type OptionType string
+
+const (
+	OptionCheck  OptionType = "check"
+	OptionSpin   OptionType = "spin"
+	OptionCombo  OptionType = "combo"
+	OptionButton OptionType = "button"
+	OptionString OptionType = "string"
+)
+
+// Option is a setting advertised by the engine in response to "uci".
+type Option struct {
+	Name    string
+	Type    OptionType
+	Default string
+	Min     int      // spin only
+	Max     int      // spin only
+	Vars    []string // combo only
+}
+
+// optionKeywords separate the fields of an "option" line. Names and values
+// may contain spaces, so each field runs until the next keyword.
+var optionKeywords = map[string]bool{
+	"name": true, "type": true, "default": true, "min": true, "max": true, "var": true,
+}
+
+// parseOption parses an "option name ... type ..." line. ok is false if the
+// line is not a well-formed option.
+func parseOption(line string) (opt Option, ok bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || fields[0] != "option" {
+		return Option{}, false
+	}
+
+	key := ""
+	var value []string
+	flush := func() {
+		v := strings.Join(value, " ")
+		switch key {
+		case "name":
+			opt.Name = v
+		case "type":
+			opt.Type = OptionType(v)
+		case "default":
+			if v == "<empty>" {
+				v = ""
+			}
+			opt.Default = v
+		case "min":
+			opt.Min, _ = strconv.Atoi(v)
+		case "max":
+			opt.Max, _ = strconv.Atoi(v)
+		case "var":
+			opt.Vars = append(opt.Vars, v)
+		}
+		value = nil
+	}
+	for _, f := range fields[1:] {
+		// "name" only counts as a keyword at the start; later keywords end
+		// the name, but a name may itself contain e.g. "var".
+		if optionKeywords[f] && (key != "name" || f == "type") {
+			flush()
+			key = f
+			continue
+		}
+		value = append(value, f)
+	}
+	flush()
+
+	switch opt.Type {
+	case OptionCheck, OptionSpin, OptionCombo, OptionButton, OptionString:
+	default:
+		return Option{}, false
+	}
+	return opt, opt.Name != ""
+}
+
+// Validate reports whether value is acceptable for the option.
+func (o Option) Validate(value string) error {
+	switch o.Type {
+	case OptionCheck:
+		if value != "true" && value != "false" {
+			return fmt.Errorf("%s: %q is not true or false", o.Name, value)
+		}
+	case OptionSpin:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s: %q is not a number", o.Name, value)
+		}
+		if n < o.Min || n > o.Max {
+			return fmt.Errorf("%s: %d is outside %d-%d", o.Name, n, o.Min, o.Max)
+		}
+	case OptionCombo:
+		for _, v := range o.Vars {
+			if strings.EqualFold(v, value) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s: %q is not one of %s", o.Name, value, strings.Join(o.Vars, ", "))
+	case OptionButton:
+		return fmt.Errorf("%s: buttons take no value", o.Name)
+	}
+	return nil
+}
+
+// ID returns the engine's self-reported name (e.g. "Stockfish 16"), or ""
+// if it did not send one.
+func (e *UCIEngine) ID() string {
+	return e.id
+}
+
+// Options returns the options the engine advertised, in the order given.
+func (e *UCIEngine) Options() []Option {
+	return append([]Option(nil), e.options...)
+}
+
+// Option looks up an advertised option by name (case-insensitive, as UCI
+// option names are).
+func (e *UCIEngine) Option(name string) (Option, bool) {
+	for _, o := range e.options {
+		if strings.EqualFold(o.Name, name) {
+			return o, true
+		}
+	}
+	return Option{}, false
+}
+
+// SetOption validates value against the advertised option and sends it to
+// the engine. For button options value is ignored.
+func (e *UCIEngine) SetOption(name, value string) error {
+	opt, ok := e.Option(name)
+	if !ok {
+		return fmt.Errorf("engine has no option %q", name)
+	}
+	if opt.Type != OptionButton {
+		if err := opt.Validate(value); err != nil {
+			return err
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := e.stopPonderLocked(); err != nil {
+		return err
+	}
+	var err error
+	if opt.Type == OptionButton {
+		err = e.proc.send("setoption name %s", opt.Name)
+	} else {
+		err = e.proc.send("setoption name %s value %s", opt.Name, value)
+	}
+	if err != nil {
+		return err
+	}
+	return e.proc.sync()
+}
+
+// ApplyOptions sets each override the engine supports. Overrides for
+// options this engine does not advertise are skipped; invalid values are
+// reported together after the rest have been applied.
+func (e *UCIEngine) ApplyOptions(overrides map[string]string) error {
+	var errs []error
+	for name, value := range overrides {
+		if _, ok := e.Option(name); !ok {
+			continue
+		}
+		if err := e.SetOption(name, value); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// OptionStore persists user overrides of engine options, keyed by engine ID
+// so each engine keeps its own settings.
+type OptionStore struct {
+	path string
+
+	mu        sync.Mutex
+	overrides map[string]map[string]string
+}
+
+// DefaultOptionStorePath returns the per-user location of the option store.
+func DefaultOptionStorePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "nayan", "engine-options.json"), nil
+}
+
+// NewOptionStore returns an empty store that saves to path.
+func NewOptionStore(path string) *OptionStore {
+	return &OptionStore{path: path, overrides: map[string]map[string]string{}}
+}
+
+// LoadOptionStore reads the store at path. A missing file yields an empty
+// store that will be created on Save.
+func LoadOptionStore(path string) (*OptionStore, error) {
+	s := NewOptionStore(path)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.overrides); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Overrides returns a copy of the overrides stored for an engine.
+func (s *OptionStore) Overrides(engineID string) map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := map[string]string{}
+	for k, v := range s.overrides[engineID] {
+		out[k] = v
+	}
+	return out
+}
+
+// SetOverrides replaces the overrides stored for an engine. An empty map
+// removes the engine's entry.
+func (s *OptionStore) SetOverrides(engineID string, overrides map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(overrides) == 0 {
+		delete(s.overrides, engineID)
+		return
+	}
+	cp := map[string]string{}
+	for k, v := range overrides {
+		cp[k] = v
+	}
+	s.overrides[engineID] = cp
+}
+
+// ApplySavedOptions applies the overrides saved for eng. A nil store means
+// the one at DefaultOptionStorePath; callers that edit a store pass it
+// instead so unsaved changes are used too.
+func ApplySavedOptions(eng Engine, store *OptionStore) error {
+	if store == nil {
+		path, err := DefaultOptionStorePath()
+		if err != nil {
+			return nil // nowhere options could have been saved
+		}
+		if store, err = LoadOptionStore(path); err != nil {
+			return err
+		}
+	}
+	return eng.ApplyOptions(store.Overrides(eng.ID()))
+}
+
+// Save writes the store to disk, creating its directory if needed.
+func (s *OptionStore) Save() error {
+	s.mu.Lock()
+	data, err := json.MarshalIndent(s.overrides, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0644)
+}
@@ -0,0 +1,164 @@
+package engine
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseOption(t *testing.T) {
+	tests := []struct {
+		line string
+		want Option
+	}{
+		{
+			"option name Threads type spin default 1 min 1 max 512",
+			Option{Name: "Threads", Type: OptionSpin, Default: "1", Min: 1, Max: 512},
+		},
+		{
+			"option name Skill Level type spin default 20 min 0 max 20",
+			Option{Name: "Skill Level", Type: OptionSpin, Default: "20", Min: 0, Max: 20},
+		},
+		{
+			"option name Analysis Contempt type combo default Both var Off var White var Black var Both",
+			Option{Name: "Analysis Contempt", Type: OptionCombo, Default: "Both", Vars: []string{"Off", "White", "Black", "Both"}},
+		},
+		{
+			"option name SyzygyPath type string default <empty>",
+			Option{Name: "SyzygyPath", Type: OptionString, Default: ""},
+		},
+		{
+			"option name Debug Log File type string default /tmp/my log.txt",
+			Option{Name: "Debug Log File", Type: OptionString, Default: "/tmp/my log.txt"},
+		},
+		{
+			"option name Clear Hash type button",
+			Option{Name: "Clear Hash", Type: OptionButton},
+		},
+		{
+			"option name UCI_Chess960 type check default false",
+			Option{Name: "UCI_Chess960", Type: OptionCheck, Default: "false"},
+		},
+	}
+	for _, tt := range tests {
+		got, ok := parseOption(tt.line)
+		if !ok {
+			t.Errorf("parseOption(%q) failed", tt.line)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseOption(%q) = %+v, want %+v", tt.line, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"id name Stockfish", "option name Foo type colour", "option type spin"} {
+		if _, ok := parseOption(bad); ok {
+			t.Errorf("parseOption(%q) should fail", bad)
+		}
+	}
+}
+
+func TestOptionValidate(t *testing.T) {
+	spin := Option{Name: "Hash", Type: OptionSpin, Min: 1, Max: 1024}
+	combo := Option{Name: "Style", Type: OptionCombo, Vars: []string{"Solid", "Risky"}}
+	check := Option{Name: "Ponder", Type: OptionCheck}
+
+	valid := []struct {
+		opt   Option
+		value string
+	}{{spin, "64"}, {combo, "risky"}, {check, "true"}}
+	for _, v := range valid {
+		if err := v.opt.Validate(v.value); err != nil {
+			t.Errorf("%s=%q should be valid: %v", v.opt.Name, v.value, err)
+		}
+	}
+
+	invalid := []struct {
+		opt   Option
+		value string
+	}{{spin, "0"}, {spin, "big"}, {combo, "Wild"}, {check, "yes"}}
+	for _, v := range invalid {
+		if err := v.opt.Validate(v.value); err == nil {
+			t.Errorf("%s=%q should be invalid", v.opt.Name, v.value)
+		}
+	}
+}
+
+func TestEngineOptions(t *testing.T) {
+	eng := newFakeEngine(t)
+	if eng.ID() != "Fake Engine" {
+		t.Errorf("ID = %q, want Fake Engine", eng.ID())
+	}
+	if len(eng.Options()) != 3 {
+		t.Fatalf("got %d options, want 3", len(eng.Options()))
+	}
+	if err := eng.SetOption("skill level", "5"); err != nil {
+		t.Errorf("SetOption failed: %v", err)
+	}
+	if err := eng.SetOption("Skill Level", "50"); err == nil {
+		t.Error("SetOption should reject out-of-range spin value")
+	}
+
+	// Unknown options are skipped, invalid ones reported.
+	err := eng.ApplyOptions(map[string]string{"Hash": "64", "Ponder": "maybe"})
+	if err == nil {
+		t.Error("ApplyOptions should report the invalid Ponder value")
+	}
+}
+
+func TestOptionStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nayan", "engine-options.json")
+
+	store, err := LoadOptionStore(path)
+	if err != nil {
+		t.Fatalf("LoadOptionStore on missing file: %v", err)
+	}
+	store.SetOverrides("Stockfish 16", map[string]string{"Threads": "4", "Hash": "256"})
+	store.SetOverrides("Other", map[string]string{"Style": "Risky"})
+	store.SetOverrides("Other", nil) // clears
+	if err := store.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := LoadOptionStore(path)
+	if err != nil {
+		t.Fatalf("LoadOptionStore failed: %v", err)
+	}
+	want := map[string]string{"Threads": "4", "Hash": "256"}
+	if got := loaded.Overrides("Stockfish 16"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Overrides = %v, want %v", got, want)
+	}
+	if got := loaded.Overrides("Other"); len(got) != 0 {
+		t.Errorf("cleared engine still has overrides %v", got)
+	}
+}
+
+func TestApplySavedOptions(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	eng := newFakeEngine(t)
+
+	// Nothing saved yet.
+	if err := ApplySavedOptions(eng, nil); err != nil {
+		t.Errorf("ApplySavedOptions with no store: %v", err)
+	}
+
+	path, err := DefaultOptionStorePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewOptionStore(path)
+	store.SetOverrides(eng.ID(), map[string]string{"Ponder": "maybe"})
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+	// The saved, invalid value is applied and reported.
+	if err := ApplySavedOptions(eng, nil); err == nil {
+		t.Error("ApplySavedOptions should report the saved invalid Ponder value")
+	}
+
+	// A store being edited is used as it stands.
+	store.SetOverrides(eng.ID(), map[string]string{"Ponder": "true"})
+	if err := ApplySavedOptions(eng, store); err != nil {
+		t.Errorf("ApplySavedOptions with the edited store: %v", err)
+	}
+}
@@ -1,52 +1,265 @@
 package engine
 
 import (
+	"fmt"
+	"strings"
+	"sync"
+
 	"github.com/notnil/chess"
-	"github.com/notnil/chess/uci"
 )
 
-// Engine wraps a UCI chess engine (e.g. Stockfish).
-type Engine struct {
-	eng *uci.Engine
+// UCIEngine wraps a UCI chess engine (e.g. Stockfish).
+type UCIEngine struct {
+	// mu serialises searches — a position command and its go command must
+	// not interleave with another goroutine's search.
+	mu   sync.Mutex
+	proc *process
+
+	// Identity and options reported during the UCI handshake.
+	id      string
+	options []Option
+
+	// ponder is the background search started by Ponder, nil when idle.
+	// Guarded by mu.
+	ponder *ponderSearch
+}
+
+// ponderSearch describes a running "go ponder" search.
+type ponderSearch struct {
+	pos   *chess.Position // position being pondered (after the expected reply)
+	depth int
 }
 
-// NewEngine starts a UCI engine process. If no path is given, "stockfish"
-// is used (expected to be on PATH).
-func NewEngine(path ...string) (*Engine, error) {
+// NewUCIEngine starts a UCI engine process. If no path is given,
+// "stockfish" is used (expected to be on PATH).
+func NewUCIEngine(path ...string) (*UCIEngine, error) {
 	bin := "stockfish"
 	if len(path) > 0 && path[0] != "" {
 		bin = path[0]
 	}
 
-	eng, err := uci.New(bin)
+	proc, err := startProcess(bin)
 	if err != nil {
 		return nil, err
 	}
 
-	// Initialize UCI protocol
-	if err := eng.Run(uci.CmdUCI, uci.CmdIsReady, uci.CmdUCINewGame); err != nil {
-		eng.Close()
+	// Initialize UCI protocol, collecting the engine's id and options
+	e := &UCIEngine{proc: proc}
+	err = proc.send("uci")
+	if err == nil {
+		_, err = proc.waitFor("uciok", func(line string) {
+			if name, ok := strings.CutPrefix(line, "id name "); ok {
+				e.id = name
+			} else if opt, ok := parseOption(line); ok {
+				e.options = append(e.options, opt)
+			}
+		})
+	}
+	if err == nil {
+		err = proc.send("ucinewgame")
+	}
+	if err == nil {
+		err = proc.sync()
+	}
+	if err != nil {
+		proc.close()
 		return nil, err
 	}
 
-	return &Engine{eng: eng}, nil
+	return e, nil
 }
 
 // BestMove queries the engine for the best move at the given depth.
-func (e *Engine) BestMove(game *chess.Game, depth int) (*chess.Move, error) {
-	cmdPos := uci.CmdPosition{Position: game.Position()}
-	cmdGo := uci.CmdGo{Depth: depth}
+func (e *UCIEngine) BestMove(game *chess.Game, depth int) (*chess.Move, error) {
+	res, err := e.Search(game, depth)
+	if err != nil {
+		return nil, err
+	}
+	return res.BestMove, nil
+}
+
+// Search queries the engine for the best move at the given depth together
+// with the reply it expects. If the engine is pondering on exactly this
+// position the ponder search is converted with "ponderhit" and usually
+// answers immediately; otherwise the ponder search is abandoned and a new
+// search started.
+func (e *UCIEngine) Search(game *chess.Game, depth int) (*SearchResult, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
 
-	if err := e.eng.Run(cmdPos, cmdGo); err != nil {
+	pos := game.Position()
+	var res *searchResult
+	var err error
+	if e.ponder != nil && e.ponder.pos.String() == pos.String() && e.ponder.depth >= depth {
+		e.ponder = nil
+		if err = e.proc.send("ponderhit"); err == nil {
+			res, err = e.proc.waitBestMove()
+		}
+	} else {
+		if err = e.stopPonderLocked(); err == nil {
+			res, err = e.searchLocked(pos, depth)
+		}
+	}
+	if err != nil {
 		return nil, err
 	}
 
-	return e.eng.SearchResults().BestMove, nil
+	best, err := decodeMove(pos, res.bestMove)
+	if err != nil {
+		return nil, err
+	}
+	result := &SearchResult{BestMove: best}
+	if res.info.depth > 0 {
+		result.Score = &Evaluation{CP: res.info.cp, Mate: res.info.mate}
+	}
+	if res.ponder != "" {
+		// A bad ponder move is not fatal — we simply won't ponder.
+		result.Ponder, _ = decodeMove(pos.Update(best), res.ponder)
+	}
+	return result, nil
 }
 
-// Close shuts down the engine process.
-func (e *Engine) Close() {
-	if e.eng != nil {
-		e.eng.Close()
+// Ponder starts a background search on the position reached when expected
+// is played in pos, so the engine thinks on the opponent's time. It returns
+// immediately. If the opponent then plays expected, the next Search on the
+// resulting position answers from the ponder search; any other call
+// abandons it.
+func (e *UCIEngine) Ponder(pos *chess.Position, expected *chess.Move, depth int) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.stopPonderLocked(); err != nil {
+		return err
+	}
+
+	move, err := resolveMove(pos, expected)
+	if err != nil {
+		return err
+	}
+	e.proc.resetInfo()
+	if err := e.proc.send("%s", positionCommand(pos, move)); err != nil {
+		return err
+	}
+	if err := e.proc.send("go ponder depth %d", depth); err != nil {
+		return err
+	}
+	e.ponder = &ponderSearch{pos: pos.Update(move), depth: depth}
+	return nil
+}
+
+// StopPondering abandons any running ponder search.
+func (e *UCIEngine) StopPondering() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.stopPonderLocked()
+}
+
+// Evaluate searches the given position to the given depth and returns the
+// score together with the engine's preferred line.
+func (e *UCIEngine) Evaluate(pos *chess.Position, depth int) (*Evaluation, error) {
+	// Terminal positions are scored directly — engines answer them with
+	// "bestmove (none)".
+	switch pos.Status() {
+	case chess.Checkmate:
+		return &Evaluation{CP: -MateScore}, nil
+	case chess.Stalemate:
+		return &Evaluation{}, nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.stopPonderLocked(); err != nil {
+		return nil, err
+	}
+	res, err := e.searchLocked(pos, depth)
+	if err != nil {
+		return nil, err
+	}
+
+	best, err := decodeMove(pos, res.bestMove)
+	if err != nil {
+		return nil, err
+	}
+	ev := &Evaluation{
+		CP:       res.info.cp,
+		Mate:     res.info.mate,
+		BestMove: best,
+	}
+
+	// Resolve the PV move by move so each entry carries its tags. Stop at
+	// the first move that is not legal (e.g. a PV from a stale info line).
+	p := pos
+	for _, s := range res.info.pv {
+		m, err := decodeMove(p, s)
+		if err != nil {
+			break
+		}
+		ev.PV = append(ev.PV, m)
+		p = p.Update(m)
+	}
+	if len(ev.PV) == 0 || ev.PV[0].String() != best.String() {
+		ev.PV = []*chess.Move{best}
+	}
+	return ev, nil
+}
+
+// Close shuts down the engine process. It does not wait for a search in
+// progress; that search returns an error instead.
+func (e *UCIEngine) Close() {
+	e.proc.close()
+}
+
+// searchLocked runs a fixed-depth search on pos. Caller holds mu and must
+// have stopped any ponder search.
+func (e *UCIEngine) searchLocked(pos *chess.Position, depth int) (*searchResult, error) {
+	e.proc.resetInfo()
+	if err := e.proc.send("%s", positionCommand(pos)); err != nil {
+		return nil, err
+	}
+	if err := e.proc.send("go depth %d", depth); err != nil {
+		return nil, err
+	}
+	return e.proc.waitBestMove()
+}
+
+// stopPonderLocked stops a running ponder search and discards its result.
+// Caller holds mu.
+func (e *UCIEngine) stopPonderLocked() error {
+	if e.ponder == nil {
+		return nil
+	}
+	e.ponder = nil
+	if err := e.proc.send("stop"); err != nil {
+		return err
+	}
+	_, err := e.proc.waitBestMove()
+	return err
+}
+
+// decodeMove parses a move in UCI notation and resolves it against pos.
+func decodeMove(pos *chess.Position, s string) (*chess.Move, error) {
+	if s == "" || s == "(none)" {
+		return nil, fmt.Errorf("engine returned no move")
+	}
+	m, err := chess.UCINotation{}.Decode(pos, s)
+	if err != nil {
+		return nil, err
+	}
+	return resolveMove(pos, m)
+}
+
+// resolveMove returns the legal move in pos matching m. Moves decoded from
+// UCI output may lack tags (capture, castling, en passant), so they must be
+// matched against the position's valid moves before being applied.
+func resolveMove(pos *chess.Position, m *chess.Move) (*chess.Move, error) {
+	if m == nil {
+		return nil, fmt.Errorf("engine returned no move")
+	}
+	for _, valid := range pos.ValidMoves() {
+		if valid.String() == m.String() {
+			return valid, nil
+		}
 	}
+	return nil, fmt.Errorf("engine move %s is not legal in this position", m)
 }
@@ -0,0 +1,188 @@
+package engine
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/notnil/chess"
+)
+
+// TestMain lets the test binary double as a fake UCI engine: when
+// NAYAN_FAKE_ENGINE is set it speaks UCI on stdin/stdout instead of
+// running the tests.
+func TestMain(m *testing.M) {
+	if os.Getenv("NAYAN_FAKE_ENGINE") == "1" {
+		runFakeEngine()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runFakeEngine answers "go depth" with the first legal move (and the first
+// legal reply as its ponder move) and a ponderhit with the last legal move,
+// so tests can tell which search produced a result.
+func runFakeEngine() {
+	var pos *chess.Position
+	pondering := false
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "uci":
+			fmt.Println("id name Fake Engine")
+			fmt.Println("option name Skill Level type spin default 20 min 0 max 20")
+			fmt.Println("option name Ponder type check default false")
+			fmt.Println("option name Clear Hash type button")
+			fmt.Println("uciok")
+		case "isready":
+			fmt.Println("readyok")
+		case "position":
+			// position fen <6 fields> [moves ...]
+			fen, _ := chess.FEN(strings.Join(fields[2:8], " "))
+			game := chess.NewGame(fen, chess.UseNotation(chess.UCINotation{}))
+			if len(fields) > 9 {
+				for _, m := range fields[9:] {
+					game.MoveStr(m)
+				}
+			}
+			pos = game.Position()
+		case "go":
+			if len(fields) > 1 && fields[1] == "ponder" {
+				pondering = true
+				continue
+			}
+			moves := pos.ValidMoves()
+			reply := pos.Update(moves[0]).ValidMoves()[0]
+			fmt.Printf("info depth 5 score cp 42 pv %s %s\n", moves[0], reply)
+			fmt.Printf("bestmove %s ponder %s\n", moves[0], reply)
+		case "ponderhit":
+			moves := pos.ValidMoves()
+			fmt.Printf("info depth 5 score cp -17 pv %s\n", moves[len(moves)-1])
+			fmt.Printf("bestmove %s\n", moves[len(moves)-1])
+			pondering = false
+		case "stop":
+			if pondering {
+				fmt.Printf("bestmove %s\n", pos.ValidMoves()[0])
+				pondering = false
+			}
+		case "quit":
+			return
+		}
+	}
+}
+
+func newFakeEngine(t *testing.T) *UCIEngine {
+	t.Helper()
+	t.Setenv("NAYAN_FAKE_ENGINE", "1")
+	eng, err := NewUCIEngine(os.Args[0])
+	if err != nil {
+		t.Fatalf("NewUCIEngine failed: %v", err)
+	}
+	t.Cleanup(eng.Close)
+	return eng
+}
+
+func TestSearchReturnsPonderMove(t *testing.T) {
+	eng := newFakeEngine(t)
+	game := chess.NewGame()
+
+	res, err := eng.Search(game, 5)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	want := game.Position().ValidMoves()[0]
+	if res.BestMove.String() != want.String() {
+		t.Errorf("BestMove = %s, want %s", res.BestMove, want)
+	}
+	if res.Ponder == nil {
+		t.Fatal("expected a ponder move")
+	}
+	if res.Score == nil || res.Score.CP != 42 {
+		t.Errorf("Score = %+v, want 42 cp", res.Score)
+	}
+}
+
+func TestPonderHit(t *testing.T) {
+	eng := newFakeEngine(t)
+	game := chess.NewGame()
+	game.MoveStr("e4")
+
+	expected := game.Position().ValidMoves()[3]
+	if err := eng.Ponder(game.Position(), expected, 5); err != nil {
+		t.Fatalf("Ponder failed: %v", err)
+	}
+
+	// The opponent plays the expected move: the ponder search is used.
+	if err := game.Move(expected); err != nil {
+		t.Fatal(err)
+	}
+	best, err := eng.BestMove(game, 5)
+	if err != nil {
+		t.Fatalf("BestMove failed: %v", err)
+	}
+	moves := game.Position().ValidMoves()
+	if want := moves[len(moves)-1]; best.String() != want.String() {
+		t.Errorf("BestMove = %s, want ponderhit answer %s", best, want)
+	}
+}
+
+func TestPonderMiss(t *testing.T) {
+	eng := newFakeEngine(t)
+	game := chess.NewGame()
+	game.MoveStr("e4")
+
+	expected := game.Position().ValidMoves()[3]
+	if err := eng.Ponder(game.Position(), expected, 5); err != nil {
+		t.Fatalf("Ponder failed: %v", err)
+	}
+
+	// The opponent plays something else: the engine searches afresh.
+	other := game.Position().ValidMoves()[4]
+	if err := game.Move(other); err != nil {
+		t.Fatal(err)
+	}
+	best, err := eng.BestMove(game, 5)
+	if err != nil {
+		t.Fatalf("BestMove failed: %v", err)
+	}
+	if want := game.Position().ValidMoves()[0]; best.String() != want.String() {
+		t.Errorf("BestMove = %s, want fresh search answer %s", best, want)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	eng := newFakeEngine(t)
+	pos := chess.StartingPosition()
+
+	ev, err := eng.Evaluate(pos, 5)
+	if err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	if ev.CP != 42 {
+		t.Errorf("CP = %d, want 42", ev.CP)
+	}
+	if len(ev.PV) != 2 {
+		t.Errorf("PV length = %d, want 2", len(ev.PV))
+	}
+}
+
+func TestEvaluateCheckmate(t *testing.T) {
+	game := chess.NewGame()
+	for _, san := range []string{"f3", "e5", "g4", "Qh4#"} {
+		game.MoveStr(san)
+	}
+	// Terminal positions never reach the engine, so no process is needed.
+	ev, err := (&UCIEngine{}).Evaluate(game.Position(), 5)
+	if err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	if ev.Centipawns() != -MateScore {
+		t.Errorf("Centipawns = %d, want %d", ev.Centipawns(), -MateScore)
+	}
+}
@@ -0,0 +1,235 @@
+package engine
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/notnil/chess"
+)
+
+// process is a running UCI engine. Output is read continuously by a
+// background goroutine: "info" lines are folded into the latest search
+// info, everything else is queued on lines for waitFor to consume. Reading
+// continuously matters while pondering — an engine that cannot flush its
+// output stalls its search.
+type process struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	lines chan string // non-info output, closed when the engine exits
+
+	writeMu   sync.Mutex
+	closeOnce sync.Once
+	closeErr  error
+
+	infoMu sync.Mutex
+	info   searchInfo
+}
+
+// searchInfo is the most recent principal-variation report of a search.
+type searchInfo struct {
+	depth int
+	cp    int
+	mate  int
+	pv    []string // moves in UCI notation
+}
+
+// searchResult is the outcome of a completed search.
+type searchResult struct {
+	bestMove string // UCI notation, "(none)" if the position has no moves
+	ponder   string // expected reply in UCI notation, "" if not given
+	info     searchInfo
+}
+
+// startProcess launches the engine binary and starts reading its output.
+func startProcess(bin string) (*process, error) {
+	path, err := exec.LookPath(bin)
+	if err != nil {
+		return nil, fmt.Errorf("engine executable %q not found: %w", bin, err)
+	}
+	cmd := exec.Command(path)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	p := &process{cmd: cmd, stdin: stdin, lines: make(chan string, 256)}
+	go p.readLoop(stdout)
+	return p, nil
+}
+
+// readLoop consumes engine output until the engine exits.
+func (p *process) readLoop(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "info ") {
+			p.recordInfo(line)
+			continue
+		}
+		p.lines <- line
+	}
+	close(p.lines)
+}
+
+// send writes a single command line to the engine.
+func (p *process) send(format string, args ...any) error {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+	_, err := fmt.Fprintf(p.stdin, format+"\n", args...)
+	return err
+}
+
+// waitFor discards output until a line starting with prefix arrives and
+// returns it. seen, if non-nil, is called with every line skipped on the way.
+func (p *process) waitFor(prefix string, seen func(string)) (string, error) {
+	for line := range p.lines {
+		if line == prefix || strings.HasPrefix(line, prefix+" ") {
+			return line, nil
+		}
+		if seen != nil {
+			seen(line)
+		}
+	}
+	return "", fmt.Errorf("engine exited while waiting for %q", prefix)
+}
+
+// sync blocks until the engine has processed all previous commands.
+func (p *process) sync() error {
+	if err := p.send("isready"); err != nil {
+		return err
+	}
+	_, err := p.waitFor("readyok", nil)
+	return err
+}
+
+// resetInfo clears the search info before a new search starts.
+func (p *process) resetInfo() {
+	p.infoMu.Lock()
+	p.info = searchInfo{}
+	p.infoMu.Unlock()
+}
+
+// waitBestMove blocks until the running search reports its best move.
+func (p *process) waitBestMove() (*searchResult, error) {
+	line, err := p.waitFor("bestmove", nil)
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(line)
+	res := &searchResult{}
+	if len(fields) > 1 {
+		res.bestMove = fields[1]
+	}
+	if len(fields) > 3 && fields[2] == "ponder" {
+		res.ponder = fields[3]
+	}
+	p.infoMu.Lock()
+	res.info = p.info
+	p.infoMu.Unlock()
+	return res, nil
+}
+
+// recordInfo parses an "info" line and keeps it if it reports a score for
+// the main line. Other info lines (currmove, hashfull, strings, secondary
+// MultiPV lines) are ignored.
+func (p *process) recordInfo(line string) {
+	fields := strings.Fields(line)
+	info := searchInfo{}
+	hasScore := false
+	for i := 1; i < len(fields); i++ {
+		switch fields[i] {
+		case "depth":
+			if i+1 < len(fields) {
+				info.depth, _ = strconv.Atoi(fields[i+1])
+				i++
+			}
+		case "multipv":
+			if i+1 < len(fields) {
+				if n, _ := strconv.Atoi(fields[i+1]); n > 1 {
+					return
+				}
+				i++
+			}
+		case "score":
+			if i+2 < len(fields) {
+				v, err := strconv.Atoi(fields[i+2])
+				if err != nil {
+					return
+				}
+				switch fields[i+1] {
+				case "cp":
+					info.cp = v
+				case "mate":
+					info.mate = v
+				}
+				hasScore = true
+				i += 2
+			}
+		case "pv":
+			info.pv = append([]string(nil), fields[i+1:]...)
+			i = len(fields)
+		case "string":
+			return
+		}
+	}
+	if !hasScore {
+		return
+	}
+	p.infoMu.Lock()
+	p.info = info
+	p.infoMu.Unlock()
+}
+
+// close asks the engine to quit and releases the process. An engine that
+// does not exit within two seconds is killed. Safe to call more than once
+// and concurrently with a search, which then fails with an error.
+func (p *process) close() error {
+	p.closeOnce.Do(func() { p.closeErr = p.shutdown() })
+	return p.closeErr
+}
+
+func (p *process) shutdown() error {
+	p.send("stop")
+	p.send("quit")
+	p.stdin.Close()
+
+	// Drain remaining output so readLoop sees EOF before Wait closes the pipe.
+	done := make(chan struct{})
+	go func() {
+		for range p.lines {
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		p.cmd.Process.Kill()
+		<-done
+	}
+	return p.cmd.Wait()
+}
+
+// positionCommand builds a UCI "position" command for pos followed by moves.
+func positionCommand(pos *chess.Position, moves ...*chess.Move) string {
+	cmd := "position fen " + pos.String()
+	if len(moves) > 0 {
+		cmd += " moves"
+		for _, m := range moves {
+			cmd += " " + chess.UCINotation{}.Encode(pos, m)
+		}
+	}
+	return cmd
+}
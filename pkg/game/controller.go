@@ -0,0 +1,620 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/intothevoid/nayan/pkg/analysis"
+	nchess "github.com/intothevoid/nayan/pkg/chess"
+	"github.com/intothevoid/nayan/pkg/engine"
+	"github.com/notnil/chess"
+)
+
+// State is the phase of a game against the engine.
+type State int
+
+const (
+	Idle    State = iota // no game
+	Playing              // game in progress
+	Over                 // game finished
+)
+
+func (s State) String() string {
+	switch s {
+	case Playing:
+		return "playing"
+	case Over:
+		return "over"
+	default:
+		return "idle"
+	}
+}
+
+// Observation is one reading of the physical board.
+type Observation struct {
+	Occupancy  [8][8]bool
+	Brightness [8][8]float64 // mean brightness per square, to tell colours apart
+}
+
+// Clock tells the time. Tests substitute a fake to step through settle
+// periods.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// Settings tune move detection and the engine. They may be changed while a
+// game is running and apply from the next move.
+type Settings struct {
+	Depth       int  // engine search depth for its moves and hints
+	Ponder      bool // think on the human's time about the expected reply
+	Review      bool // grade human moves
+	ReviewDepth int  // search depth for grading, independent of Depth
+
+	// A changed board must read the same StabilityThreshold times in a
+	// row, then stay unchanged for SettleTime, before a move is inferred.
+	// This keeps hands over the board and camera noise from being read
+	// as moves.
+	StabilityThreshold int
+	SettleTime         time.Duration
+}
+
+// DefaultSettings returns the settings the app starts with.
+func DefaultSettings() Settings {
+	return Settings{
+		Depth:              10,
+		Ponder:             true,
+		Review:             true,
+		ReviewDepth:        12,
+		StabilityThreshold: 5,
+		SettleTime:         2 * time.Second,
+	}
+}
+
+// Errors returned by the game actions.
+var (
+	ErrNotPlaying     = errors.New("no game in progress")
+	ErrNotYourTurn    = errors.New("not the human's turn")
+	ErrNoEngine       = errors.New("the engine is not running")
+	ErrEngineThinking = errors.New("the engine has not chosen its move yet")
+	ErrNoMoves        = errors.New("no move to take back")
+)
+
+// Controller runs a game between a human at the physical board and the
+// engine. It infers moves from board observations, asks the engine for its
+// replies, insists that the engine's moves are played as recommended, and
+// reports everything that happens through OnEvent.
+//
+// Engine searches run in the background; events may therefore arrive on
+// any goroutine.
+type Controller struct {
+	// OnEvent receives every event. Set it before starting a game.
+	OnEvent func(Event)
+
+	clock Clock
+	wg    sync.WaitGroup // background engine work
+
+	moveMu sync.Mutex // serialises moves from observations and manual entry
+
+	mu       sync.Mutex
+	settings Settings
+	state    State
+	gs       *nchess.GameState
+	eng      engine.Engine
+	gen      int // bumped whenever the position jumps, so stale engine results are dropped
+
+	pending     [8][8]bool // changed occupancy being confirmed
+	stable      int        // consecutive observations of pending
+	settling    bool
+	settleStart time.Time
+	invalid     bool       // the board matches no legal move
+	restoring   bool       // waiting for the board to be put back after a take back
+	stale       [8][8]bool // the board before a move entered on screen
+	awaiting    bool       // the board may still show stale
+
+	rec, reply *chess.Move // the engine's move and the reply it expects
+}
+
+// NewController returns an idle controller using clock, or the system
+// clock if nil.
+func NewController(clock Clock) *Controller {
+	if clock == nil {
+		clock = systemClock{}
+	}
+	return &Controller{clock: clock, settings: DefaultSettings()}
+}
+
+// Settings returns the current settings.
+func (c *Controller) Settings() Settings {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.settings
+}
+
+// SetSettings replaces the settings.
+func (c *Controller) SetSettings(s Settings) {
+	c.mu.Lock()
+	c.settings = s
+	c.mu.Unlock()
+}
+
+// State returns the phase of the game.
+func (c *Controller) State() State {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+// Game returns the current or last game, nil if idle.
+func (c *Controller) Game() *nchess.GameState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gs
+}
+
+// Snapshot returns a copy of the current or last game, nil if idle. Unlike
+// the game itself, which moves are applied to from the caller of Observe,
+// the copy is safe to read from any goroutine. It is taken between moves,
+// so it must not be called from OnEvent.
+func (c *Controller) Snapshot() *nchess.GameState {
+	c.moveMu.Lock()
+	defer c.moveMu.Unlock()
+	gs := c.Game()
+	if gs == nil {
+		return nil
+	}
+	return gs.Clone()
+}
+
+// Engine returns the engine playing the game, nil if none is attached.
+func (c *Controller) Engine() engine.Engine {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.eng
+}
+
+// Recommendation returns the move the engine is waiting to see played, nil
+// if none.
+func (c *Controller) Recommendation() *chess.Move {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rec
+}
+
+// Wait blocks until background engine work has finished.
+func (c *Controller) Wait() {
+	c.wg.Wait()
+}
+
+// Start begins gs, stopping any previous game. The game runs without an
+// engine until SetEngine attaches one.
+func (c *Controller) Start(gs *nchess.GameState) {
+	c.Stop()
+	c.mu.Lock()
+	c.state = Playing
+	c.gs = gs
+	c.mu.Unlock()
+	c.emit(Event{Kind: GameStarted, Game: gs})
+}
+
+// SetEngine attaches eng to the running game, closing any engine attached
+// before, and asks it for a move if it is the engine's turn. If no game is
+// running eng is closed.
+func (c *Controller) SetEngine(eng engine.Engine) {
+	c.mu.Lock()
+	if c.state != Playing {
+		c.mu.Unlock()
+		eng.Close()
+		return
+	}
+	old := c.eng
+	c.eng = eng
+	gs, gen, depth := c.gs, c.gen, c.settings.Depth
+	c.mu.Unlock()
+
+	if old != nil {
+		old.Close()
+	}
+	if !gs.IsHumanTurn() {
+		c.background(func() { c.queryEngine(gs, eng, gen, depth) })
+	}
+}
+
+// Stop abandons the game and closes its engine.
+func (c *Controller) Stop() {
+	c.mu.Lock()
+	eng := c.eng
+	c.gen++
+	c.state = Idle
+	c.gs = nil
+	c.eng = nil
+	c.rec, c.reply = nil, nil
+	c.resetDetection()
+	c.restoring = false
+	c.mu.Unlock()
+
+	if eng != nil {
+		eng.Close()
+	}
+}
+
+// resetDetection forgets any board change being confirmed. Callers hold mu.
+func (c *Controller) resetDetection() {
+	c.stable = 0
+	c.settling = false
+	c.invalid = false
+	c.awaiting = false
+}
+
+// Observe feeds one reading of the board. Once a changed board has been
+// stable long enough it is matched against the legal moves: the human may
+// play any of them, the engine only the move it recommended.
+func (c *Controller) Observe(obs Observation) {
+	now := c.clock.Now()
+	occ := obs.Occupancy
+
+	c.mu.Lock()
+	if c.state != Playing {
+		c.mu.Unlock()
+		return
+	}
+	gs := c.gs
+	expected := gs.ExpectedOccupancy()
+
+	if c.restoring {
+		restored := occ == expected
+		if restored {
+			c.restoring = false
+		}
+		c.mu.Unlock()
+		if restored {
+			c.emit(Event{Kind: BoardRestored, Game: gs})
+		}
+		return
+	}
+
+	if c.awaiting && occ == c.stale {
+		// The board has yet to catch up with a move entered on screen.
+		c.stable = 0
+		c.settling = false
+		c.mu.Unlock()
+		return
+	}
+	c.awaiting = false
+
+	if occ == expected {
+		corrected := c.invalid
+		c.resetDetection()
+		c.mu.Unlock()
+		if corrected {
+			c.emit(Event{Kind: BoardCorrected, Game: gs})
+		}
+		return
+	}
+
+	if occ == c.pending {
+		c.stable++
+	} else {
+		c.pending = occ
+		c.stable = 1
+		c.settling = false
+	}
+	if !c.settling && c.stable >= c.settings.StabilityThreshold {
+		c.settling = true
+		c.settleStart = now
+	}
+	if !c.settling || now.Sub(c.settleStart) < c.settings.SettleTime {
+		c.mu.Unlock()
+		return
+	}
+	c.settling = false
+	c.stable = 0
+	rec := c.rec
+	c.mu.Unlock()
+
+	var move *chess.Move
+	var err error
+	switch {
+	case gs.IsHumanTurn():
+		// Brightness tells apart captures that leave the same occupancy.
+		move, err = gs.InferMoveWithColor(occ, obs.Brightness)
+	case rec != nil:
+		// Compare with the recommended move directly, so captures with
+		// the same occupancy cannot be mistaken for it.
+		if occ == gs.OccupancyAfterMove(rec) {
+			move = rec
+		} else {
+			err = fmt.Errorf("board does not match the engine's move %s", rec)
+		}
+	default:
+		// The engine has not chosen yet; accept any legal move.
+		move, err = gs.InferMoveWithColor(occ, obs.Brightness)
+	}
+	if err != nil {
+		c.mu.Lock()
+		c.invalid = true
+		c.mu.Unlock()
+		c.emit(Event{Kind: InvalidBoard, Game: gs, Squares: diffSquares(expected, occ), Err: err})
+		return
+	}
+	c.playMove(gs, move, false)
+}
+
+// EnterMove plays move as if it had been detected, for moves entered on
+// screen when the camera missed or misread them. Detection continues from
+// the position after the move, ignoring the board while it still shows the
+// position before it.
+func (c *Controller) EnterMove(move *chess.Move) error {
+	c.mu.Lock()
+	gs := c.gs
+	if c.state != Playing {
+		c.mu.Unlock()
+		return ErrNotPlaying
+	}
+	c.resetDetection()
+	c.pending = gs.OccupancyAfterMove(move)
+	c.stale = gs.ExpectedOccupancy()
+	c.awaiting = true
+	c.mu.Unlock()
+	return c.playMove(gs, move, true)
+}
+
+// playMove applies move to gs and moves the game on: ends it, asks the
+// engine for its reply, or starts pondering on the human's.
+func (c *Controller) playMove(gs *nchess.GameState, move *chess.Move, manual bool) error {
+	c.moveMu.Lock()
+	defer c.moveMu.Unlock()
+
+	c.mu.Lock()
+	if c.gs != gs || c.state != Playing {
+		c.mu.Unlock()
+		return ErrNotPlaying
+	}
+	eng, gen, settings := c.eng, c.gen, c.settings
+	expectedReply := c.reply
+	c.rec, c.reply = nil, nil
+	c.mu.Unlock()
+
+	byHuman := gs.IsHumanTurn()
+	pre := gs.Game().Position()
+	notation := gs.MoveToAlgebraic(move)
+	_, wasClaimable := gs.ClaimableDraw()
+	if err := gs.ApplyMove(move); err != nil {
+		return err
+	}
+	// A claimable draw is announced once, when the move makes it so.
+	claim := chess.NoMethod
+	if method, ok := gs.ClaimableDraw(); ok && !wasClaimable {
+		claim = method
+	}
+	c.emit(Event{Kind: MoveMade, Game: gs, Move: move, Position: pre, Notation: notation, ByHuman: byHuman, Manual: manual, Method: claim})
+
+	// Grade human moves once the engine has replied — the reply may come
+	// straight from a ponder search, which a review would abandon.
+	var review func()
+	if byHuman && eng != nil && settings.Review {
+		review = func() { c.review(gs, eng, gen, pre, move, settings.ReviewDepth) }
+	}
+
+	switch {
+	case gs.IsGameOver():
+		c.mu.Lock()
+		if c.gen == gen {
+			c.state = Over
+		}
+		c.mu.Unlock()
+		if review != nil {
+			c.background(review)
+		}
+		c.emit(Event{Kind: GameOver, Game: gs})
+		return nil
+	case !gs.IsHumanTurn() && eng != nil:
+		c.background(func() {
+			c.queryEngine(gs, eng, gen, settings.Depth)
+			if review != nil {
+				review()
+			}
+		})
+	case !byHuman && eng != nil && settings.Ponder && expectedReply != nil:
+		pos := gs.Game().Position()
+		c.background(func() {
+			if err := eng.Ponder(pos, expectedReply, settings.Depth); err != nil {
+				c.emit(Event{Kind: EngineError, Game: gs, Err: fmt.Errorf("pondering: %w", err)})
+				return
+			}
+			c.emit(Event{Kind: Pondering, Game: gs, Move: expectedReply, Position: pos,
+				Notation: chess.AlgebraicNotation{}.Encode(pos, expectedReply)})
+		})
+	}
+
+	if claim != chess.NoMethod {
+		c.emit(Event{Kind: DrawClaimable, Game: gs, Method: claim})
+	}
+	return nil
+}
+
+// queryEngine asks eng for its move in gs and records it as the move the
+// board must show next.
+func (c *Controller) queryEngine(gs *nchess.GameState, eng engine.Engine, gen, depth int) {
+	start := c.clock.Now()
+	pos := gs.Game().Position()
+	res, err := eng.Search(gs.Game(), depth)
+	if err != nil {
+		c.emit(Event{Kind: EngineError, Game: gs, Err: err})
+		return
+	}
+	c.mu.Lock()
+	if c.gen != gen || gs.Game().Position().Hash() != pos.Hash() {
+		c.mu.Unlock()
+		return // the game moved on while the engine was thinking
+	}
+	c.rec, c.reply = res.BestMove, res.Ponder
+	c.mu.Unlock()
+	c.emit(Event{
+		Kind:     Recommendation,
+		Game:     gs,
+		Move:     res.BestMove,
+		Position: pos,
+		Notation: chess.AlgebraicNotation{}.Encode(pos, res.BestMove),
+		Reply:    res.Ponder,
+		Elapsed:  c.clock.Now().Sub(start),
+		Score:    res.Score,
+	})
+}
+
+// review grades the human's move played from pos.
+func (c *Controller) review(gs *nchess.GameState, eng engine.Engine, gen int, pos *chess.Position, move *chess.Move, depth int) {
+	review, err := analysis.ReviewMove(eng, pos, move, depth)
+	if err != nil {
+		c.emit(Event{Kind: EngineError, Game: gs, Err: fmt.Errorf("move review: %w", err)})
+		return
+	}
+	c.mu.Lock()
+	stale := c.gen != gen
+	c.mu.Unlock()
+	if stale {
+		return
+	}
+	c.emit(Event{Kind: MoveReviewed, Game: gs, Move: move, Position: pos,
+		Notation: chess.AlgebraicNotation{}.Encode(pos, move), Review: review})
+}
+
+// Hint asks the engine for the human's best move in the background and
+// reports it as a Hint event.
+func (c *Controller) Hint() error {
+	c.mu.Lock()
+	gs, eng, gen, depth := c.gs, c.eng, c.gen, c.settings.Depth
+	state := c.state
+	c.mu.Unlock()
+
+	switch {
+	case state != Playing:
+		return ErrNotPlaying
+	case !gs.IsHumanTurn():
+		return ErrNotYourTurn
+	case eng == nil:
+		return ErrNoEngine
+	}
+	pos := gs.Game().Position()
+	c.background(func() {
+		move, err := eng.BestMove(gs.Game(), depth)
+		if err != nil {
+			c.emit(Event{Kind: EngineError, Game: gs, Err: fmt.Errorf("hint: %w", err)})
+			return
+		}
+		c.mu.Lock()
+		stale := c.gen != gen || gs.Game().Position().Hash() != pos.Hash()
+		c.mu.Unlock()
+		if stale {
+			return
+		}
+		c.emit(Event{Kind: Hint, Game: gs, Move: move, Position: pos,
+			Notation: chess.AlgebraicNotation{}.Encode(pos, move)})
+	})
+	return nil
+}
+
+// TakeBack undoes the human's last move, and the engine's reply if it has
+// been played, so the human is to move again. Move detection then waits for
+// the board to be put back, reported by BoardRestored.
+func (c *Controller) TakeBack() error {
+	c.mu.Lock()
+	gs, eng, rec := c.gs, c.eng, c.rec
+	state := c.state
+	c.mu.Unlock()
+
+	if state != Playing {
+		return ErrNotPlaying
+	}
+	plies := 2 // the human's move and the engine's reply
+	if !gs.IsHumanTurn() {
+		if rec == nil && eng != nil {
+			return ErrEngineThinking
+		}
+		plies = 1 // the reply has not been played yet
+	}
+
+	c.moveMu.Lock()
+	if plies > len(gs.Game().Moves()) {
+		c.moveMu.Unlock()
+		return ErrNoMoves
+	}
+	err := gs.TakeBack(plies)
+	c.moveMu.Unlock()
+	if err != nil {
+		return err
+	}
+	if eng != nil {
+		eng.StopPondering()
+	}
+
+	c.mu.Lock()
+	c.gen++
+	c.rec, c.reply = nil, nil
+	c.resetDetection()
+	c.restoring = true
+	c.mu.Unlock()
+	c.emit(Event{Kind: TakenBack, Game: gs, Plies: plies})
+	return nil
+}
+
+// Resign ends the game with the human resigning.
+func (c *Controller) Resign() error {
+	c.mu.Lock()
+	gs, eng := c.gs, c.eng
+	if c.state != Playing {
+		c.mu.Unlock()
+		return ErrNotPlaying
+	}
+	c.gen++
+	c.state = Over
+	c.rec, c.reply = nil, nil
+	c.mu.Unlock()
+
+	side := chess.White
+	if gs.HumanColor == nchess.Black {
+		side = chess.Black
+	}
+	c.moveMu.Lock()
+	gs.Resign(side)
+	c.moveMu.Unlock()
+	if eng != nil {
+		eng.StopPondering()
+	}
+	c.emit(Event{Kind: GameOver, Game: gs})
+	return nil
+}
+
+func (c *Controller) emit(ev Event) {
+	if c.OnEvent != nil {
+		c.OnEvent(ev)
+	}
+}
+
+// background runs f on its own goroutine, tracked by Wait.
+func (c *Controller) background(f func()) {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		f()
+	}()
+}
+
+// diffSquares returns the (row, col) of every square whose occupancy
+// differs between expected and observed.
+func diffSquares(expected, observed [8][8]bool) [][2]int {
+	var diffs [][2]int
+	for r := 0; r < 8; r++ {
+		for c := 0; c < 8; c++ {
+			if expected[r][c] != observed[r][c] {
+				diffs = append(diffs, [2]int{r, c})
+			}
+		}
+	}
+	return diffs
+}
@@ -0,0 +1,405 @@
+package game
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	nchess "github.com/intothevoid/nayan/pkg/chess"
+	"github.com/intothevoid/nayan/pkg/engine"
+	"github.com/notnil/chess"
+)
+
+// fakeClock is a clock that only moves when told to.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+// fakeEngine plays scripted SAN moves in order, expecting reply (if set) to
+// each, and records what it was asked to ponder. Everything else comes from
+// the built-in engine.
+type fakeEngine struct {
+	*engine.BuiltinEngine
+
+	mu       sync.Mutex
+	moves    []string
+	reply    string
+	pondered []*chess.Move
+	closed   bool
+}
+
+func (e *fakeEngine) next(pos *chess.Position) (*chess.Move, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.moves) == 0 {
+		return nil, errors.New("fake engine: out of moves")
+	}
+	san := e.moves[0]
+	e.moves = e.moves[1:]
+	return chess.AlgebraicNotation{}.Decode(pos, san)
+}
+
+func (e *fakeEngine) Search(game *chess.Game, depth int) (*engine.SearchResult, error) {
+	m, err := e.next(game.Position())
+	if err != nil {
+		return nil, err
+	}
+	res := &engine.SearchResult{BestMove: m}
+	if e.reply != "" {
+		res.Ponder, err = chess.AlgebraicNotation{}.Decode(game.Position().Update(m), e.reply)
+	}
+	return res, err
+}
+
+func (e *fakeEngine) BestMove(game *chess.Game, depth int) (*chess.Move, error) {
+	return e.next(game.Position())
+}
+
+func (e *fakeEngine) Ponder(pos *chess.Position, expected *chess.Move, depth int) error {
+	e.mu.Lock()
+	e.pondered = append(e.pondered, expected)
+	e.mu.Unlock()
+	return nil
+}
+
+func (e *fakeEngine) Close() {
+	e.mu.Lock()
+	e.closed = true
+	e.mu.Unlock()
+}
+
+// harness drives a controller with a fake clock and collects its events.
+type harness struct {
+	t     *testing.T
+	c     *Controller
+	clock *fakeClock
+	gs    *nchess.GameState
+
+	mu     sync.Mutex
+	events []Event
+}
+
+func newHarness(t *testing.T, human nchess.Color, eng engine.Engine) *harness {
+	h := &harness{t: t, clock: &fakeClock{now: time.Unix(0, 0)}}
+	h.c = NewController(h.clock)
+	s := h.c.Settings()
+	s.Review = false
+	h.c.SetSettings(s)
+	h.c.OnEvent = func(ev Event) {
+		h.mu.Lock()
+		h.events = append(h.events, ev)
+		h.mu.Unlock()
+	}
+	h.gs = nchess.NewGame(human)
+	h.c.Start(h.gs)
+	if eng != nil {
+		h.c.SetEngine(eng)
+	}
+	h.c.Wait()
+	return h
+}
+
+// take returns the events since the last call, after background work has
+// finished.
+func (h *harness) take() []Event {
+	h.c.Wait()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	events := h.events
+	h.events = nil
+	return events
+}
+
+// kinds lists the kinds of events.
+func kinds(events []Event) []EventKind {
+	var ks []EventKind
+	for _, ev := range events {
+		ks = append(ks, ev.Kind)
+	}
+	return ks
+}
+
+func (h *harness) expect(want ...EventKind) []Event {
+	h.t.Helper()
+	events := h.take()
+	got := kinds(events)
+	if len(got) != len(want) {
+		h.t.Fatalf("events = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			h.t.Fatalf("events = %v, want %v", got, want)
+		}
+	}
+	return events
+}
+
+// after returns the occupancy after san is played in the current position.
+func (h *harness) after(san string) [8][8]bool {
+	h.t.Helper()
+	m, err := chess.AlgebraicNotation{}.Decode(h.gs.Game().Position(), san)
+	if err != nil {
+		h.t.Fatalf("decode %s: %v", san, err)
+	}
+	return h.gs.OccupancyAfterMove(m)
+}
+
+// settle shows occ to the controller until it has been stable for the
+// whole settle period.
+func (h *harness) settle(occ [8][8]bool) {
+	s := h.c.Settings()
+	for i := 0; i < s.StabilityThreshold; i++ {
+		h.c.Observe(Observation{Occupancy: occ})
+	}
+	h.clock.Advance(s.SettleTime)
+	h.c.Observe(Observation{Occupancy: occ})
+}
+
+func TestHumanMoveThenEngineMove(t *testing.T) {
+	eng := &fakeEngine{BuiltinEngine: engine.NewBuiltinEngine(), moves: []string{"e5"}}
+	h := newHarness(t, nchess.White, eng)
+	h.expect(GameStarted)
+
+	h.settle(h.after("e4"))
+	events := h.expect(MoveMade, Recommendation)
+	if !events[0].ByHuman || events[0].Notation != "e4" {
+		t.Errorf("first move = %s (by human %v), want e4 by the human", events[0].Notation, events[0].ByHuman)
+	}
+	if events[1].Notation != "e5" {
+		t.Errorf("recommendation = %s, want e5", events[1].Notation)
+	}
+	if rec := h.c.Recommendation(); rec == nil || rec.String() != "e7e5" {
+		t.Errorf("Recommendation() = %v, want e7e5", rec)
+	}
+
+	h.settle(h.after("e5"))
+	events = h.expect(MoveMade)
+	if events[0].ByHuman || events[0].Notation != "e5" {
+		t.Errorf("second move = %s (by human %v), want e5 by the engine", events[0].Notation, events[0].ByHuman)
+	}
+	if h.c.Recommendation() != nil {
+		t.Error("recommendation should be cleared once played")
+	}
+}
+
+func TestSettleWaitsForStableBoard(t *testing.T) {
+	h := newHarness(t, nchess.White, nil)
+	h.take()
+	s := h.c.Settings()
+
+	// A hand over the board: the reading keeps changing.
+	e4, d4 := h.after("e4"), h.after("d4")
+	for i := 0; i < 3*s.StabilityThreshold; i++ {
+		occ := e4
+		if i%2 == 1 {
+			occ = d4
+		}
+		h.c.Observe(Observation{Occupancy: occ})
+		h.clock.Advance(s.SettleTime)
+	}
+	h.expect()
+
+	// Stable, but not yet for the whole settle period.
+	for i := 0; i < s.StabilityThreshold; i++ {
+		h.c.Observe(Observation{Occupancy: e4})
+	}
+	h.clock.Advance(s.SettleTime - time.Millisecond)
+	h.c.Observe(Observation{Occupancy: e4})
+	h.expect()
+
+	h.clock.Advance(time.Millisecond)
+	h.c.Observe(Observation{Occupancy: e4})
+	h.expect(MoveMade)
+}
+
+func TestWrongMoveForEngine(t *testing.T) {
+	eng := &fakeEngine{BuiltinEngine: engine.NewBuiltinEngine(), moves: []string{"e5"}}
+	h := newHarness(t, nchess.White, eng)
+	h.settle(h.after("e4"))
+	h.take()
+
+	// The engine wants e5, but d5 is played for it.
+	h.settle(h.after("d5"))
+	events := h.expect(InvalidBoard)
+	if len(events[0].Squares) != 2 {
+		t.Errorf("invalid squares = %v, want d7 and d5", events[0].Squares)
+	}
+
+	// Putting the pawn back clears the alert; the right move is accepted.
+	h.c.Observe(Observation{Occupancy: h.gs.ExpectedOccupancy()})
+	h.expect(BoardCorrected)
+	h.settle(h.after("e5"))
+	h.expect(MoveMade)
+}
+
+func TestEnterMoveAndPonder(t *testing.T) {
+	eng := &fakeEngine{BuiltinEngine: engine.NewBuiltinEngine(), moves: []string{"e4"}, reply: "c5"}
+	h := newHarness(t, nchess.Black, eng)
+	h.expect(GameStarted, Recommendation)
+
+	// The engine's move is entered on screen; the engine then thinks on
+	// the reply it expects.
+	if err := h.c.EnterMove(h.c.Recommendation()); err != nil {
+		t.Fatal(err)
+	}
+	events := h.expect(MoveMade, Pondering)
+	if !events[0].Manual || events[0].ByHuman {
+		t.Errorf("entered move: manual %v, by human %v", events[0].Manual, events[0].ByHuman)
+	}
+	if len(eng.pondered) != 1 || eng.pondered[0].String() != "c7c5" {
+		t.Errorf("pondered %v, want [c7c5]", eng.pondered)
+	}
+}
+
+func TestEnterMoveBeforeBoardUpdated(t *testing.T) {
+	h := newHarness(t, nchess.White, nil)
+	h.take()
+	before := h.gs.ExpectedOccupancy()
+	move, err := chess.AlgebraicNotation{}.Decode(h.gs.Game().Position(), "e4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.c.EnterMove(move); err != nil {
+		t.Fatal(err)
+	}
+	h.expect(MoveMade)
+
+	// The board still shows the position before the move: no alert.
+	h.settle(before)
+	h.expect()
+
+	// Once the board catches up, the next move is detected as usual.
+	h.settle(h.gs.ExpectedOccupancy())
+	h.expect()
+	h.settle(h.after("e5"))
+	h.expect(MoveMade)
+
+	// Going back to the old board now is an error like any other.
+	h.settle(before)
+	h.expect(InvalidBoard)
+}
+
+func TestSnapshot(t *testing.T) {
+	h := newHarness(t, nchess.White, nil)
+	h.settle(h.after("e4"))
+	h.take()
+
+	snap := h.c.Snapshot()
+	if snap == h.gs || snap.FEN() != h.gs.FEN() {
+		t.Fatalf("snapshot %s is not a copy of %s", snap.FEN(), h.gs.FEN())
+	}
+	h.settle(h.after("e5"))
+	h.take()
+	if n := len(snap.Game().Moves()); n != 1 {
+		t.Errorf("snapshot has %d moves after the game went on, want 1", n)
+	}
+}
+
+func TestDrawClaimableAnnouncedOnce(t *testing.T) {
+	h := newHarness(t, nchess.White, nil)
+	h.take()
+	shuffle := []string{"Nf3", "Nf6", "Ng1", "Ng8", "Nf3", "Nf6", "Ng1"}
+	for _, san := range shuffle {
+		h.settle(h.after(san))
+		h.expect(MoveMade)
+	}
+
+	// Ng8 repeats the starting position a third time...
+	h.settle(h.after("Ng8"))
+	events := h.expect(MoveMade, DrawClaimable)
+	if events[0].Method != chess.ThreefoldRepetition || events[1].Method != chess.ThreefoldRepetition {
+		t.Errorf("claim methods %v and %v, want threefold repetition", events[0].Method, events[1].Method)
+	}
+	// ...and Nf3 keeps the claim, which is not announced again.
+	h.settle(h.after("Nf3"))
+	if _, ok := h.gs.ClaimableDraw(); !ok {
+		t.Fatal("draw no longer claimable after Nf3")
+	}
+	if events := h.expect(MoveMade); events[0].Method != chess.NoMethod {
+		t.Errorf("claim method %v after the claim was announced", events[0].Method)
+	}
+}
+
+func TestTakeBack(t *testing.T) {
+	eng := &fakeEngine{BuiltinEngine: engine.NewBuiltinEngine(), moves: []string{"e5"}}
+	h := newHarness(t, nchess.White, eng)
+	if err := h.c.TakeBack(); !errors.Is(err, ErrNoMoves) {
+		t.Errorf("TakeBack at the start = %v, want ErrNoMoves", err)
+	}
+
+	h.settle(h.after("e4"))
+	h.take()
+	h.settle(h.after("e5"))
+	h.take()
+	played := h.gs.ExpectedOccupancy()
+
+	if err := h.c.TakeBack(); err != nil {
+		t.Fatal(err)
+	}
+	events := h.expect(TakenBack)
+	if events[0].Plies != 2 || len(h.gs.Game().Moves()) != 0 {
+		t.Errorf("took back %d plies leaving %d moves, want 2 and 0", events[0].Plies, len(h.gs.Game().Moves()))
+	}
+
+	// The board still shows the moves; nothing is inferred until it has
+	// been put back.
+	h.settle(played)
+	h.expect()
+	h.c.Observe(Observation{Occupancy: h.gs.ExpectedOccupancy()})
+	h.expect(BoardRestored)
+}
+
+func TestResign(t *testing.T) {
+	h := newHarness(t, nchess.Black, nil)
+	h.take()
+	if err := h.c.Resign(); err != nil {
+		t.Fatal(err)
+	}
+	h.expect(GameOver)
+	if h.c.State() != Over || h.gs.Game().Outcome() != chess.WhiteWon {
+		t.Errorf("after resigning: state %s, outcome %s", h.c.State(), h.gs.Game().Outcome())
+	}
+	if err := h.c.Resign(); !errors.Is(err, ErrNotPlaying) {
+		t.Errorf("second Resign = %v, want ErrNotPlaying", err)
+	}
+}
+
+func TestHint(t *testing.T) {
+	eng := &fakeEngine{BuiltinEngine: engine.NewBuiltinEngine(), moves: []string{"d4"}}
+	h := newHarness(t, nchess.White, eng)
+	h.take()
+	if err := h.c.Hint(); err != nil {
+		t.Fatal(err)
+	}
+	events := h.expect(Hint)
+	if events[0].Notation != "d4" {
+		t.Errorf("hint = %s, want d4", events[0].Notation)
+	}
+}
+
+func TestStopClosesEngine(t *testing.T) {
+	eng := &fakeEngine{BuiltinEngine: engine.NewBuiltinEngine()}
+	h := newHarness(t, nchess.White, eng)
+	h.c.Stop()
+	if !eng.closed || h.c.State() != Idle || h.c.Game() != nil {
+		t.Errorf("after Stop: engine closed %v, state %s", eng.closed, h.c.State())
+	}
+	// Observations are ignored while idle.
+	h.take()
+	h.settle(h.after("e4"))
+	h.expect()
+}
@@ -0,0 +1,75 @@
+package game
+
+import (
+	"time"
+
+	"github.com/intothevoid/nayan/pkg/analysis"
+	nchess "github.com/intothevoid/nayan/pkg/chess"
+	"github.com/intothevoid/nayan/pkg/engine"
+	"github.com/notnil/chess"
+)
+
+// EventKind identifies what happened in a game.
+type EventKind int
+
+const (
+	GameStarted    EventKind = iota
+	MoveMade                 // Move was played; ByHuman tells whose it was
+	InvalidBoard             // the board matches no legal move; Squares differ from the game
+	BoardCorrected           // the board matches the game again after InvalidBoard
+	Recommendation           // the engine chose Move for its side, expecting Reply
+	Pondering                // the engine is thinking ahead on Move, the human's expected reply
+	MoveReviewed             // Review grades the human's Move
+	Hint                     // Move is the engine's suggestion for the human
+	DrawClaimable            // Method has just made a draw claimable
+	TakenBack                // Plies moves were undone; the board must be put back
+	BoardRestored            // the board matches the game again after TakenBack
+	GameOver                 // the game has ended; see Game.Outcome
+	EngineError              // the engine failed with Err
+)
+
+var eventKindNames = [...]string{
+	GameStarted:    "game_started",
+	MoveMade:       "move_made",
+	InvalidBoard:   "invalid_board",
+	BoardCorrected: "board_corrected",
+	Recommendation: "recommendation",
+	Pondering:      "pondering",
+	MoveReviewed:   "move_reviewed",
+	Hint:           "hint",
+	DrawClaimable:  "draw_claimable",
+	TakenBack:      "taken_back",
+	BoardRestored:  "board_restored",
+	GameOver:       "game_over",
+	EngineError:    "engine_error",
+}
+
+func (k EventKind) String() string {
+	if int(k) < len(eventKindNames) {
+		return eventKindNames[k]
+	}
+	return "unknown"
+}
+
+// Event reports a change in the game. Only the fields relevant to Kind are
+// set.
+type Event struct {
+	Kind EventKind
+	Game *nchess.GameState
+
+	Move     *chess.Move
+	Position *chess.Position // position Move is played from
+	Notation string          // Move in SAN
+	ByHuman  bool            // MoveMade: the human's move rather than the engine's
+	Manual   bool            // MoveMade: entered on screen rather than detected
+
+	Reply   *chess.Move        // Recommendation: the reply the engine expects
+	Elapsed time.Duration      // Recommendation: search time
+	Score   *engine.Evaluation // Recommendation: the engine's score for its side, nil if unknown
+
+	Squares [][2]int             // InvalidBoard: squares (row, col) that differ from the game
+	Review  *analysis.MoveReview // MoveReviewed
+	Method  chess.Method         // DrawClaimable; MoveMade: the draw it made claimable, NoMethod if none
+	Plies   int                  // TakenBack
+	Err     error                // InvalidBoard, EngineError
+}
@@ -0,0 +1,499 @@
+package headless
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/intothevoid/nayan/pkg/audio"
+	"github.com/intothevoid/nayan/pkg/calibration"
+	"github.com/intothevoid/nayan/pkg/camera"
+	nchess "github.com/intothevoid/nayan/pkg/chess"
+	"github.com/intothevoid/nayan/pkg/commentary"
+	"github.com/intothevoid/nayan/pkg/config"
+	"github.com/intothevoid/nayan/pkg/engine"
+	"github.com/intothevoid/nayan/pkg/game"
+	"github.com/intothevoid/nayan/pkg/overlay"
+	"github.com/intothevoid/nayan/pkg/pipeline"
+	"github.com/intothevoid/nayan/pkg/relay"
+	"github.com/intothevoid/nayan/pkg/server"
+	"github.com/intothevoid/nayan/pkg/speech"
+	"github.com/intothevoid/nayan/pkg/vision"
+	"github.com/intothevoid/nayan/pkg/voice"
+	"github.com/notnil/chess"
+)
+
+// Options configures a headless session.
+type Options struct {
+	Config   config.Config // camera, detection, engine and voice
+	Human    nchess.Color
+	Settings game.Settings // depth, stability and settle time are taken from Config
+
+	LogPath       string // also append the log to this file
+	Record        string // record the camera to this .avi file for replay
+	VoiceCommands bool   // listen for spoken commands (see package voice)
+}
+
+// DefaultOptions returns the options for the default config and game
+// settings.
+func DefaultOptions() Options {
+	return Options{
+		Config:   config.Default(),
+		Human:    nchess.White,
+		Settings: game.DefaultSettings(),
+	}
+}
+
+// AddFlags registers flags on fs that set the headless options other than
+// Config, which comes from config.AddFlags.
+func (o *Options) AddFlags(fs *flag.FlagSet) {
+	fs.BoolFunc("black", "the human plays Black", func(string) error {
+		o.Human = nchess.Black
+		return nil
+	})
+	fs.StringVar(&o.LogPath, "log", o.LogPath, "also append the log to this file")
+	fs.StringVar(&o.Record, "record", o.Record, "record the camera to this .avi file for replay")
+	fs.BoolVar(&o.VoiceCommands, "listen", o.VoiceCommands, "accept spoken commands (needs "+voice.DefaultCommand+")")
+}
+
+// setupFrames is how many consecutive frames must show the starting
+// position before a new game begins (~1 second).
+const setupFrames = 30
+
+// session is a running headless game loop.
+type session struct {
+	opts       Options
+	log        *log.Logger
+	controller *game.Controller
+	player     audio.Player
+	speaker    speech.Speaker
+	lang       *commentary.Language
+	api        *server.Server   // nil unless the config enables it
+	overlay    *overlay.Overlay // nil unless the config enables it
+	relay      *relay.Board     // nil unless the config enables it
+
+	mu         sync.Mutex
+	alertStop  chan struct{} // stops the invalid board alert
+	setupCount int           // consecutive frames showing the starting position
+}
+
+// Run plays games against the engine with no display: it captures frames
+// from the camera, detects moves with the same pipeline as the GUI, and
+// logs moves, recommendations and results to stdout and opts.LogPath,
+// speaking them if the config's voiceover is enabled, serving them if its
+// server is, rendering the board if its overlay is and relaying it as PGN if
+// its relay is. A game begins whenever the board shows the starting
+// position and no game is in progress. Run returns when ctx is cancelled.
+func Run(ctx context.Context, opts Options) error {
+	cfg := opts.Config
+	opts.Settings.Depth = cfg.Engine.Depth
+	opts.Settings.StabilityThreshold = cfg.Game.StabilityFrames
+	opts.Settings.SettleTime = cfg.Game.SettleTime
+	out := io.Writer(os.Stdout)
+	if opts.LogPath != "" {
+		f, err := os.OpenFile(opts.LogPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = io.MultiWriter(os.Stdout, f)
+	}
+	s := &session{
+		opts:       opts,
+		log:        log.New(out, "", log.LstdFlags),
+		controller: game.NewController(nil),
+		player:     audio.Nop{},
+		speaker:    speech.Silent{},
+		lang:       commentary.ByCode(cfg.Voice.Language),
+	}
+
+	device := cfg.Camera.Device
+	path, err := cfg.CalibrationPath()
+	if err != nil {
+		return err
+	}
+	calib, err := calibration.Load(path)
+	if errors.Is(err, calibration.ErrNotCalibrated) {
+		return fmt.Errorf("%w: calibrate the board first (no corners saved at %s)", err, path)
+	}
+	if err != nil {
+		return err
+	}
+	if calib.Device != device {
+		s.log.Printf("Calibration was saved for camera %d; using it for camera %d", calib.Device, device)
+	}
+
+	stream, err := camera.NewVideoStreamSize(device, cfg.Camera.Width, cfg.Camera.Height)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+	if opts.Record != "" {
+		if err := stream.Record(opts.Record); err != nil {
+			return err
+		}
+		s.log.Printf("Recording the camera to %s", opts.Record)
+	}
+
+	if p, err := audio.Detect(); err != nil {
+		s.log.Print("No sound player found (install paplay or aplay); alerts are muted")
+	} else {
+		s.player = p
+	}
+	if cfg.Voice.Enabled {
+		if sp, err := findSpeaker(cfg.Voice.Backend); err != nil {
+			s.log.Printf("Voiceover disabled: %v", err)
+		} else {
+			s.speaker = sp
+			s.log.Printf("Voiceover using %s", sp.Name())
+		}
+	}
+	if opts.VoiceCommands {
+		go s.listen(ctx)
+	}
+
+	if cfg.Server.Enabled {
+		s.serve(ctx, cfg.Server.Addr)
+	}
+	if cfg.Overlay.Enabled() {
+		if err := s.startOverlay(ctx, cfg.Overlay); err != nil {
+			return err
+		}
+	}
+	if cfg.Relay.Enabled() {
+		rb, err := relay.New(cfg.Relay)
+		if err != nil {
+			return err
+		}
+		rb.OnError = func(err error) { s.log.Print(err) }
+		s.relay = rb
+		s.log.Printf("Relaying board %d to %s", cfg.Relay.Board, rb.Path())
+	}
+
+	s.controller.SetSettings(opts.Settings)
+	s.controller.OnEvent = s.event
+	defer s.controller.Stop()
+	defer s.stopAlert()
+
+	pipe := pipeline.New(s.controller)
+	pipe.SetParams(vision.Params(cfg.Vision))
+	pipe.SetCorners(calib.Points())
+	pipe.OnFrame = s.frame
+	s.log.Printf("Watching camera %d; set up the pieces to start a game", device)
+	return pipe.Run(ctx, stream)
+}
+
+// frame starts a game once the starting position has been on the board
+// for setupFrames frames while no game is in progress.
+func (s *session) frame(f *pipeline.Frame) {
+	if s.api != nil && f.Detected() {
+		s.api.SetOccupancy(f.Occupancy)
+	}
+	if !f.Detected() || s.controller.State() == game.Playing {
+		return
+	}
+	s.mu.Lock()
+	if f.Occupancy != nchess.NewGame(s.opts.Human).ExpectedOccupancy() {
+		s.setupCount = 0
+		s.mu.Unlock()
+		return
+	}
+	s.setupCount++
+	ready := s.setupCount == setupFrames
+	s.mu.Unlock()
+	if ready {
+		s.newGame()
+	}
+}
+
+// newGame starts a game from the starting position and launches the
+// engine for it.
+func (s *session) newGame() {
+	s.mu.Lock()
+	s.setupCount = 0
+	human := s.opts.Human
+	s.mu.Unlock()
+	s.stopAlert()
+	s.controller.Start(nchess.NewGame(human))
+
+	go func() {
+		eng, err := s.startEngine()
+		if err != nil {
+			s.log.Printf("Engine not available: %v", err)
+			return
+		}
+		s.log.Printf("%s engine started", eng.ID())
+		s.controller.SetEngine(eng)
+	}()
+}
+
+// startEngine launches the engine and applies the user's saved option
+// overrides for it, as the GUI does.
+func (s *session) startEngine() (engine.Engine, error) {
+	var path []string
+	if p := s.opts.Config.Engine.Path; p != "" {
+		path = append(path, p)
+	}
+	eng, err := engine.NewEngine(path...)
+	if err != nil {
+		return nil, err
+	}
+	if err := engine.ApplySavedOptions(eng, nil); err != nil {
+		s.log.Printf("Engine options: %v", err)
+	}
+	return eng, nil
+}
+
+// serve starts the API server on addr. Games started through it use the
+// requested colour from then on; a stopped game restarts once the pieces
+// are set up again, as after any game.
+func (s *session) serve(ctx context.Context, addr string) {
+	s.api = server.New(s.controller)
+	s.api.StartGame = func(human nchess.Color) error {
+		s.mu.Lock()
+		s.opts.Human = human
+		s.mu.Unlock()
+		s.newGame()
+		return nil
+	}
+	s.api.StopGame = func() error {
+		s.stopAlert()
+		s.controller.Stop()
+		s.log.Print("Game stopped through the API")
+		return nil
+	}
+	go func() {
+		if err := s.api.ListenAndServe(ctx, addr); err != nil {
+			s.log.Printf("API server: %v", err)
+		}
+	}()
+	s.log.Printf("Serving the game API on %s", addr)
+	if urls, err := server.URLs(addr); err == nil {
+		s.log.Printf("Spectators can watch at %s", strings.Join(urls, " or "))
+	}
+}
+
+// startOverlay renders the board to the PNG file and MJPEG address in
+// cfg, whichever are set.
+func (s *session) startOverlay(ctx context.Context, cfg config.Overlay) error {
+	o, err := overlay.New(cfg)
+	if err != nil {
+		return err
+	}
+	o.OnError = func(err error) { s.log.Print(err) }
+	s.overlay = o
+	go o.Run(ctx)
+	if cfg.PNG != "" {
+		s.log.Printf("Writing the board overlay to %s", cfg.PNG)
+	}
+	if cfg.MJPEG != "" {
+		go func() {
+			if err := o.ListenAndServe(ctx, cfg.MJPEG); err != nil {
+				s.log.Printf("Overlay server: %v", err)
+			}
+		}()
+		s.log.Printf("Serving the board overlay at http://%s/board.mjpg", cfg.MJPEG)
+	}
+	return nil
+}
+
+// event logs and announces a game event.
+func (s *session) event(ev game.Event) {
+	if s.api != nil {
+		s.api.Publish(ev)
+	}
+	if s.overlay != nil {
+		s.overlay.Publish(ev)
+	}
+	if s.relay != nil {
+		s.relay.Publish(ev)
+	}
+	gs := ev.Game
+	lang := s.lang
+	switch ev.Kind {
+	case game.GameStarted:
+		s.log.Printf("Game started, human plays %s", colorName(gs.HumanColor))
+
+	case game.MoveMade:
+		who := "Engine"
+		if ev.ByHuman {
+			who = "Human"
+		}
+		s.log.Printf("%s %s: %s", moveNumber(gs), who, ev.Notation)
+		s.stopAlert()
+
+		// Engine moves were announced when recommended.
+		var spoken []string
+		if ev.ByHuman {
+			spoken = append(spoken, lang.Describe(ev.Move, ev.Position, false, commentary.Options{}))
+		}
+		if gs.IsGameOver() {
+			spoken = append(spoken, lang.Outcome(gs.Game()))
+		} else if ev.Method != chess.NoMethod {
+			spoken = append(spoken, lang.Claimable(ev.Method))
+		}
+		s.say(commentary.Join(spoken...))
+
+	case game.Recommendation:
+		s.log.Printf("Engine plays %s (%s); make it on the board", ev.Notation, ev.Elapsed.Round(time.Millisecond))
+		s.say(lang.Describe(ev.Move, ev.Position, true, commentary.Options{}))
+
+	case game.Hint:
+		s.log.Printf("Hint: %s", ev.Notation)
+		s.say(lang.Describe(ev.Move, ev.Position, true, commentary.Options{}))
+
+	case game.MoveReviewed:
+		if ev.Review != nil {
+			s.log.Printf("Move review: %s — %s (loss %d cp)", ev.Notation, ev.Review.Class, ev.Review.CPLoss)
+		}
+
+	case game.DrawClaimable:
+		s.log.Printf("A draw can be claimed (%s)", ev.Method)
+
+	case game.InvalidBoard:
+		s.mu.Lock()
+		first := s.alertStop == nil
+		if first {
+			s.alertStop = make(chan struct{})
+			go s.alertLoop(s.alertStop)
+		}
+		s.mu.Unlock()
+		if first {
+			s.log.Printf("Invalid board: %v", ev.Err)
+			s.say(lang.InvalidMove)
+		}
+
+	case game.BoardCorrected:
+		s.stopAlert()
+		s.log.Print("Board corrected")
+
+	case game.TakenBack:
+		s.stopAlert()
+		s.log.Printf("Took back %d moves; put the pieces back", ev.Plies)
+
+	case game.BoardRestored:
+		s.log.Print("Board restored")
+
+	case game.GameOver:
+		s.log.Printf("Game over: %s", gs.Outcome())
+		s.log.Printf("PGN:\n%s", gs.Game().String())
+		if gs.Game().Method() == chess.Resignation {
+			s.say(lang.Outcome(gs.Game()))
+		}
+		s.log.Print("Set up the pieces to start a new game")
+
+	case game.EngineError:
+		s.log.Printf("Engine error: %v", ev.Err)
+	}
+}
+
+// listen maps spoken commands to the controller until ctx is cancelled.
+func (s *session) listen(ctx context.Context) {
+	r, err := voice.Detect()
+	if err != nil {
+		s.log.Printf("Voice commands disabled: %v", err)
+		return
+	}
+	s.log.Printf("Listening for voice commands with %s", r.Name())
+	var confirmer voice.Confirmer
+	err = voice.Listen(ctx, r, func(c voice.Command, text string) {
+		s.log.Printf("Heard %q: %s", text, c)
+		if c.NeedsConfirmation() {
+			prompt := fmt.Sprintf("Say yes to confirm %s", c)
+			s.log.Printf("%s, within %s", prompt, voice.ConfirmTimeout)
+			s.say(prompt)
+		}
+		c = confirmer.Resolve(c)
+		var err error
+		switch c {
+		case voice.Hint:
+			err = s.controller.Hint()
+		case voice.TakeBack:
+			err = s.controller.TakeBack()
+		case voice.NewGame:
+			s.newGame()
+		case voice.Resign:
+			err = s.controller.Resign()
+		}
+		if err != nil {
+			s.log.Printf("%s: %v", c, err)
+		}
+	})
+	if err != nil {
+		s.log.Printf("Voice commands stopped: %v", err)
+	}
+}
+
+// findSpeaker returns the installed text-to-speech backend called name, or
+// the detected one if name is empty.
+func findSpeaker(name string) (speech.Speaker, error) {
+	if name == "" {
+		return speech.Detect()
+	}
+	if name == (speech.Silent{}).Name() {
+		return speech.Silent{}, nil
+	}
+	for _, sp := range speech.Available() {
+		if sp.Name() == name {
+			return sp, nil
+		}
+	}
+	return nil, fmt.Errorf("text-to-speech backend %q is not installed", name)
+}
+
+// say speaks text in the background if voiceover is on.
+func (s *session) say(text string) {
+	if text == "" {
+		return
+	}
+	go s.speaker.Say(s.opts.Config.Voice.Name, text)
+}
+
+// alertLoop plays the alert at the configured interval until stop is
+// closed.
+func (s *session) alertLoop(stop <-chan struct{}) {
+	ticker := time.NewTicker(s.opts.Config.Game.AlertInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.player.Play(audio.Alert); err != nil {
+			s.log.Printf("Alert sound failed: %v", err)
+		}
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *session) stopAlert() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.alertStop != nil {
+		close(s.alertStop)
+		s.alertStop = nil
+	}
+}
+
+// moveNumber numbers the last move played, e.g. "12." or "12...".
+func moveNumber(gs *nchess.GameState) string {
+	plies := gs.StartPly() + len(gs.Game().Moves())
+	if plies%2 == 1 {
+		return fmt.Sprintf("%d.", (plies+1)/2)
+	}
+	return fmt.Sprintf("%d...", plies/2)
+}
+
+func colorName(c nchess.Color) string {
+	if c == nchess.White {
+		return "White"
+	}
+	return "Black"
+}
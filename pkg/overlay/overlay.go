@@ -0,0 +1,305 @@
+// Package overlay renders the virtual board for broadcast overlays. It
+// follows the game controller's events and draws the board offscreen at a
+// fixed size: pieces, the last move, check, invalid squares, the engine's
+// arrows and an eval bar. Frames are written as PNG files with a
+// transparent background and served as MJPEG for capture software that
+// reads a URL:
+//
+//	GET /board.mjpg  the board as an MJPEG stream over the background colour
+//	GET /board.png   the latest frame, transparent
+package overlay
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/intothevoid/nayan/pkg/atomicfile"
+	nchess "github.com/intothevoid/nayan/pkg/chess"
+	"github.com/intothevoid/nayan/pkg/config"
+	"github.com/intothevoid/nayan/pkg/engine"
+	"github.com/intothevoid/nayan/pkg/game"
+	"github.com/intothevoid/nayan/pkg/ui"
+	"github.com/notnil/chess"
+)
+
+// jpegQuality for MJPEG frames.
+const jpegQuality = 90
+
+// Overlay keeps a scene of the board up to date from game events and
+// renders it. Publish is cheap; rendering and writing happen in Run.
+type Overlay struct {
+	// OnError, if set, reports PNG frames that could not be written. It is
+	// called from Run.
+	OnError func(error)
+
+	cfg config.Overlay
+	bg  color.NRGBA
+
+	mu    sync.Mutex
+	scene ui.BoardScene
+	theme ui.BoardTheme
+	set   ui.PieceSet
+	frame *image.RGBA // latest render
+	jpeg  []byte      // frame over bg, encoded on first request
+	seq   int         // frames rendered; numbers PNG files
+
+	dirty chan struct{} // signalled when the scene or appearance changes
+}
+
+// New returns an overlay for the settings in cfg, showing the starting
+// position until a game starts.
+func New(cfg config.Overlay) (*Overlay, error) {
+	bg, err := cfg.BackgroundColor()
+	if err != nil {
+		return nil, fmt.Errorf("overlay background: %w", err)
+	}
+	o := &Overlay{
+		cfg:   cfg,
+		bg:    bg,
+		scene: ui.BoardScene{Pieces: ui.StartingPosition()},
+		theme: ui.DefaultBoardTheme,
+		set:   ui.DefaultPieceSet,
+		dirty: make(chan struct{}, 1),
+	}
+	o.frame = o.render()
+	return o, nil
+}
+
+// SetTheme draws the board with a colour palette, as BoardWidget.SetTheme.
+func (o *Overlay) SetTheme(theme ui.BoardTheme) {
+	o.mu.Lock()
+	o.theme = theme
+	o.mu.Unlock()
+	o.changed()
+}
+
+// SetPieceSet draws the pieces from set, as BoardWidget.SetPieceSet.
+func (o *Overlay) SetPieceSet(set ui.PieceSet) {
+	o.mu.Lock()
+	o.set = set
+	o.mu.Unlock()
+	o.changed()
+}
+
+// Publish updates the scene for a controller event.
+func (o *Overlay) Publish(ev game.Event) {
+	gs := ev.Game
+	o.mu.Lock()
+	s := &o.scene
+	switch ev.Kind {
+	case game.GameStarted:
+		*s = ui.BoardScene{Pieces: ui.PiecesFromChess(gs.PieceGrid()), Flipped: gs.HumanColor == nchess.Black}
+
+	case game.MoveMade, game.TakenBack:
+		s.Pieces = ui.PiecesFromChess(gs.PieceGrid())
+		s.HasMove, s.InCheck = false, false
+		s.Invalid, s.Arrows = nil, nil
+		if moves := gs.Game().Moves(); len(moves) > 0 {
+			last := moves[len(moves)-1]
+			s.HasMove = true
+			s.FromRow, s.FromCol, s.ToRow, s.ToCol = squares(last)
+			s.CheckRow, s.CheckCol, s.InCheck = gs.CheckedKingSquare(last)
+		}
+
+	case game.Recommendation:
+		// Like the app's board: the engine's move is highlighted and
+		// arrowed until it is played.
+		s.HasMove = true
+		s.FromRow, s.FromCol, s.ToRow, s.ToCol = squares(ev.Move)
+		s.Arrows = []ui.Arrow{arrow(ev.Move, ui.AnnotationGreen)}
+		if ev.Score != nil && ev.Position != nil {
+			s.Eval, _ = engine.WhiteScore(ev.Score.Centipawns(), ev.Position.Turn())
+		}
+
+	case game.MoveReviewed:
+		if ev.Review != nil && ev.Position != nil {
+			s.Eval, _ = engine.WhiteScore(ev.Review.EvalAfter, ev.Position.Turn())
+		}
+
+	case game.Hint:
+		s.Arrows = []ui.Arrow{arrow(ev.Move, ui.AnnotationBlue)}
+
+	case game.InvalidBoard:
+		s.Invalid = ev.Squares
+
+	case game.BoardCorrected:
+		s.Invalid = nil
+
+	default:
+		o.mu.Unlock()
+		return
+	}
+	o.mu.Unlock()
+	o.changed()
+}
+
+// changed asks Run for a new frame.
+func (o *Overlay) changed() {
+	select {
+	case o.dirty <- struct{}{}:
+	default: // a frame is already pending
+	}
+}
+
+// Run renders a frame whenever the scene changes, writing it to the PNG
+// path if one is set, until ctx is cancelled.
+func (o *Overlay) Run(ctx context.Context) {
+	if err := o.writePNG(o.Frame(), 0); err != nil && o.OnError != nil {
+		o.OnError(err)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-o.dirty:
+		}
+		img := o.render()
+		o.mu.Lock()
+		o.frame, o.jpeg = img, nil
+		o.seq++
+		seq := o.seq
+		o.mu.Unlock()
+		if err := o.writePNG(img, seq); err != nil && o.OnError != nil {
+			o.OnError(err)
+		}
+	}
+}
+
+// Frame returns the latest rendered frame. It must not be modified.
+func (o *Overlay) Frame() *image.RGBA {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.frame
+}
+
+// render draws the current scene.
+func (o *Overlay) render() *image.RGBA {
+	o.mu.Lock()
+	scene := o.scene
+	opts := ui.RenderOptions{Width: o.cfg.Width, Height: o.cfg.Height, Theme: o.theme, PieceSet: o.set, EvalBar: o.cfg.EvalBar}
+	o.mu.Unlock()
+	return ui.RenderBoard(scene, opts)
+}
+
+// writePNG writes frame seq to the PNG path, if one is set. A single file
+// is replaced by renaming so readers never see it half written.
+func (o *Overlay) writePNG(img image.Image, seq int) error {
+	path := o.cfg.PNG
+	if path == "" {
+		return nil
+	}
+	if strings.Contains(path, "%") {
+		path = fmt.Sprintf(path, seq)
+	}
+	err := atomicfile.Write(path, ".overlay-*.png", func(w io.Writer) error {
+		return png.Encode(w, img)
+	})
+	if err != nil {
+		return fmt.Errorf("overlay frame: %w", err)
+	}
+	return nil
+}
+
+// jpegFrame returns the latest frame over the background colour as JPEG.
+func (o *Overlay) jpegFrame() ([]byte, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.jpeg != nil {
+		return o.jpeg, nil
+	}
+	img := image.NewRGBA(o.frame.Bounds())
+	draw.Draw(img, img.Bounds(), image.NewUniform(o.bg), image.Point{}, draw.Src)
+	draw.Draw(img, img.Bounds(), o.frame, image.Point{}, draw.Over)
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, err
+	}
+	o.jpeg = buf.Bytes()
+	return o.jpeg, nil
+}
+
+// Handler returns the HTTP handler serving the MJPEG stream and the
+// latest PNG.
+func (o *Overlay) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /board.mjpg", o.mjpeg)
+	mux.HandleFunc("GET /board.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Cache-Control", "no-store")
+		png.Encode(w, o.Frame())
+	})
+	return mux
+}
+
+// ListenAndServe serves Handler on addr until ctx is cancelled.
+func (o *Overlay) ListenAndServe(ctx context.Context, addr string) error {
+	srv := &http.Server{Addr: addr, Handler: o.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// mjpeg streams the latest frame at the configured rate until the client
+// disconnects. Unchanged frames are resent because capture software treats
+// a silent stream as ended.
+func (o *Overlay) mjpeg(w http.ResponseWriter, r *http.Request) {
+	const boundary = "frame"
+	w.Header().Set("Content-Type", "multipart/x-mixed-replace; boundary="+boundary)
+	w.Header().Set("Cache-Control", "no-store")
+	rc := http.NewResponseController(w)
+
+	tick := time.NewTicker(time.Second / time.Duration(o.cfg.FPS))
+	defer tick.Stop()
+	for {
+		data, err := o.jpegFrame()
+		if err != nil {
+			return
+		}
+		if _, err := fmt.Fprintf(w, "--%s\r\nContent-Type: image/jpeg\r\nContent-Length: %d\r\n\r\n", boundary, len(data)); err != nil {
+			return
+		}
+		if _, err := w.Write(data); err != nil {
+			return
+		}
+		if _, err := io.WriteString(w, "\r\n"); err != nil {
+			return
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-tick.C:
+		}
+	}
+}
+
+// squares returns a move's from and to squares in board coordinates.
+func squares(m *chess.Move) (fromRow, fromCol, toRow, toCol int) {
+	fromRow, fromCol = nchess.RowColFromSquare(m.S1())
+	toRow, toCol = nchess.RowColFromSquare(m.S2())
+	return
+}
+
+func arrow(m *chess.Move, c color.Color) ui.Arrow {
+	fromRow, fromCol, toRow, toCol := squares(m)
+	return ui.Arrow{FromRow: fromRow, FromCol: fromCol, ToRow: toRow, ToCol: toCol, Color: c}
+}
@@ -0,0 +1,190 @@
+package overlay
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	nchess "github.com/intothevoid/nayan/pkg/chess"
+	"github.com/intothevoid/nayan/pkg/config"
+	"github.com/intothevoid/nayan/pkg/engine"
+	"github.com/intothevoid/nayan/pkg/game"
+	"github.com/intothevoid/nayan/pkg/ui"
+	"github.com/notnil/chess"
+)
+
+func testConfig(t *testing.T) config.Overlay {
+	t.Helper()
+	cfg := config.Default().Overlay
+	cfg.Width, cfg.Height, cfg.EvalBar = 160, 160, false
+	return cfg
+}
+
+// run starts o's render loop for the test.
+func run(t *testing.T, o *Overlay) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		o.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+// waitFor polls until cond holds.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); !cond(); {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func play(t *testing.T, gs *nchess.GameState, uci string) (*chess.Move, *chess.Position) {
+	t.Helper()
+	pos := gs.Game().Position()
+	m, err := chess.UCINotation{}.Decode(pos, uci)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gs.ApplyMove(m); err != nil {
+		t.Fatal(err)
+	}
+	return m, pos
+}
+
+func TestPublishUpdatesScene(t *testing.T) {
+	o, err := New(testConfig(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs := nchess.NewGame(nchess.Black)
+	o.Publish(game.Event{Kind: game.GameStarted, Game: gs})
+	m, pos := play(t, gs, "e2e4")
+	o.Publish(game.Event{Kind: game.MoveMade, Game: gs, Move: m, Position: pos})
+
+	o.mu.Lock()
+	s := o.scene
+	o.mu.Unlock()
+	if !s.Flipped {
+		t.Error("board not flipped for a human playing Black")
+	}
+	if s.Pieces[4][4] != ui.WhitePawn || s.Pieces[6][4] != ui.NoPieceType || s.Pieces[0][4] != ui.BlackKing {
+		t.Errorf("pieces after e4: e4=%v e2=%v e8=%v", s.Pieces[4][4], s.Pieces[6][4], s.Pieces[0][4])
+	}
+	if !s.HasMove || s.FromRow != 6 || s.ToRow != 4 || s.FromCol != 4 {
+		t.Errorf("last move highlight = %+v", s)
+	}
+
+	// Black's engine likes e5 by a pawn: White is a pawn worse.
+	after := gs.Game().Position()
+	reply, _ := chess.UCINotation{}.Decode(after, "e7e5")
+	o.Publish(game.Event{Kind: game.Recommendation, Game: gs, Move: reply, Position: after,
+		Score: &engine.Evaluation{CP: 100}})
+	o.Publish(game.Event{Kind: game.InvalidBoard, Game: gs, Squares: [][2]int{{1, 3}}})
+	o.mu.Lock()
+	s = o.scene
+	o.mu.Unlock()
+	if s.Eval != -100 || len(s.Arrows) != 1 || len(s.Invalid) != 1 {
+		t.Errorf("scene after recommendation and invalid board: eval %d, arrows %v, invalid %v", s.Eval, s.Arrows, s.Invalid)
+	}
+}
+
+func TestPNGFrames(t *testing.T) {
+	dir := t.TempDir()
+	cfg := testConfig(t)
+	cfg.PNG = filepath.Join(dir, "board-%03d.png")
+	o, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.OnError = func(err error) { t.Error(err) }
+	run(t, o)
+
+	gs := nchess.NewGame(nchess.White)
+	o.Publish(game.Event{Kind: game.GameStarted, Game: gs})
+	numbered := filepath.Join(dir, "board-001.png")
+	waitFor(t, "frame 1", func() bool {
+		_, err := os.Stat(numbered)
+		return err == nil
+	})
+
+	f, err := os.Open(numbered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 160 || b.Dy() != 160 {
+		t.Errorf("frame is %v, want 160x160", b)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "board-000.png")); err != nil {
+		t.Errorf("no initial frame: %v", err)
+	}
+	if tmp, _ := filepath.Glob(filepath.Join(dir, ".overlay-*")); len(tmp) != 0 {
+		t.Errorf("temporary files left behind: %v", tmp)
+	}
+}
+
+func TestMJPEGStream(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Width, cfg.EvalBar = 200, true
+	o, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(o.Handler())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/board.mjpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/x-mixed-replace" {
+		t.Fatalf("Content-Type %q", resp.Header.Get("Content-Type"))
+	}
+	mr := multipart.NewReader(bufio.NewReader(resp.Body), params["boundary"])
+	for i := 0; i < 2; i++ {
+		part, err := mr.NextPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("part %d: %v", i, err)
+		}
+		if b := img.Bounds(); b.Dx() != 200 || b.Dy() != 160 {
+			t.Errorf("part %d is %v, want 200x160", i, b)
+		}
+		// The margin shows the chroma key background.
+		if r, g, b, _ := img.At(2, 80).RGBA(); g>>8 < 0xe0 || r>>8 > 0x20 || b>>8 > 0x20 {
+			t.Errorf("background = %d,%d,%d, want green", r>>8, g>>8, b>>8)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"image"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/intothevoid/nayan/pkg/game"
+	"github.com/intothevoid/nayan/pkg/vision"
+	"gocv.io/x/gocv"
+)
+
+// Source supplies camera frames. camera.VideoStream is the usual one; a
+// source that runs out of frames returns io.EOF.
+type Source interface {
+	ReadRaw() (*gocv.Mat, error)
+}
+
+// Frame is one camera frame after board detection. Its Mats belong to the
+// pipeline and are only valid during OnFrame.
+type Frame struct {
+	Number int           // 1 for the first frame read
+	Camera *gocv.Mat     // camera image, mirrored
+	Params vision.Params // detection parameters used for this frame
+
+	// Set once the board is calibrated.
+	Warped     *gocv.Mat // top-down board with occupancy and grid drawn
+	Occupancy  [8][8]bool
+	Brightness [8][8]float64
+	Metrics    [64]vision.SquareMetrics
+	Changed    bool // Occupancy differs from the previous detection
+}
+
+// Detected reports whether the board was read from this frame.
+func (f *Frame) Detected() bool { return f.Warped != nil }
+
+// Pipeline turns camera frames into occupancy readings and feeds them to a
+// game controller: mirror, warp to the calibrated corners, detect pieces,
+// observe. The GUI and headless mode both run it.
+type Pipeline struct {
+	Controller *game.Controller
+	// OnFrame, if set, is called with every frame after detection, on the
+	// goroutine running the pipeline.
+	OnFrame func(f *Frame)
+	// Interval is the minimum time between frames (~30 FPS by default);
+	// zero processes frames as fast as they are read, e.g. for replays.
+	Interval time.Duration
+
+	mu            sync.Mutex
+	params        vision.Params
+	corners       []image.Point
+	lastOccupancy [8][8]bool
+}
+
+// New returns a pipeline feeding c, detecting with vision.DefaultParams.
+func New(c *game.Controller) *Pipeline {
+	return &Pipeline{Controller: c, Interval: 33 * time.Millisecond, params: vision.DefaultParams()}
+}
+
+// SetParams changes the warp size and detection thresholds from the next
+// frame on.
+func (p *Pipeline) SetParams(params vision.Params) {
+	p.mu.Lock()
+	p.params = params
+	p.mu.Unlock()
+}
+
+// SetCorners sets the board corners in camera pixels, ordered as
+// vision.ReorderPoints orders them. Nil stops detection, e.g. while
+// recalibrating.
+func (p *Pipeline) SetCorners(corners []image.Point) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.corners = append([]image.Point(nil), corners...)
+}
+
+// Corners returns the board corners, or nil if not calibrated.
+func (p *Pipeline) Corners() []image.Point {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.corners == nil {
+		return nil
+	}
+	return append([]image.Point(nil), p.corners...)
+}
+
+// Mirror flips a camera frame in place so it feels natural. Corners are
+// picked on mirrored frames.
+func Mirror(mat *gocv.Mat) {
+	gocv.Flip(*mat, mat, -1)
+}
+
+// Run reads frames from src until ctx is cancelled or src runs out.
+func (p *Pipeline) Run(ctx context.Context, src Source) error {
+	frame := Frame{}
+	for ctx.Err() == nil {
+		mat, err := src.ReadRaw()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil || mat.Empty() {
+			continue
+		}
+		frame.Number++
+		p.Process(mat, &frame)
+		if p.Interval <= 0 {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(p.Interval):
+		}
+	}
+	return nil
+}
+
+// Process runs one frame through the pipeline. mat is mirrored in place.
+// frame.Number is left as it is; the other fields are overwritten.
+func (p *Pipeline) Process(mat *gocv.Mat, frame *Frame) {
+	Mirror(mat)
+	*frame = Frame{Number: frame.Number, Camera: mat}
+
+	p.mu.Lock()
+	params := p.params
+	p.mu.Unlock()
+	frame.Params = params
+
+	corners := p.Corners()
+	if corners != nil {
+		warped := params.WarpBoard(*mat, corners)
+		defer warped.Close()
+
+		// Variance-based detection needs no reference frame
+		frame.Warped = &warped
+		frame.Occupancy, frame.Metrics = params.ScanBoardDebug(warped)
+		frame.Brightness = params.ScanBrightness(warped)
+
+		p.mu.Lock()
+		frame.Changed = frame.Occupancy != p.lastOccupancy
+		p.lastOccupancy = frame.Occupancy
+		p.mu.Unlock()
+
+		if p.Controller != nil {
+			p.Controller.Observe(game.Observation{Occupancy: frame.Occupancy, Brightness: frame.Brightness})
+		}
+		params.DrawOccupancy(&warped, frame.Occupancy)
+		params.DrawGrid(&warped)
+	}
+
+	if p.OnFrame != nil {
+		p.OnFrame(frame)
+	}
+}
@@ -0,0 +1,297 @@
+// Package relay broadcasts games for tournament relay tools, which poll a
+// PGN file that grows as moves are played. After every move each board
+// rewrites its game, with headers, clock comments and the result, then
+// rebuilds live.pgn from every board's game in board order. Boards are
+// usually separate Nayan instances, one per camera, sharing a directory:
+//
+//	<dir>/board-<n>.pgn  the game on board n
+//	<dir>/live.pgn       every board's game, by board number
+//
+// Clock comments are [%clk] with the time left when a time control is
+// configured, and [%emt], the time the move took, otherwise.
+package relay
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/intothevoid/nayan/pkg/atomicfile"
+	nchess "github.com/intothevoid/nayan/pkg/chess"
+	"github.com/intothevoid/nayan/pkg/config"
+	"github.com/intothevoid/nayan/pkg/game"
+	"github.com/notnil/chess"
+)
+
+// LiveFile is the combined PGN that relay tools poll.
+const LiveFile = "live.pgn"
+
+// Lock file timing: writers wait up to lockWait for another board to
+// finish, and a lock older than lockStale was left by a crashed process.
+const (
+	lockWait  = 2 * time.Second
+	lockStale = 10 * time.Second
+)
+
+// lineWidth wraps movetext, well inside PGN's 255 character limit.
+const lineWidth = 79
+
+// boardFile matches the per-board files, capturing the board number.
+var boardFile = regexp.MustCompile(`^board-([0-9]+)\.pgn$`)
+
+// Board publishes the game played on one board.
+type Board struct {
+	// OnError, if set, reports files that could not be written. It is
+	// called from Publish.
+	OnError func(error)
+
+	cfg             config.Relay
+	base, increment time.Duration // zero without a time control
+	now             func() time.Time
+
+	mu        sync.Mutex
+	gs        *nchess.GameState
+	date      time.Time       // when the game started
+	spent     []time.Duration // time taken by each move, by ply
+	turnStart time.Time       // when the side to move began thinking
+}
+
+// New returns a board publishing to the directory in cfg, which is
+// created if needed.
+func New(cfg config.Relay) (*Board, error) {
+	base, inc, err := cfg.Clock()
+	if err != nil {
+		return nil, fmt.Errorf("relay time control: %w", err)
+	}
+	if err := os.MkdirAll(cfg.Dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Board{cfg: cfg, base: base, increment: inc, now: time.Now}, nil
+}
+
+// Path returns the combined file relay tools should poll.
+func (b *Board) Path() string {
+	return filepath.Join(b.cfg.Dir, LiveFile)
+}
+
+// Publish records a controller event, rewriting the files when the game
+// changes. The files are written before it returns, in event order.
+func (b *Board) Publish(ev game.Event) {
+	now := b.now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case ev.Kind == game.GameStarted:
+		b.gs = ev.Game
+		b.date = now
+		b.spent = nil
+		b.turnStart = now
+	case b.gs == nil || ev.Game != b.gs:
+		return
+	case ev.Kind == game.MoveMade:
+		b.spent = append(b.spent, now.Sub(b.turnStart))
+		b.turnStart = now
+	case ev.Kind == game.TakenBack:
+		// The side to move again starts its time afresh.
+		b.spent = b.spent[:min(len(b.spent), len(ev.Game.Game().Moves()))]
+		b.turnStart = now
+	case ev.Kind != game.GameOver:
+		return
+	}
+	if err := b.write(b.pgnLocked()); err != nil && b.OnError != nil {
+		b.OnError(err)
+	}
+}
+
+// pgnLocked formats the current game. b.mu must be held.
+func (b *Board) pgnLocked() []byte {
+	g := b.gs.Game()
+	result := g.Outcome().String()
+
+	var buf bytes.Buffer
+	tag := func(name, value string) {
+		if value == "" {
+			value = "?"
+		}
+		fmt.Fprintf(&buf, "[%s %s]\n", name, strconv.Quote(value))
+	}
+	tag("Event", b.cfg.Event)
+	tag("Site", b.cfg.Site)
+	tag("Date", b.date.Format("2006.01.02"))
+	tag("Round", b.cfg.Round)
+	tag("White", b.cfg.White)
+	tag("Black", b.cfg.Black)
+	tag("Result", result)
+	positions := g.Positions()
+	if fen := positions[0].String(); fen != chess.StartingPosition().String() {
+		tag("SetUp", "1")
+		tag("FEN", fen)
+	}
+	tag("Board", strconv.Itoa(b.cfg.Board))
+	if b.cfg.TimeControl != "" {
+		tag("TimeControl", b.cfg.TimeControl)
+	}
+	if g.Method() == chess.Resignation {
+		tag("Termination", "resignation")
+	}
+	buf.WriteByte('\n')
+
+	var tokens []string
+	var used [2]time.Duration
+	var made [2]int // moves made by each side
+	comment := true // a Black move after a comment repeats its number
+	start := nchess.StartPly(positions[0])
+	for ply, m := range g.Moves() {
+		pos := positions[ply]
+		side := pos.Turn()
+		if num := strconv.Itoa((start+ply)/2 + 1); side == chess.White {
+			tokens = append(tokens, num+".")
+		} else if comment {
+			tokens = append(tokens, num+"...")
+		}
+		tokens = append(tokens, chess.AlgebraicNotation{}.Encode(pos, m))
+		made[side-1]++
+		comment = ply < len(b.spent)
+		if comment {
+			used[side-1] += b.spent[ply]
+			tokens = append(tokens, "{"+b.clockComment(used[side-1], made[side-1], b.spent[ply])+"}")
+		}
+	}
+	tokens = append(tokens, result)
+	wrap(&buf, tokens)
+	return buf.Bytes()
+}
+
+// clockComment is the clock annotation after a side's moves'th move in
+// the game, each adding the increment, having used used in total and the
+// move itself taking spent.
+func (b *Board) clockComment(used time.Duration, moves int, spent time.Duration) string {
+	if b.base == 0 {
+		return "[%emt " + clock(spent) + "]"
+	}
+	return "[%clk " + clock(max(b.base+time.Duration(moves)*b.increment-used, 0)) + "]"
+}
+
+// clock formats d as h:mm:ss, rounded down to the second.
+func clock(d time.Duration) string {
+	s := int(d / time.Second)
+	return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+}
+
+// wrap writes tokens separated by spaces in lines of at most lineWidth,
+// ending with a newline.
+func wrap(buf *bytes.Buffer, tokens []string) {
+	n := 0
+	for _, t := range tokens {
+		switch {
+		case n == 0:
+		case n+1+len(t) > lineWidth:
+			buf.WriteByte('\n')
+			n = 0
+		default:
+			buf.WriteByte(' ')
+			n++
+		}
+		buf.WriteString(t)
+		n += len(t)
+	}
+	buf.WriteByte('\n')
+}
+
+// write replaces this board's file with pgn, then rebuilds the combined
+// file under the directory's lock so boards writing at once cannot leave
+// it without the other's latest move.
+func (b *Board) write(pgn []byte) error {
+	own := filepath.Join(b.cfg.Dir, fmt.Sprintf("board-%d.pgn", b.cfg.Board))
+	if err := writeFile(own, pgn); err != nil {
+		return fmt.Errorf("relay: %w", err)
+	}
+	unlock, err := lock(b.Path() + ".lock")
+	if err != nil {
+		return fmt.Errorf("relay: %w", err)
+	}
+	defer unlock()
+	live, err := combine(b.cfg.Dir)
+	if err != nil {
+		return fmt.Errorf("relay: %w", err)
+	}
+	if err := writeFile(b.Path(), live); err != nil {
+		return fmt.Errorf("relay: %w", err)
+	}
+	return nil
+}
+
+// combine concatenates the boards' files in dir by board number.
+func combine(dir string) ([]byte, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	type boardPGN struct {
+		number int
+		name   string
+	}
+	var boards []boardPGN
+	for _, e := range entries {
+		if m := boardFile.FindStringSubmatch(e.Name()); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			boards = append(boards, boardPGN{n, e.Name()})
+		}
+	}
+	slices.SortFunc(boards, func(a, b boardPGN) int { return a.number - b.number })
+
+	var games [][]byte
+	for _, bd := range boards {
+		data, err := os.ReadFile(filepath.Join(dir, bd.name))
+		if err != nil {
+			return nil, err
+		}
+		games = append(games, bytes.TrimSpace(data))
+	}
+	out := bytes.Join(games, []byte("\n\n"))
+	if len(out) > 0 {
+		out = append(out, '\n')
+	}
+	return out, nil
+}
+
+// writeFile replaces path with data in one step, so pollers never read a
+// half-written file.
+func writeFile(path string, data []byte) error {
+	return atomicfile.Write(path, ".relay-*.pgn", func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// lock takes the lock file at path, shared by every process writing the
+// directory, and returns the function that releases it.
+func lock(path string) (unlock func(), err error) {
+	deadline := time.Now().Add(lockWait)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if fi, err := os.Stat(path); err == nil && time.Since(fi.ModTime()) > lockStale {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s is held by another board", filepath.Base(path))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
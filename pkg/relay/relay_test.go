@@ -0,0 +1,209 @@
+package relay
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	nchess "github.com/intothevoid/nayan/pkg/chess"
+	"github.com/intothevoid/nayan/pkg/config"
+	"github.com/intothevoid/nayan/pkg/game"
+	"github.com/notnil/chess"
+)
+
+// testBoard is a Board whose clock advances only when told.
+type testBoard struct {
+	*Board
+	t   *testing.T
+	now time.Time
+	gs  *nchess.GameState
+}
+
+func newTestBoard(t *testing.T, cfg config.Relay) *testBoard {
+	t.Helper()
+	b, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.OnError = func(err error) { t.Error(err) }
+	tb := &testBoard{Board: b, t: t, now: time.Date(2026, 10, 18, 19, 0, 0, 0, time.UTC)}
+	b.now = func() time.Time { return tb.now }
+	return tb
+}
+
+func (tb *testBoard) start() {
+	tb.gs = nchess.NewGame(nchess.White)
+	tb.Publish(game.Event{Kind: game.GameStarted, Game: tb.gs})
+}
+
+// startFrom starts a game set up from fen.
+func (tb *testBoard) startFrom(fen string) {
+	tb.t.Helper()
+	setup, err := nchess.SetupFromFEN(fen)
+	if err != nil {
+		tb.t.Fatal(err)
+	}
+	if tb.gs, err = setup.NewGame(nchess.White); err != nil {
+		tb.t.Fatal(err)
+	}
+	tb.Publish(game.Event{Kind: game.GameStarted, Game: tb.gs})
+}
+
+// play advances the clock by think, then plays uci.
+func (tb *testBoard) play(think time.Duration, uci string) {
+	tb.t.Helper()
+	tb.now = tb.now.Add(think)
+	pos := tb.gs.Game().Position()
+	m, err := chess.UCINotation{}.Decode(pos, uci)
+	if err != nil {
+		tb.t.Fatal(err)
+	}
+	if err := tb.gs.ApplyMove(m); err != nil {
+		tb.t.Fatal(err)
+	}
+	tb.Publish(game.Event{Kind: game.MoveMade, Game: tb.gs, Move: m, Position: pos})
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestLivePGNHeadersAndElapsedTimes(t *testing.T) {
+	dir := t.TempDir()
+	b := newTestBoard(t, config.Relay{Dir: dir, Board: 1, Event: "Club night", White: "Asha", Black: "Ben"})
+	b.start()
+	b.play(12*time.Second, "e2e4")
+	b.play(3*time.Second, "e7e5")
+
+	got := readFile(t, b.Path())
+	want := `[Event "Club night"]
+[Site "?"]
+[Date "2026.10.18"]
+[Round "?"]
+[White "Asha"]
+[Black "Ben"]
+[Result "*"]
+[Board "1"]
+
+1. e4 {[%emt 0:00:12]} 1... e5 {[%emt 0:00:03]} *
+`
+	if got != want {
+		t.Errorf("live.pgn =\n%s\nwant\n%s", got, want)
+	}
+	if own := readFile(t, filepath.Join(dir, "board-1.pgn")); own != want {
+		t.Errorf("board-1.pgn differs from live.pgn:\n%s", own)
+	}
+}
+
+func TestClockCountsDownWithIncrement(t *testing.T) {
+	b := newTestBoard(t, config.Relay{Dir: t.TempDir(), Board: 1, TimeControl: "300+2"})
+	b.start()
+	b.play(10*time.Second, "e2e4")
+	b.play(20*time.Second, "e7e5")
+	b.play(30*time.Second, "g1f3")
+
+	got := readFile(t, b.Path())
+	for _, want := range []string{
+		`[TimeControl "300+2"]`,
+		"1. e4 {[%clk 0:04:52]} 1... e5 {[%clk 0:04:42]} 2. Nf3 {[%clk 0:04:24]} *",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("live.pgn without %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestSetUpPositionWithBlackToMove(t *testing.T) {
+	const fen = "r1bqkbnr/pppp1ppp/2n5/1B2p3/4P3/5N2/PPPP1PPP/RNBQK2R b KQkq - 3 3"
+	b := newTestBoard(t, config.Relay{Dir: t.TempDir(), Board: 1, TimeControl: "300+2"})
+	b.startFrom(fen)
+	b.play(10*time.Second, "a7a6")
+	b.play(20*time.Second, "b5a4")
+	b.play(30*time.Second, "g8f6")
+
+	got := readFile(t, b.Path())
+	for _, want := range []string{
+		`[Result "*"]` + "\n" + `[SetUp "1"]` + "\n" + `[FEN "` + fen + `"]`,
+		"3... a6 {[%clk 0:04:52]} 4. Ba4 {[%clk 0:04:42]} 4... Nf6 {[%clk 0:04:24]} *",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("live.pgn without %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestTakeBackAndResult(t *testing.T) {
+	b := newTestBoard(t, config.Relay{Dir: t.TempDir(), Board: 1})
+	b.start()
+	b.play(time.Second, "f2f3")
+	b.play(time.Second, "e7e5")
+	b.play(time.Second, "g2g4")
+
+	if err := b.gs.TakeBack(1); err != nil {
+		t.Fatal(err)
+	}
+	b.now = b.now.Add(time.Minute)
+	b.Publish(game.Event{Kind: game.TakenBack, Game: b.gs, Plies: 1})
+	if got := readFile(t, b.Path()); strings.Contains(got, "g4") {
+		t.Errorf("taken back move still relayed:\n%s", got)
+	}
+
+	b.play(4*time.Second, "g2g4")
+	b.play(time.Second, "d8h4")
+	b.Publish(game.Event{Kind: game.GameOver, Game: b.gs})
+	got := strings.Join(strings.Fields(readFile(t, b.Path())), " ") // unwrapped
+	for _, want := range []string{`[Result "0-1"] [Board "1"]`, "2. g4 {[%emt 0:00:04]} 2... Qh4# {[%emt 0:00:01]} 0-1"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("live.pgn without %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestBoardsCombinedByNumber(t *testing.T) {
+	dir := t.TempDir()
+	b10 := newTestBoard(t, config.Relay{Dir: dir, Board: 10, White: "Ten"})
+	b2 := newTestBoard(t, config.Relay{Dir: dir, Board: 2, White: "Two"})
+	b10.start()
+	b2.start()
+	b10.play(time.Second, "d2d4")
+	b2.play(time.Second, "c2c4")
+
+	got := readFile(t, b10.Path())
+	two, ten := strings.Index(got, `[White "Two"]`), strings.Index(got, `[White "Ten"]`)
+	if two < 0 || ten < 0 || two > ten {
+		t.Fatalf("live.pgn should hold board 2 then board 10:\n%s", got)
+	}
+	if !strings.Contains(got, "1. d4") || !strings.Contains(got, "1. c4") {
+		t.Errorf("live.pgn without both boards' moves:\n%s", got)
+	}
+	if !strings.Contains(got, "*\n\n[Event") {
+		t.Errorf("games not separated by a blank line:\n%s", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, LiveFile+".lock")); !os.IsNotExist(err) {
+		t.Errorf("lock file left behind: %v", err)
+	}
+}
+
+func TestStaleLockIsBroken(t *testing.T) {
+	dir := t.TempDir()
+	lockPath := filepath.Join(dir, LiveFile+".lock")
+	if err := os.WriteFile(lockPath, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Minute)
+	if err := os.Chtimes(lockPath, old, old); err != nil {
+		t.Fatal(err)
+	}
+	b := newTestBoard(t, config.Relay{Dir: dir, Board: 1})
+	b.start()
+	if got := readFile(t, b.Path()); !strings.Contains(got, `[Board "1"]`) {
+		t.Errorf("live.pgn not written past a stale lock:\n%s", got)
+	}
+}
@@ -0,0 +1,445 @@
+// Package server exposes a game over HTTP on the local network so other
+// screens and tools can follow it: a spectator page, the current state as
+// JSON, a WebSocket stream of the controller's events, and endpoints to
+// start and stop games and ask for hints.
+//
+//	GET  /                the spectator page (see spectator.go)
+//	GET  /api/state       the current State
+//	GET  /api/events      WebSocket stream of Messages, starting with "state"
+//	POST /api/game/start  start a game; ?human=black plays the human as Black
+//	POST /api/game/stop   stop the game
+//	POST /api/hint        ask for a hint, sent on the stream as "hint"
+//
+// Errors are returned as {"error": "..."} with a 4xx or 5xx status. POSTs
+// from another site's pages are refused, so a page elsewhere cannot start or
+// stop the game.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	nchess "github.com/intothevoid/nayan/pkg/chess"
+	"github.com/intothevoid/nayan/pkg/engine"
+	"github.com/intothevoid/nayan/pkg/game"
+	"github.com/notnil/chess"
+)
+
+// State is the game as returned by GET /api/state.
+type State struct {
+	State          string     `json:"state"`                    // idle, playing or over
+	Status         string     `json:"status,omitempty"`         // the app's status line
+	Human          string     `json:"human,omitempty"`          // white or black
+	FEN            string     `json:"fen,omitempty"`            // current position
+	Moves          []string   `json:"moves"`                    // moves played, in SAN
+	StartPly       int        `json:"start_ply,omitempty"`      // plies before the first move in a set-up position
+	LastMove       *MoveInfo  `json:"last_move,omitempty"`      // the last move played
+	Recommendation *MoveInfo  `json:"recommendation,omitempty"` // the engine's last chosen move
+	Eval           *Eval      `json:"eval,omitempty"`           // the engine's latest evaluation
+	Clock          Clock      `json:"clock"`
+	Outcome        string     `json:"outcome,omitempty"` // result once the game is over
+	Occupancy      [8][8]bool `json:"occupancy"`         // last board reading; row 0 is rank 8, column 0 file a
+}
+
+// Eval is an engine evaluation from White's point of view.
+type Eval struct {
+	CP   int `json:"cp"`             // centipawns, positive when White is better
+	Mate int `json:"mate,omitempty"` // moves to mate, positive when White mates; 0 if none
+}
+
+// Clock is the thinking time each side has used this game, counting the
+// turn in progress up to when the State was taken.
+type Clock struct {
+	White   int64  `json:"white_ms"`
+	Black   int64  `json:"black_ms"`
+	Running string `json:"running,omitempty"` // the side whose time is running: white or black
+}
+
+// MoveInfo is a move in both UCI and SAN notation.
+type MoveInfo struct {
+	UCI string `json:"uci"`
+	SAN string `json:"san"`
+}
+
+// Message is one event on the WebSocket stream. Type is the event kind
+// (see game.EventKind) or "state", which carries the State and is sent
+// when a client connects.
+type Message struct {
+	Type     string    `json:"type"`
+	FEN      string    `json:"fen,omitempty"`      // position after the event
+	Move     *MoveInfo `json:"move,omitempty"`     // move_made, recommendation, pondering, hint, move_reviewed
+	ByHuman  bool      `json:"by_human,omitempty"` // move_made
+	Manual   bool      `json:"manual,omitempty"`   // move_made: entered on screen
+	Reply    *MoveInfo `json:"reply,omitempty"`    // recommendation: the reply the engine expects
+	Eval     *Eval     `json:"eval,omitempty"`     // recommendation, move_reviewed
+	Squares  [][2]int  `json:"squares,omitempty"`  // invalid_board: (row, col) squares that differ
+	Grade    string    `json:"grade,omitempty"`    // move_reviewed
+	CPLoss   int       `json:"cp_loss,omitempty"`  // move_reviewed
+	Method   string    `json:"method,omitempty"`   // draw_claimable
+	Plies    int       `json:"plies,omitempty"`    // taken_back
+	Outcome  string    `json:"outcome,omitempty"`  // game_over
+	Error    string    `json:"error,omitempty"`    // invalid_board, engine_error
+	GameInfo *State    `json:"game,omitempty"`     // state
+}
+
+// Server serves a controller's game. Events reach it through Publish and
+// board readings through SetOccupancy; the app calls both from its own
+// event and frame handlers.
+type Server struct {
+	// StartGame starts a new game with the human playing human, as the
+	// app's own start control does. Starting games is refused if nil.
+	StartGame func(human nchess.Color) error
+	// StopGame stops the game in progress. Stopping is refused if nil.
+	StopGame func() error
+
+	controller *game.Controller
+	upgrader   websocket.Upgrader
+	now        func() time.Time
+
+	mu        sync.Mutex
+	status    string
+	occupancy [8][8]bool
+	rec       *MoveInfo
+	eval      *Eval
+	used      [2]time.Duration // thinking time of completed turns, by chess.Color-1
+	turnStart time.Time        // start of the turn in progress; zero when no clock runs
+	clients   map[chan []byte]struct{}
+}
+
+// clientBuffer is how many messages may wait for a slow client before it is
+// disconnected.
+const clientBuffer = 64
+
+// New returns a server for c's games.
+func New(c *game.Controller) *Server {
+	return &Server{controller: c, now: time.Now, clients: make(map[chan []byte]struct{})}
+}
+
+// SetStatus sets the status line reported in the State.
+func (s *Server) SetStatus(msg string) {
+	s.mu.Lock()
+	s.status = msg
+	s.mu.Unlock()
+}
+
+// SetOccupancy records the latest board reading.
+func (s *Server) SetOccupancy(occ [8][8]bool) {
+	s.mu.Lock()
+	s.occupancy = occ
+	s.mu.Unlock()
+}
+
+// State returns the current game state. Only the status line and board
+// reading are reported when there is no game.
+func (s *Server) State() State {
+	st := State{State: s.controller.State().String(), Moves: []string{}}
+	s.mu.Lock()
+	st.Status = s.status
+	st.Occupancy = s.occupancy
+	s.mu.Unlock()
+
+	gs := s.controller.Snapshot()
+	if gs == nil {
+		return st
+	}
+	st.Human = colorName(gs.HumanColor)
+	st.FEN = gs.FEN()
+	st.Moves = gs.SANMoves()
+	st.StartPly = gs.StartPly()
+	if n := len(st.Moves); n > 0 {
+		st.LastMove = &MoveInfo{UCI: gs.Game().Moves()[n-1].String(), SAN: st.Moves[n-1]}
+	}
+	if gs.IsGameOver() {
+		st.Outcome = gs.Outcome()
+	}
+	turn := gs.Game().Position().Turn()
+
+	s.mu.Lock()
+	st.Recommendation = s.rec
+	st.Eval = s.eval
+	used := s.used
+	if !s.turnStart.IsZero() {
+		used[turn-1] += s.now().Sub(s.turnStart)
+		st.Clock.Running = strings.ToLower(turn.Name())
+	}
+	s.mu.Unlock()
+	st.Clock.White = used[chess.White-1].Milliseconds()
+	st.Clock.Black = used[chess.Black-1].Milliseconds()
+	return st
+}
+
+// Publish sends ev to every connected client.
+func (s *Server) Publish(ev game.Event) {
+	msg := message(ev)
+	now := s.now()
+	s.mu.Lock()
+	switch ev.Kind {
+	case game.GameStarted:
+		s.rec, s.eval = nil, nil
+		s.used = [2]time.Duration{}
+		s.turnStart = now
+	case game.MoveMade:
+		if !s.turnStart.IsZero() && ev.Position != nil {
+			s.used[ev.Position.Turn()-1] += now.Sub(s.turnStart)
+			s.turnStart = now
+		}
+	case game.Recommendation:
+		s.rec = msg.Move
+	case game.GameOver:
+		// The side to move used its time until the end (a resignation).
+		if !s.turnStart.IsZero() && ev.Game != nil {
+			s.used[ev.Game.Game().Position().Turn()-1] += now.Sub(s.turnStart)
+		}
+		s.turnStart = time.Time{}
+	}
+	if msg.Eval != nil {
+		s.eval = msg.Eval
+	}
+	s.mu.Unlock()
+	s.broadcast(msg)
+}
+
+// whiteEval converts a score as for engine.WhiteScore.
+func whiteEval(cp int, side chess.Color) *Eval {
+	cp, mate := engine.WhiteScore(cp, side)
+	return &Eval{CP: cp, Mate: mate}
+}
+
+// broadcast sends msg to every client, disconnecting those that have
+// fallen too far behind.
+func (s *Server) broadcast(msg Message) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.clients {
+		select {
+		case ch <- data:
+		default:
+			delete(s.clients, ch)
+			close(ch)
+		}
+	}
+}
+
+// message converts a controller event to its stream form.
+func message(ev game.Event) Message {
+	msg := Message{Type: ev.Kind.String()}
+	if ev.Game != nil {
+		msg.FEN = ev.Game.FEN()
+	}
+	if ev.Move != nil {
+		msg.Move = &MoveInfo{UCI: ev.Move.String(), SAN: ev.Notation}
+		if msg.Move.SAN == "" && ev.Position != nil {
+			msg.Move.SAN = chess.AlgebraicNotation{}.Encode(ev.Position, ev.Move)
+		}
+	}
+	switch ev.Kind {
+	case game.MoveMade:
+		msg.ByHuman = ev.ByHuman
+		msg.Manual = ev.Manual
+	case game.Recommendation:
+		if ev.Reply != nil && ev.Position != nil {
+			after := ev.Position.Update(ev.Move)
+			msg.Reply = &MoveInfo{UCI: ev.Reply.String(), SAN: chess.AlgebraicNotation{}.Encode(after, ev.Reply)}
+		}
+		if ev.Score != nil && ev.Position != nil {
+			msg.Eval = whiteEval(ev.Score.Centipawns(), ev.Position.Turn())
+		}
+	case game.InvalidBoard:
+		msg.Squares = ev.Squares
+	case game.MoveReviewed:
+		if r := ev.Review; r != nil {
+			msg.Move = &MoveInfo{UCI: r.Move.String()}
+			if ev.Position != nil {
+				msg.Move.SAN = chess.AlgebraicNotation{}.Encode(ev.Position, r.Move)
+			}
+			msg.Grade = r.Class.String()
+			msg.CPLoss = r.CPLoss
+			if ev.Position != nil {
+				msg.Eval = whiteEval(r.EvalAfter, ev.Position.Turn())
+			}
+		}
+	case game.DrawClaimable:
+		msg.Method = ev.Method.String()
+	case game.TakenBack:
+		msg.Plies = ev.Plies
+	case game.GameOver:
+		if ev.Game != nil {
+			msg.Outcome = ev.Game.Outcome()
+		}
+	}
+	if ev.Err != nil {
+		msg.Error = ev.Err.Error()
+	}
+	return msg
+}
+
+// Handler returns the HTTP handler serving the spectator page and the API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/state", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, s.State())
+	})
+	mux.HandleFunc("GET /api/events", s.events)
+	mux.HandleFunc("POST /api/game/start", sameOrigin(s.start))
+	mux.HandleFunc("POST /api/game/stop", sameOrigin(s.stop))
+	mux.HandleFunc("POST /api/hint", sameOrigin(s.hint))
+	mux.Handle("GET /", spectatorHandler())
+	return mux
+}
+
+// ListenAndServe serves the page and the API on addr until ctx is cancelled.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	srv := &http.Server{Addr: addr, Handler: s.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// events streams Messages to a WebSocket client until it disconnects.
+func (s *Server) events(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return // the upgrader has replied
+	}
+	defer conn.Close()
+
+	// The client is registered before the state is taken so no event falls
+	// between the two; events queued meanwhile may repeat what it shows.
+	ch := make(chan []byte, clientBuffer)
+	s.mu.Lock()
+	s.clients[ch] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		if _, ok := s.clients[ch]; ok {
+			delete(s.clients, ch)
+			close(ch)
+		}
+		s.mu.Unlock()
+	}()
+	st := s.State()
+	first, _ := json.Marshal(Message{Type: "state", FEN: st.FEN, GameInfo: &st})
+	conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	if err := conn.WriteMessage(websocket.TextMessage, first); err != nil {
+		return
+	}
+
+	// Clients only listen; reading notices when they go away.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			return
+		case data, ok := <-ch:
+			if !ok {
+				return // too slow
+			}
+			conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *Server) start(w http.ResponseWriter, r *http.Request) {
+	if s.StartGame == nil {
+		writeError(w, http.StatusNotImplemented, errors.New("starting games is not available"))
+		return
+	}
+	human := nchess.White
+	switch r.FormValue("human") {
+	case "", "white":
+	case "black":
+		human = nchess.Black
+	default:
+		writeError(w, http.StatusBadRequest, errors.New("human must be white or black"))
+		return
+	}
+	if err := s.StartGame(human); err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.State())
+}
+
+func (s *Server) stop(w http.ResponseWriter, r *http.Request) {
+	if s.StopGame == nil {
+		writeError(w, http.StatusNotImplemented, errors.New("stopping games is not available"))
+		return
+	}
+	if s.controller.State() != game.Playing {
+		writeError(w, http.StatusConflict, game.ErrNotPlaying)
+		return
+	}
+	if err := s.StopGame(); err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.State())
+}
+
+func (s *Server) hint(w http.ResponseWriter, r *http.Request) {
+	if err := s.controller.Hint(); err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]string{"status": "thinking"})
+}
+
+// sameOrigin refuses requests sent by a browser from a page on another host.
+// Browsers set Origin on cross-site POSTs, even plain form submissions;
+// other clients such as curl send none and are let through.
+func sameOrigin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if origin := r.Header.Get("Origin"); origin != "" {
+			u, err := url.Parse(origin)
+			if err != nil || !strings.EqualFold(u.Host, r.Host) {
+				writeError(w, http.StatusForbidden, errors.New("cross-origin request refused"))
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
+
+func colorName(c nchess.Color) string {
+	if c == nchess.Black {
+		return "black"
+	}
+	return "white"
+}
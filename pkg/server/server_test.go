@@ -0,0 +1,285 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	nchess "github.com/intothevoid/nayan/pkg/chess"
+	"github.com/intothevoid/nayan/pkg/engine"
+	"github.com/intothevoid/nayan/pkg/game"
+	"github.com/notnil/chess"
+)
+
+// newTestServer serves a controller without an engine whose events are
+// published to the server, as the app wires them.
+func newTestServer(t *testing.T) (*Server, *game.Controller, *httptest.Server) {
+	t.Helper()
+	c := game.NewController(nil)
+	s := New(c)
+	c.OnEvent = s.Publish
+	s.StartGame = func(human nchess.Color) error {
+		c.Start(nchess.NewGame(human))
+		return nil
+	}
+	s.StopGame = func() error {
+		c.Stop()
+		return nil
+	}
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(ts.Close)
+	return s, c, ts
+}
+
+func post(t *testing.T, url string) (int, map[string]any) {
+	t.Helper()
+	resp, err := http.Post(url, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var body map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, body
+}
+
+func getState(t *testing.T, url string) State {
+	t.Helper()
+	resp, err := http.Get(url + "/api/state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /api/state: %s", resp.Status)
+	}
+	var st State
+	if err := json.NewDecoder(resp.Body).Decode(&st); err != nil {
+		t.Fatal(err)
+	}
+	return st
+}
+
+func TestStateIdle(t *testing.T) {
+	s, _, ts := newTestServer(t)
+	var occ [8][8]bool
+	occ[0][0] = true
+	s.SetOccupancy(occ)
+	s.SetStatus("Waiting for camera...")
+
+	st := getState(t, ts.URL)
+	if st.State != "idle" || st.FEN != "" || len(st.Moves) != 0 {
+		t.Errorf("idle state = %+v", st)
+	}
+	if st.Occupancy != occ || st.Status != "Waiting for camera..." {
+		t.Errorf("occupancy/status not reported: %+v", st)
+	}
+}
+
+func TestStartStopAndState(t *testing.T) {
+	_, c, ts := newTestServer(t)
+
+	if code, body := post(t, ts.URL+"/api/game/start?human=purple"); code != http.StatusBadRequest {
+		t.Errorf("start with bad colour: %d %v", code, body)
+	}
+	if code, body := post(t, ts.URL+"/api/game/start?human=black"); code != http.StatusOK || body["human"] != "black" {
+		t.Fatalf("start: %d %v", code, body)
+	}
+	move, err := chess.UCINotation{}.Decode(c.Game().Game().Position(), "e2e4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.EnterMove(move); err != nil {
+		t.Fatal(err)
+	}
+
+	st := getState(t, ts.URL)
+	if st.State != "playing" || len(st.Moves) != 1 || st.Moves[0] != "e4" {
+		t.Errorf("state after e4 = %+v", st)
+	}
+	if !strings.HasPrefix(st.FEN, "rnbqkbnr/pppppppp/8/8/4P3/") {
+		t.Errorf("FEN = %q", st.FEN)
+	}
+
+	if code, body := post(t, ts.URL+"/api/hint"); code != http.StatusConflict {
+		t.Errorf("hint without an engine: %d %v", code, body)
+	}
+	if code, body := post(t, ts.URL+"/api/game/stop"); code != http.StatusOK || body["state"] != "idle" {
+		t.Errorf("stop: %d %v", code, body)
+	}
+	if code, _ := post(t, ts.URL+"/api/game/stop"); code != http.StatusConflict {
+		t.Errorf("stop when idle: %d", code)
+	}
+}
+
+func TestStartUnavailable(t *testing.T) {
+	s, _, ts := newTestServer(t)
+	s.StartGame = nil
+	if code, _ := post(t, ts.URL+"/api/game/start"); code != http.StatusNotImplemented {
+		t.Errorf("start without StartGame: %d", code)
+	}
+}
+
+func TestCrossOriginPostRefused(t *testing.T) {
+	_, c, ts := newTestServer(t)
+	for _, tc := range []struct {
+		origin string
+		code   int
+	}{
+		{"http://evil.example", http.StatusForbidden},
+		{"null", http.StatusForbidden},
+		{ts.URL, http.StatusOK},
+	} {
+		req, err := http.NewRequest(http.MethodPost, ts.URL+"/api/game/start", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Origin", tc.origin)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tc.code {
+			t.Errorf("start from %q: %s, want %d", tc.origin, resp.Status, tc.code)
+		}
+		if tc.code == http.StatusForbidden && c.State() == game.Playing {
+			t.Fatalf("start from %q started a game", tc.origin)
+		}
+	}
+}
+
+func TestEventStream(t *testing.T) {
+	s, c, ts := newTestServer(t)
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/api/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	read := func() Message {
+		t.Helper()
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		var msg Message
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatal(err)
+		}
+		return msg
+	}
+	if msg := read(); msg.Type != "state" || msg.GameInfo == nil || msg.GameInfo.State != "idle" {
+		t.Fatalf("first message = %+v", msg)
+	}
+
+	c.Start(nchess.NewGame(nchess.White))
+	if msg := read(); msg.Type != "game_started" {
+		t.Errorf("got %q, want game_started", msg.Type)
+	}
+	move, err := chess.UCINotation{}.Decode(c.Game().Game().Position(), "g1f3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.EnterMove(move); err != nil {
+		t.Fatal(err)
+	}
+	msg := read()
+	if msg.Type != "move_made" || msg.Move == nil || msg.Move.UCI != "g1f3" || msg.Move.SAN != "Nf3" ||
+		!msg.ByHuman || !msg.Manual {
+		t.Errorf("move message = %+v", msg)
+	}
+
+	s.Publish(game.Event{Kind: game.InvalidBoard, Game: c.Game(), Squares: [][2]int{{6, 4}}})
+	if msg := read(); msg.Type != "invalid_board" || len(msg.Squares) != 1 || msg.Squares[0] != [2]int{6, 4} {
+		t.Errorf("invalid board message = %+v", msg)
+	}
+}
+
+func TestSpectatorPage(t *testing.T) {
+	_, _, ts := newTestServer(t)
+	for path, want := range map[string]string{
+		"/":              `src="spectator.js"`,
+		"/spectator.js":  "api/events",
+		"/spectator.css": "#board",
+	} {
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), want) {
+			t.Errorf("GET %s: %s, body without %q", path, resp.Status, want)
+		}
+	}
+}
+
+func TestClockAndEval(t *testing.T) {
+	s, c, _ := newTestServer(t)
+	now := time.Unix(0, 0)
+	s.now = func() time.Time { return now }
+
+	c.Start(nchess.NewGame(nchess.White))
+	now = now.Add(3 * time.Second)
+	pos := c.Game().Game().Position()
+	move, _ := chess.UCINotation{}.Decode(pos, "e2e4")
+	if err := c.EnterMove(move); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(2 * time.Second)
+
+	// Black's engine thinks it is 1.5 pawns better.
+	after := c.Game().Game().Position()
+	reply, _ := chess.UCINotation{}.Decode(after, "e7e5")
+	s.Publish(game.Event{Kind: game.Recommendation, Game: c.Game(), Move: reply, Position: after,
+		Score: &engine.Evaluation{CP: 150}})
+
+	st := s.State()
+	if st.Clock.White != 3000 || st.Clock.Black != 2000 || st.Clock.Running != "black" {
+		t.Errorf("clock = %+v, want white 3000 ms, black 2000 ms running", st.Clock)
+	}
+	if st.Eval == nil || st.Eval.CP != -150 || st.Eval.Mate != 0 {
+		t.Errorf("eval = %+v, want -150 cp for White", st.Eval)
+	}
+	if st.Recommendation == nil || st.Recommendation.SAN != "e5" {
+		t.Errorf("recommendation = %+v", st.Recommendation)
+	}
+	if st.LastMove == nil || st.LastMove.UCI != "e2e4" {
+		t.Errorf("last move = %+v", st.LastMove)
+	}
+
+	s.Publish(game.Event{Kind: game.Recommendation, Game: c.Game(), Move: reply, Position: after,
+		Score: &engine.Evaluation{Mate: -2}})
+	if ev := s.State().Eval; ev == nil || ev.Mate != 2 {
+		t.Errorf("eval = %+v, want White mates in 2", ev)
+	}
+
+	c.Stop()
+	if st := s.State(); st.Clock != (Clock{}) || st.Eval != nil || st.FEN != "" {
+		t.Errorf("state after stop = %+v", st)
+	}
+}
+
+func TestURLs(t *testing.T) {
+	urls, err := URLs("192.168.1.20:8734")
+	if err != nil || len(urls) != 1 || urls[0] != "http://192.168.1.20:8734/" {
+		t.Errorf("URLs = %v, %v", urls, err)
+	}
+	urls, err = URLs(":8734")
+	if err != nil || len(urls) == 0 {
+		t.Fatalf("URLs(:8734) = %v, %v", urls, err)
+	}
+	for _, u := range urls {
+		if !strings.HasSuffix(u, ":8734/") || strings.Contains(u, "127.0.0.1") {
+			t.Errorf("URL %q", u)
+		}
+	}
+	if _, err := URLs("8734"); err == nil {
+		t.Error("URLs without a port separator succeeded")
+	}
+}
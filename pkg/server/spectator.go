@@ -0,0 +1,54 @@
+package server
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+)
+
+// The spectator page shows the live board, move list, evaluation and
+// clocks for people watching on a TV or their phones. It follows the
+// /api/events stream and re-reads /api/state after each event.
+//
+//go:embed web
+var web embed.FS
+
+// spectatorHandler serves the spectator page and its assets.
+func spectatorHandler() http.Handler {
+	sub, err := fs.Sub(web, "web")
+	if err != nil {
+		panic(err) // the directory is embedded above
+	}
+	return http.FileServerFS(sub)
+}
+
+// URLs returns the addresses at which spectators on the local network can
+// open the page served on addr: the host in addr if it names one, otherwise
+// every IPv4 address of this computer other than loopback.
+func URLs(addr string) ([]string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if host != "" && host != "0.0.0.0" && host != "::" {
+		return []string{fmt.Sprintf("http://%s/", net.JoinHostPort(host, port))}, nil
+	}
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil, err
+	}
+	var urls []string
+	for _, a := range addrs {
+		ipnet, ok := a.(*net.IPNet)
+		if !ok || ipnet.IP.IsLoopback() || ipnet.IP.To4() == nil {
+			continue
+		}
+		urls = append(urls, fmt.Sprintf("http://%s/", net.JoinHostPort(ipnet.IP.String(), port)))
+	}
+	if len(urls) == 0 {
+		urls = append(urls, fmt.Sprintf("http://%s/", net.JoinHostPort("localhost", port)))
+	}
+	return urls, nil
+}
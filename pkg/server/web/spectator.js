@@ -0,0 +1,195 @@
+// Spectator view: follows /api/events and redraws from /api/state.
+"use strict";
+
+const GLYPHS = { k: "♚", q: "♛", r: "♜", b: "♝", n: "♞", p: "♟" };
+const FILES = "abcdefgh";
+
+const $ = (id) => document.getElementById(id);
+
+let state = null;    // last State from the server
+let received = 0;    // performance.now() when state arrived
+let invalid = [];    // [row, col] squares flashing after invalid_board
+
+// squares parses the board part of a FEN into an 8x8 array of piece
+// letters (uppercase for White), row 0 being rank 8.
+function squares(fen) {
+  const rows = [];
+  for (const rank of fen.split(" ")[0].split("/")) {
+    const row = [];
+    for (const ch of rank) {
+      if (ch >= "1" && ch <= "8") {
+        for (let i = 0; i < Number(ch); i++) row.push("");
+      } else {
+        row.push(ch);
+      }
+    }
+    rows.push(row);
+  }
+  return rows;
+}
+
+function renderBoard() {
+  const board = $("board");
+  const fen = state && state.fen ? state.fen : "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1";
+  const grid = squares(fen);
+  const flipped = state && state.human === "black";
+  const last = state && state.last_move ? state.last_move.uci : "";
+  board.replaceChildren();
+  for (let i = 0; i < 8; i++) {
+    for (let j = 0; j < 8; j++) {
+      const row = flipped ? 7 - i : i;
+      const col = flipped ? 7 - j : j;
+      const name = FILES[col] + (8 - row);
+      const sq = document.createElement("div");
+      sq.className = "sq " + ((row + col) % 2 === 0 ? "light" : "dark");
+      if (last.slice(0, 2) === name) sq.classList.add("from");
+      if (last.slice(2, 4) === name) sq.classList.add("to");
+      if (invalid.some(([r, c]) => r === row && c === col)) sq.classList.add("invalid");
+      const p = grid[row][col];
+      if (p) {
+        const piece = document.createElement("span");
+        piece.className = "piece " + (p === p.toUpperCase() ? "w" : "b");
+        piece.textContent = GLYPHS[p.toLowerCase()];
+        sq.append(piece);
+      }
+      if (i === 7) sq.append(coord("file", FILES[col]));
+      if (j === 0) sq.append(coord("rank", String(8 - row)));
+      board.append(sq);
+    }
+  }
+}
+
+function coord(kind, text) {
+  const el = document.createElement("span");
+  el.className = "coord " + kind;
+  el.textContent = text;
+  return el;
+}
+
+function renderMoves() {
+  const list = $("moves");
+  list.replaceChildren();
+  const moves = state ? state.moves : [];
+  // A game set up with Black to move starts with an empty White move.
+  const start = (state && state.start_ply) || 0;
+  const lead = start % 2;
+  list.start = Math.floor(start / 2) + 1;
+  for (let i = -lead; i < moves.length; i += 2) {
+    const li = document.createElement("li");
+    for (const k of [i, i + 1]) {
+      if (k >= moves.length) break;
+      const span = document.createElement("span");
+      span.textContent = k < 0 ? "…" : moves[k];
+      if (k === moves.length - 1) span.className = "current";
+      li.append(span);
+    }
+    list.append(li);
+  }
+  list.scrollTop = list.scrollHeight;
+}
+
+function renderEval() {
+  const ev = state && state.eval;
+  let white = 0.5;
+  let text = "0.0";
+  if (ev && ev.mate) {
+    white = ev.mate > 0 ? 1 : 0;
+    text = "M" + Math.abs(ev.mate);
+  } else if (ev) {
+    white = 1 / (1 + Math.pow(10, -ev.cp / 400));
+    text = (ev.cp > 0 ? "+" : "") + (ev.cp / 100).toFixed(1);
+  }
+  // The bar fills from the bottom with the colour playing from there.
+  const flipped = state && state.human === "black";
+  $("evalfill").style.height = (flipped ? 1 - white : white) * 100 + "%";
+  $("evalfill").style.background = flipped ? "#000" : "#f5f5f5";
+  $("evalbar").style.background = flipped ? "#f5f5f5" : "#403d39";
+  $("evaltext").textContent = text;
+}
+
+function formatTime(ms) {
+  const s = Math.floor(ms / 1000);
+  const h = Math.floor(s / 3600);
+  const m = Math.floor(s / 60) % 60;
+  const sec = String(s % 60).padStart(2, "0");
+  return h > 0 ? `${h}:${String(m).padStart(2, "0")}:${sec}` : `${m}:${sec}`;
+}
+
+function renderClocks() {
+  const clock = state ? state.clock : { white_ms: 0, black_ms: 0 };
+  const flipped = state && state.human === "black";
+  const elapsed = performance.now() - received;
+  const sides = flipped ? ["white", "black"] : ["black", "white"]; // top, bottom
+  [["clock-top", sides[0]], ["clock-bottom", sides[1]]].forEach(([id, side]) => {
+    const el = $(id);
+    let ms = clock[side + "_ms"];
+    if (clock.running === side) ms += elapsed;
+    let name = side === "white" ? "White" : "Black";
+    if (state && state.human) name += state.human === side ? " (player)" : " (engine)";
+    el.querySelector(".name").textContent = name;
+    el.querySelector(".time").textContent = formatTime(ms);
+    el.classList.toggle("running", clock.running === side);
+  });
+}
+
+function render() {
+  renderBoard();
+  renderMoves();
+  renderEval();
+  renderClocks();
+  $("status").textContent = state && state.status ? state.status : state && state.state === "idle" ? "Waiting for a game…" : "";
+  $("outcome").textContent = state && state.outcome ? state.outcome : "";
+  $("banner").hidden = invalid.length === 0;
+  $("banner").textContent = "The board does not match the game — waiting for it to be corrected";
+}
+
+function setState(s) {
+  state = s;
+  received = performance.now();
+  render();
+}
+
+async function refresh() {
+  try {
+    const resp = await fetch("api/state");
+    if (resp.ok) setState(await resp.json());
+  } catch (e) {
+    // The stream reconnects and refreshes again.
+  }
+}
+
+function connect() {
+  const proto = location.protocol === "https:" ? "wss:" : "ws:";
+  const ws = new WebSocket(`${proto}//${location.host}${location.pathname.replace(/[^/]*$/, "")}api/events`);
+  ws.onopen = () => {
+    $("connection").textContent = "live";
+    $("connection").className = "online";
+  };
+  ws.onmessage = (e) => {
+    const msg = JSON.parse(e.data);
+    switch (msg.type) {
+      case "state":
+        setState(msg.game);
+        return;
+      case "invalid_board":
+        invalid = msg.squares || [];
+        break;
+      case "board_corrected":
+      case "game_started":
+      case "move_made":
+      case "taken_back":
+        invalid = [];
+        break;
+    }
+    refresh();
+  };
+  ws.onclose = () => {
+    $("connection").textContent = "reconnecting…";
+    $("connection").className = "offline";
+    setTimeout(connect, 2000);
+  };
+}
+
+render();
+connect();
+setInterval(renderClocks, 250);
@@ -0,0 +1,255 @@
+package speech
+
+import (
+	"bufio"
+	"errors"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// Voice is a voice offered by a speech backend.
+type Voice struct {
+	Name     string // passed back to Say
+	Language string // e.g. "en_GB", "en-gb"; empty if unknown
+}
+
+// Speaker turns text into speech.
+type Speaker interface {
+	// Name identifies the backend, e.g. "espeak-ng".
+	Name() string
+	// Voices lists the voices the backend offers, nil if it cannot tell.
+	Voices() []Voice
+	// DefaultVoice is the voice to preselect, "" for the backend default.
+	DefaultVoice() string
+	// Say speaks text, interrupting anything still being spoken, and
+	// blocks until it finishes or is itself interrupted. An empty voice
+	// uses the backend default.
+	Say(voice, text string) error
+}
+
+// backend describes a command-line speech program.
+type backend struct {
+	name      string
+	args      func(voice, text string) []string // command line to speak text
+	listArgs  []string                          // command line listing voices
+	parse     func(out string) []Voice          // parses the listArgs output
+	stopArgs  []string                          // command line cancelling speech, if killing Say is not enough
+	preferred []string                          // default voices, best first
+}
+
+// Backends in order of preference for auto-detection.
+var backends = []backend{
+	{
+		name: "say",
+		args: func(voice, text string) []string {
+			if voice == "" {
+				return []string{"say", text}
+			}
+			return []string{"say", "-v", voice, text}
+		},
+		listArgs:  []string{"say", "-v", "?"},
+		parse:     parseSayVoices,
+		preferred: []string{"Daniel", "Samantha"},
+	},
+	espeakBackend("espeak-ng"),
+	espeakBackend("espeak"),
+	{
+		name: "spd-say",
+		args: func(voice, text string) []string {
+			if voice == "" {
+				return []string{"spd-say", "-w", text}
+			}
+			return []string{"spd-say", "-w", "-y", voice, text}
+		},
+		listArgs: []string{"spd-say", "-L"},
+		parse:    parseSpdVoices,
+		stopArgs: []string{"spd-say", "-C"},
+	},
+}
+
+func espeakBackend(cmd string) backend {
+	return backend{
+		name: cmd,
+		args: func(voice, text string) []string {
+			if voice == "" {
+				return []string{cmd, text}
+			}
+			return []string{cmd, "-v", voice, text}
+		},
+		listArgs:  []string{cmd, "--voices"},
+		parse:     parseEspeakVoices,
+		preferred: []string{"en-gb", "en-uk", "en"},
+	}
+}
+
+// ErrNoSpeaker is returned by Detect when no backend is installed.
+var ErrNoSpeaker = errors.New("speech: no text-to-speech program found")
+
+// Available returns a speaker for every installed backend, in order of
+// preference.
+func Available() []Speaker {
+	return available(exec.LookPath)
+}
+
+func available(lookPath func(string) (string, error)) []Speaker {
+	var speakers []Speaker
+	for _, b := range backends {
+		if _, err := lookPath(b.args("", "")[0]); err == nil {
+			speakers = append(speakers, &commandSpeaker{backend: b})
+		}
+	}
+	return speakers
+}
+
+// Detect returns the preferred installed backend: say on macOS, then
+// espeak-ng, espeak and spd-say. If none is installed it returns a silent
+// speaker and ErrNoSpeaker.
+func Detect() (Speaker, error) {
+	if speakers := Available(); len(speakers) > 0 {
+		return speakers[0], nil
+	}
+	return Silent{}, ErrNoSpeaker
+}
+
+// commandSpeaker speaks by running a backend. Each utterance kills the
+// previous one so speech never overlaps.
+type commandSpeaker struct {
+	backend
+
+	mu      sync.Mutex
+	current *exec.Cmd
+
+	voicesOnce sync.Once
+	voices     []Voice
+}
+
+func (s *commandSpeaker) Name() string { return s.name }
+
+func (s *commandSpeaker) Voices() []Voice {
+	s.voicesOnce.Do(func() {
+		out, err := exec.Command(s.listArgs[0], s.listArgs[1:]...).Output()
+		if err == nil {
+			s.voices = s.parse(string(out))
+		}
+	})
+	return s.voices
+}
+
+func (s *commandSpeaker) DefaultVoice() string {
+	return preferredVoice(s.Voices(), s.preferred)
+}
+
+func (s *commandSpeaker) Say(voice, text string) error {
+	args := s.args(voice, text)
+	cmd := exec.Command(args[0], args[1:]...)
+
+	s.mu.Lock()
+	if s.current != nil && s.current.Process != nil {
+		s.current.Process.Kill()
+	}
+	if s.stopArgs != nil {
+		exec.Command(s.stopArgs[0], s.stopArgs[1:]...).Run()
+	}
+	s.current = cmd
+	err := cmd.Start()
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	cmd.Wait() // killed by a newer utterance
+	return nil
+}
+
+// preferredVoice returns the first of preferred that is in voices, else the
+// first voice, else "".
+func preferredVoice(voices []Voice, preferred []string) string {
+	for _, p := range preferred {
+		for _, v := range voices {
+			if v.Name == p {
+				return p
+			}
+		}
+	}
+	if len(voices) > 0 {
+		return voices[0].Name
+	}
+	return ""
+}
+
+// Silent is a speaker that says nothing. If Log is set, each utterance is
+// passed to it instead, which is useful in tests and on machines without
+// speech.
+type Silent struct {
+	Log func(voice, text string)
+}
+
+func (Silent) Name() string         { return "silent" }
+func (Silent) Voices() []Voice      { return nil }
+func (Silent) DefaultVoice() string { return "" }
+
+func (s Silent) Say(voice, text string) error {
+	if s.Log != nil {
+		s.Log(voice, text)
+	}
+	return nil
+}
+
+// parseSayVoices parses `say -v ?`, one voice per line:
+//
+//	Daniel              en_GB    # Hello! My name is Daniel.
+func parseSayVoices(out string) []Voice {
+	var voices []Voice
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		voices = append(voices, Voice{
+			Name:     strings.Join(fields[:len(fields)-1], " "),
+			Language: fields[len(fields)-1],
+		})
+	}
+	return voices
+}
+
+// parseEspeakVoices parses `espeak-ng --voices` (or espeak's), a table whose
+// second column is the language, which is also what -v accepts:
+//
+//	Pty Language       Age/Gender VoiceName          File                 Other Languages
+//	 5  en-gb           --/M      English_(Great_Britain) gmw/en          (en 2)
+func parseEspeakVoices(out string) []Voice {
+	var voices []Voice
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || fields[0] == "Pty" {
+			continue
+		}
+		voices = append(voices, Voice{Name: fields[1], Language: fields[1]})
+	}
+	return voices
+}
+
+// parseSpdVoices parses `spd-say -L`, a table of name, language and variant
+// where names may contain spaces:
+//
+//	NAME                     LANGUAGE  VARIANT
+//	English (Great Britain)  en-GB     none
+func parseSpdVoices(out string) []Voice {
+	var voices []Voice
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || fields[0] == "NAME" {
+			continue
+		}
+		voices = append(voices, Voice{
+			Name:     strings.Join(fields[:len(fields)-2], " "),
+			Language: fields[len(fields)-2],
+		})
+	}
+	return voices
+}
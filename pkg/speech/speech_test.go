@@ -0,0 +1,89 @@
+package speech
+
+import (
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+func TestParseSayVoices(t *testing.T) {
+	out := `Albert              en_US    # Hello! My name is Albert.
+Bad News            en_US    # The light you see at the end of the tunnel is the headlamp of a fast approaching train.
+Daniel              en_GB    # Hello! My name is Daniel.
+`
+	want := []Voice{{"Albert", "en_US"}, {"Bad News", "en_US"}, {"Daniel", "en_GB"}}
+	if got := parseSayVoices(out); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseSayVoices() = %v, want %v", got, want)
+	}
+}
+
+func TestParseEspeakVoices(t *testing.T) {
+	out := `Pty Language       Age/Gender VoiceName          File                 Other Languages
+ 5  af              --/M      Afrikaans          gmw/af               
+ 2  en-gb           --/M      English_(Great_Britain) gmw/en            (en 2)
+ 5  hi              --/M      Hindi              inc/hi               
+`
+	want := []Voice{{"af", "af"}, {"en-gb", "en-gb"}, {"hi", "hi"}}
+	if got := parseEspeakVoices(out); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseEspeakVoices() = %v, want %v", got, want)
+	}
+}
+
+func TestParseSpdVoices(t *testing.T) {
+	out := `     NAME                     LANGUAGE  VARIANT
+     Afrikaans                af        none
+     English (Great Britain)  en-GB     none
+`
+	want := []Voice{{"Afrikaans", "af"}, {"English (Great Britain)", "en-GB"}}
+	if got := parseSpdVoices(out); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseSpdVoices() = %v, want %v", got, want)
+	}
+}
+
+func TestAvailable(t *testing.T) {
+	installed := func(names ...string) func(string) (string, error) {
+		return func(file string) (string, error) {
+			for _, n := range names {
+				if n == file {
+					return "/usr/bin/" + n, nil
+				}
+			}
+			return "", exec.ErrNotFound
+		}
+	}
+
+	var got []string
+	for _, s := range available(installed("spd-say", "espeak-ng")) {
+		got = append(got, s.Name())
+	}
+	if want := []string{"espeak-ng", "spd-say"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("available() = %v, want %v", got, want)
+	}
+	if s := available(installed()); len(s) != 0 {
+		t.Errorf("available() with nothing installed = %v, want none", s)
+	}
+}
+
+func TestPreferredVoice(t *testing.T) {
+	voices := []Voice{{Name: "Albert"}, {Name: "Daniel"}}
+	if got := preferredVoice(voices, []string{"Karen", "Daniel"}); got != "Daniel" {
+		t.Errorf("preferredVoice() = %q, want Daniel", got)
+	}
+	if got := preferredVoice(voices, []string{"Karen"}); got != "Albert" {
+		t.Errorf("preferredVoice() = %q, want the first voice", got)
+	}
+	if got := preferredVoice(nil, []string{"Karen"}); got != "" {
+		t.Errorf("preferredVoice() = %q, want empty", got)
+	}
+}
+
+func TestSilentLogs(t *testing.T) {
+	var said []string
+	s := Silent{Log: func(voice, text string) { said = append(said, voice+": "+text) }}
+	if err := s.Say("Daniel", "White castles king side"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Daniel: White castles king side"}; !reflect.DeepEqual(said, want) {
+		t.Errorf("logged %v, want %v", said, want)
+	}
+}
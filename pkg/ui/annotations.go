@@ -0,0 +1,130 @@
+package ui
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"golang.org/x/image/vector"
+)
+
+// Annotation colours, semi-transparent like lichess so pieces stay visible.
+var (
+	AnnotationGreen  = color.NRGBA{R: 0x15, G: 0x78, B: 0x1b, A: 0xaa}
+	AnnotationRed    = color.NRGBA{R: 0x88, G: 0x20, B: 0x20, A: 0xaa}
+	AnnotationBlue   = color.NRGBA{R: 0x00, G: 0x30, B: 0x88, A: 0xaa}
+	AnnotationYellow = color.NRGBA{R: 0xe6, G: 0x8f, B: 0x00, A: 0xaa}
+)
+
+// Arrow is an arrow drawn from one square to another, in board coordinates
+// (row 0 = rank 8, col 0 = file a).
+type Arrow struct {
+	FromRow, FromCol int
+	ToRow, ToCol     int
+	Color            color.Color
+}
+
+// Circle is a ring drawn around a square, in board coordinates.
+type Circle struct {
+	Row, Col int
+	Color    color.Color
+}
+
+// Arrow and circle proportions, as fractions of a square.
+const (
+	arrowShaftWidth = 0.16
+	arrowHeadWidth  = 0.42
+	arrowHeadLength = 0.38
+	arrowEndOffset  = 0.12 // gap left before the to-square centre
+	circleRadius    = 0.46
+	circleThickness = 0.07
+	circleSegments  = 48
+)
+
+// boardGeometry places the 8x8 grid within an image, in pixels.
+type boardGeometry struct {
+	originX, originY float32 // top-left corner of the a8 (or h1 if flipped) square
+	square           float32 // square size
+	flipped          bool
+}
+
+// squareCentre returns the pixel centre of the square at board (row, col).
+func (g boardGeometry) squareCentre(row, col int) (float32, float32) {
+	dRow, dCol := displayPos(row, col, g.flipped)
+	return g.originX + (float32(dCol)+0.5)*g.square,
+		g.originY + (float32(dRow)+0.5)*g.square
+}
+
+// drawAnnotations renders circles then arrows onto dst.
+func drawAnnotations(dst draw.Image, g boardGeometry, arrows []Arrow, circles []Circle) {
+	b := dst.Bounds()
+	for _, c := range circles {
+		z := vector.NewRasterizer(b.Dx(), b.Dy())
+		cx, cy := g.squareCentre(c.Row, c.Col)
+		outer := circleRadius * g.square
+		inner := outer - circleThickness*g.square
+		// Outer ring one way, inner the other, so the centre is left
+		// unfilled under the non-zero winding rule.
+		addCircle(z, cx, cy, outer, false)
+		addCircle(z, cx, cy, inner, true)
+		z.Draw(dst, b, image.NewUniform(c.Color), image.Point{})
+	}
+	for _, a := range arrows {
+		if a.FromRow == a.ToRow && a.FromCol == a.ToCol {
+			continue
+		}
+		z := vector.NewRasterizer(b.Dx(), b.Dy())
+		addArrow(z, g, a)
+		z.Draw(dst, b, image.NewUniform(a.Color), image.Point{})
+	}
+}
+
+// addCircle adds a closed polygon approximating a circle.
+func addCircle(z *vector.Rasterizer, cx, cy, r float32, reverse bool) {
+	for i := 0; i <= circleSegments; i++ {
+		t := 2 * math.Pi * float64(i) / circleSegments
+		if reverse {
+			t = -t
+		}
+		x := cx + r*float32(math.Cos(t))
+		y := cy + r*float32(math.Sin(t))
+		if i == 0 {
+			z.MoveTo(x, y)
+		} else {
+			z.LineTo(x, y)
+		}
+	}
+	z.ClosePath()
+}
+
+// addArrow adds the outline of an arrow: a shaft ending in a triangular head.
+func addArrow(z *vector.Rasterizer, g boardGeometry, a Arrow) {
+	x0, y0 := g.squareCentre(a.FromRow, a.FromCol)
+	x1, y1 := g.squareCentre(a.ToRow, a.ToCol)
+
+	dx, dy := x1-x0, y1-y0
+	length := float32(math.Hypot(float64(dx), float64(dy)))
+	ux, uy := dx/length, dy/length // unit vector along the arrow
+	nx, ny := -uy, ux              // unit normal
+
+	end := length - arrowEndOffset*g.square
+	headBase := end - arrowHeadLength*g.square
+	shaft := arrowShaftWidth * g.square / 2
+	head := arrowHeadWidth * g.square / 2
+
+	// point returns the position at distance d along the arrow, offset w
+	// to the side.
+	point := func(d, w float32) (float32, float32) {
+		return x0 + ux*d + nx*w, y0 + uy*d + ny*w
+	}
+
+	z.MoveTo(point(0, -shaft))
+	z.LineTo(point(headBase, -shaft))
+	z.LineTo(point(headBase, -head))
+	z.LineTo(point(end, 0))
+	z.LineTo(point(headBase, head))
+	z.LineTo(point(headBase, shaft))
+	z.LineTo(point(0, shaft))
+	z.ClosePath()
+}
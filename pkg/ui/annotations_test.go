@@ -0,0 +1,46 @@
+package ui
+
+import (
+	"image"
+	"testing"
+)
+
+// alphaAt returns the alpha of the pixel at the centre of board square
+// (row, col) for geometry g.
+func alphaAt(img *image.NRGBA, g boardGeometry, row, col int) uint8 {
+	x, y := g.squareCentre(row, col)
+	return img.NRGBAAt(int(x), int(y)).A
+}
+
+func TestDrawAnnotationsArrow(t *testing.T) {
+	for _, flipped := range []bool{false, true} {
+		g := boardGeometry{square: 50, flipped: flipped}
+		img := image.NewNRGBA(image.Rect(0, 0, 400, 400))
+
+		// e2-e4: row 6 -> row 4, col 4
+		drawAnnotations(img, g, []Arrow{{FromRow: 6, FromCol: 4, ToRow: 4, ToCol: 4, Color: AnnotationGreen}}, nil)
+
+		if alphaAt(img, g, 5, 4) == 0 {
+			t.Errorf("flipped=%v: e3 not covered by arrow", flipped)
+		}
+		if alphaAt(img, g, 6, 3) != 0 {
+			t.Errorf("flipped=%v: neighbouring square painted", flipped)
+		}
+	}
+}
+
+func TestDrawAnnotationsCircleIsRing(t *testing.T) {
+	g := boardGeometry{square: 50}
+	img := image.NewNRGBA(image.Rect(0, 0, 400, 400))
+	drawAnnotations(img, g, nil, []Circle{{Row: 0, Col: 0, Color: AnnotationRed}})
+
+	if alphaAt(img, g, 0, 0) != 0 {
+		t.Error("circle centre should be unfilled")
+	}
+	// Left edge of the ring: centre x minus the radius
+	cx, cy := g.squareCentre(0, 0)
+	edge := int(cx - circleRadius*g.square + circleThickness*g.square/2)
+	if img.NRGBAAt(edge, int(cy)).A == 0 {
+		t.Error("ring not drawn")
+	}
+}
@@ -2,11 +2,13 @@ package ui
 
 import (
 	"embed"
+	"path"
+	"sync"
 
 	"fyne.io/fyne/v2"
 )
 
-//go:embed pieces/*.svg
+//go:embed pieces/*/*.svg
 var pieceFS embed.FS
 
 // PieceType represents a chess piece for display on the board widget.
@@ -28,33 +30,73 @@ const (
 	BlackPawn
 )
 
-// pieceFiles maps PieceType to the embedded SVG filename.
+// pieceFiles maps PieceType to its SVG filename within a piece set.
 var pieceFiles = map[PieceType]string{
-	WhiteKing:   "pieces/wK.svg",
-	WhiteQueen:  "pieces/wQ.svg",
-	WhiteRook:   "pieces/wR.svg",
-	WhiteBishop: "pieces/wB.svg",
-	WhiteKnight: "pieces/wN.svg",
-	WhitePawn:   "pieces/wP.svg",
-	BlackKing:   "pieces/bK.svg",
-	BlackQueen:  "pieces/bQ.svg",
-	BlackRook:   "pieces/bR.svg",
-	BlackBishop: "pieces/bB.svg",
-	BlackKnight: "pieces/bN.svg",
-	BlackPawn:   "pieces/bP.svg",
+	WhiteKing:   "wK.svg",
+	WhiteQueen:  "wQ.svg",
+	WhiteRook:   "wR.svg",
+	WhiteBishop: "wB.svg",
+	WhiteKnight: "wN.svg",
+	WhitePawn:   "wP.svg",
+	BlackKing:   "bK.svg",
+	BlackQueen:  "bQ.svg",
+	BlackRook:   "bR.svg",
+	BlackBishop: "bB.svg",
+	BlackKnight: "bN.svg",
+	BlackPawn:   "bP.svg",
 }
 
-// pieceResources caches loaded Fyne resources.
-var pieceResources = map[PieceType]fyne.Resource{}
+// PieceSet is an embedded set of piece SVGs, stored in pieces/<Dir>.
+type PieceSet struct {
+	Name string // shown in the UI and persisted
+	Dir  string
+}
 
-// PieceResource returns the Fyne resource for a given piece type.
-// Returns nil for NoPieceType.
+// PieceSets lists the available piece sets; the first is the default.
+var PieceSets = []PieceSet{
+	{Name: "Classic", Dir: "cburnett"},
+	{Name: "Ivory", Dir: "ivory"},
+	{Name: "High Contrast", Dir: "highcontrast"},
+}
+
+// DefaultPieceSet is the piece set used unless another is selected.
+var DefaultPieceSet = PieceSets[0]
+
+// PieceSetByName returns the piece set with the given name, or the default
+// if there is none.
+func PieceSetByName(name string) PieceSet {
+	for _, s := range PieceSets {
+		if s.Name == name {
+			return s
+		}
+	}
+	return DefaultPieceSet
+}
+
+// pieceResources caches loaded Fyne resources, keyed by set directory and
+// piece type.
+var (
+	pieceResourcesMu sync.Mutex
+	pieceResources   = map[string]map[PieceType]fyne.Resource{}
+)
+
+// PieceResource returns the Fyne resource for a given piece type in the
+// default piece set. Returns nil for NoPieceType.
 func PieceResource(pt PieceType) fyne.Resource {
+	return DefaultPieceSet.Resource(pt)
+}
+
+// Resource returns the Fyne resource for a piece type in this set.
+// Returns nil for NoPieceType.
+func (s PieceSet) Resource(pt PieceType) fyne.Resource {
 	if pt == NoPieceType {
 		return nil
 	}
 
-	if r, ok := pieceResources[pt]; ok {
+	pieceResourcesMu.Lock()
+	defer pieceResourcesMu.Unlock()
+
+	if r, ok := pieceResources[s.Dir][pt]; ok {
 		return r
 	}
 
@@ -63,13 +105,19 @@ func PieceResource(pt PieceType) fyne.Resource {
 		return nil
 	}
 
-	data, err := pieceFS.ReadFile(filename)
+	// Resource names must be unique across sets — Fyne caches rendered
+	// SVGs by name.
+	name := path.Join("pieces", s.Dir, filename)
+	data, err := pieceFS.ReadFile(name)
 	if err != nil {
 		return nil
 	}
 
-	r := fyne.NewStaticResource(filename, data)
-	pieceResources[pt] = r
+	r := fyne.NewStaticResource(name, data)
+	if pieceResources[s.Dir] == nil {
+		pieceResources[s.Dir] = map[PieceType]fyne.Resource{}
+	}
+	pieceResources[s.Dir][pt] = r
 	return r
 }
 
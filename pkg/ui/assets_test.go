@@ -0,0 +1,24 @@
+package ui
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPieceSetsDifferFromDefault(t *testing.T) {
+	for _, set := range PieceSets {
+		if set == DefaultPieceSet {
+			continue
+		}
+		for pt := range pieceFiles {
+			r := set.Resource(pt)
+			if r == nil {
+				t.Errorf("%s: no %s", set.Name, pieceFiles[pt])
+				continue
+			}
+			if bytes.Equal(r.Content(), DefaultPieceSet.Resource(pt).Content()) {
+				t.Errorf("%s: %s is the same as in %s", set.Name, pieceFiles[pt], DefaultPieceSet.Name)
+			}
+		}
+	}
+}
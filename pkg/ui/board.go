@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"image"
 	"image/color"
 	"sync"
 	"time"
@@ -11,15 +12,6 @@ import (
 	"fyne.io/fyne/v2/widget"
 )
 
-var (
-	lightSquare      = color.NRGBA{R: 0xf0, G: 0xd9, B: 0xb5, A: 0xff} // #f0d9b5
-	darkSquare       = color.NRGBA{R: 0xb5, G: 0x88, B: 0x63, A: 0xff} // #b58863
-	highlightFrom    = color.NRGBA{R: 0x00, G: 0x88, B: 0xff, A: 0x80} // blue semi-transparent
-	highlightTo      = color.NRGBA{R: 0x00, G: 0xcc, B: 0x44, A: 0x80} // green semi-transparent
-	highlightInvalid = color.NRGBA{R: 0xff, G: 0x00, B: 0x00, A: 0x80} // red semi-transparent
-	highlightCheck   = color.NRGBA{R: 0xff, G: 0x20, B: 0x20, A: 0xbb} // red, more opaque
-)
-
 // greyedTranslucency is the translucency applied to pieces in pre-game mode.
 const greyedTranslucency = 0.7
 
@@ -27,11 +19,19 @@ const greyedTranslucency = 0.7
 const labelFontSize = 17
 
 // BoardWidget is a lichess-style virtual chessboard that shows piece images.
+//
+// All methods take board coordinates (row 0 = rank 8, col 0 = file a, as
+// returned by RowColFromSquare) regardless of orientation; flipping only
+// changes where squares are drawn.
 type BoardWidget struct {
 	widget.BaseWidget
 
-	mu     sync.Mutex
-	pieces [8][8]PieceType
+	mu       sync.Mutex
+	pieces   [8][8]PieceType
+	flipped  bool // true = drawn from Black's side (rank 1 at the top)
+	greyed   bool // pieces drawn translucent (pre-game mode)
+	theme    BoardTheme
+	pieceSet PieceSet
 
 	// Flash state for invalid moves
 	flashMu   sync.Mutex
@@ -44,14 +44,40 @@ type BoardWidget struct {
 	checkRect  *canvas.Rectangle // overlay for king in check
 	checkRow   int               // row of checked king (-1 = hidden)
 	checkCol   int               // col of checked king
-	labels     []fyne.CanvasObject
+	labels     []*canvas.Text
 	root       *fyne.Container
+
+	// Annotation layer drawn above the pieces. arrows, circles and geom
+	// are guarded by mu; geom is recorded by Layout for the raster.
+	annotations *canvas.Raster
+	arrows      []Arrow
+	circles     []Circle
+	geom        boardGeometry
+	geomWidth   float32 // widget width the geometry was computed for
+
+	// Drag-and-drop move entry (see drag.go). dragImg floats above
+	// everything while a piece is dragged; drag state is UI-goroutine only.
+	dragImg     *canvas.Image
+	dragging    bool
+	dragFromRow int
+	dragFromCol int
+	dragLast    fyne.Position
+
+	// OnMove, if set, makes the board interactive: dragging a piece from
+	// one square to another reports the squares in board coordinates.
+	// The board is not changed; the callback decides whether to apply it.
+	OnMove func(fromRow, fromCol, toRow, toCol int)
+
+	// OnTapped and OnSecondaryTapped, if set, report clicks and right
+	// clicks on a square in board coordinates.
+	OnTapped          func(row, col int)
+	OnSecondaryTapped func(row, col int)
 }
 
 // NewBoardWidget creates a new virtual chessboard widget.
 // It initializes with the standard starting position in greyed-out mode.
 func NewBoardWidget() *BoardWidget {
-	b := &BoardWidget{checkRow: -1}
+	b := &BoardWidget{checkRow: -1, theme: DefaultBoardTheme, pieceSet: DefaultPieceSet, greyed: true}
 	b.ExtendBaseWidget(b)
 
 	// Build squares, highlights, piece images, check overlay, and labels
@@ -62,9 +88,9 @@ func NewBoardWidget() *BoardWidget {
 
 	for row := 0; row < 8; row++ {
 		for col := 0; col < 8; col++ {
-			c := lightSquare
+			c := b.theme.Light
 			if (row+col)%2 == 1 {
-				c = darkSquare
+				c = b.theme.Dark
 			}
 			rect := canvas.NewRectangle(c)
 			b.squares[row][col] = rect
@@ -80,7 +106,7 @@ func NewBoardWidget() *BoardWidget {
 			img := canvas.NewImageFromResource(nil)
 			img.FillMode = canvas.ImageFillContain
 			img.ScaleMode = canvas.ImageScaleSmooth
-			if res := PieceResource(pt); res != nil {
+			if res := b.pieceSet.Resource(pt); res != nil {
 				img.Resource = res
 				img.Translucency = greyedTranslucency
 			} else {
@@ -92,48 +118,131 @@ func NewBoardWidget() *BoardWidget {
 	}
 
 	// Check highlight overlay (single rectangle, layered on top of pieces)
-	b.checkRect = canvas.NewRectangle(highlightCheck)
+	b.checkRect = canvas.NewRectangle(b.theme.HighlightCheck)
 	b.checkRect.Hidden = true
 	objects = append(objects, b.checkRect)
 
-	// File labels (a-h) along the bottom (indices 0-7)
-	for col := 0; col < 8; col++ {
-		t := canvas.NewText(string(rune('a'+col)), color.White)
-		t.TextSize = labelFontSize
-		t.Alignment = fyne.TextAlignCenter
-		b.labels = append(b.labels, t)
-		objects = append(objects, t)
-	}
+	// Arrow/circle annotations, above pieces and the check overlay
+	b.annotations = canvas.NewRaster(b.drawAnnotationLayer)
+	b.annotations.Hidden = true
+	objects = append(objects, b.annotations)
 
-	// Rank labels (8-1) along the left (indices 8-15)
-	for row := 0; row < 8; row++ {
-		t := canvas.NewText(string(rune('8'-row)), color.White)
+	// Labels: files along the bottom (indices 0-7), ranks along the left
+	// (8-15), files along the top (16-23), ranks along the right (24-31).
+	// Text is filled in by setLabelTextsUnsafe to match the orientation.
+	for i := 0; i < 32; i++ {
+		t := canvas.NewText("", b.theme.Label)
 		t.TextSize = labelFontSize
 		t.Alignment = fyne.TextAlignCenter
 		b.labels = append(b.labels, t)
 		objects = append(objects, t)
 	}
+	b.setLabelTextsUnsafe()
 
-	// File labels (a-h) along the top (indices 16-23)
-	for col := 0; col < 8; col++ {
-		t := canvas.NewText(string(rune('a'+col)), color.White)
-		t.TextSize = labelFontSize
-		t.Alignment = fyne.TextAlignCenter
-		b.labels = append(b.labels, t)
-		objects = append(objects, t)
+	// Piece being dragged, above labels and annotations
+	b.dragImg = canvas.NewImageFromResource(nil)
+	b.dragImg.FillMode = canvas.ImageFillContain
+	b.dragImg.ScaleMode = canvas.ImageScaleSmooth
+	b.dragImg.Hidden = true
+	objects = append(objects, b.dragImg)
+
+	b.root = container.NewWithoutLayout(objects...)
+	return b
+}
+
+// SetFlipped sets the board orientation: true draws it from Black's side.
+func (b *BoardWidget) SetFlipped(flipped bool) {
+	b.mu.Lock()
+	b.flipped = flipped
+	b.mu.Unlock()
+	fyne.Do(func() {
+		b.setLabelTextsUnsafe()
+		// Lay out again to reposition every square; the root container
+		// has no layout of its own, so a refresh alone would not move them.
+		if size := b.Size(); !size.IsZero() {
+			(&boardRenderer{b: b}).Layout(size)
+		}
+		b.Refresh()
+	})
+}
+
+// Flipped reports whether the board is drawn from Black's side.
+func (b *BoardWidget) Flipped() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.flipped
+}
+
+// displayPos returns where the square at board (row, col) is drawn.
+func displayPos(row, col int, flipped bool) (int, int) {
+	if flipped {
+		return 7 - row, 7 - col
 	}
+	return row, col
+}
 
-	// Rank labels (8-1) along the right (indices 24-31)
-	for row := 0; row < 8; row++ {
-		t := canvas.NewText(string(rune('8'-row)), color.White)
-		t.TextSize = labelFontSize
-		t.Alignment = fyne.TextAlignCenter
-		b.labels = append(b.labels, t)
-		objects = append(objects, t)
+// setLabelTextsUnsafe writes the rank/file labels for the current
+// orientation. Must be called on the UI goroutine.
+func (b *BoardWidget) setLabelTextsUnsafe() {
+	b.mu.Lock()
+	flipped := b.flipped
+	b.mu.Unlock()
+
+	for i := 0; i < 8; i++ {
+		// Label slot i sits over display column/row i
+		row, col := displayPos(i, i, flipped)
+		file := string(rune('a' + col))
+		rank := string(rune('8' - row))
+		for _, idx := range []int{i, 16 + i} {
+			b.labels[idx].Text = file
+			b.labels[idx].Refresh()
+		}
+		for _, idx := range []int{8 + i, 24 + i} {
+			b.labels[idx].Text = rank
+			b.labels[idx].Refresh()
+		}
 	}
+}
 
-	b.root = container.NewWithoutLayout(objects...)
-	return b
+// SetAnnotations replaces the arrows and circles drawn over the board.
+// Squares are given in board coordinates.
+func (b *BoardWidget) SetAnnotations(arrows []Arrow, circles []Circle) {
+	b.mu.Lock()
+	b.arrows = append([]Arrow(nil), arrows...)
+	b.circles = append([]Circle(nil), circles...)
+	empty := len(arrows) == 0 && len(circles) == 0
+	b.mu.Unlock()
+	fyne.Do(func() {
+		b.annotations.Hidden = empty
+		b.annotations.Refresh()
+	})
+}
+
+// ClearAnnotations removes all arrows and circles.
+func (b *BoardWidget) ClearAnnotations() {
+	b.SetAnnotations(nil, nil)
+}
+
+// drawAnnotationLayer is the raster generator for the annotation layer.
+// w and h are in pixels, which may differ from the layout size on HiDPI
+// screens, so the recorded geometry is scaled to match.
+func (b *BoardWidget) drawAnnotationLayer(w, h int) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+
+	b.mu.Lock()
+	arrows, circles := b.arrows, b.circles
+	g, layoutW := b.geom, b.geomWidth
+	b.mu.Unlock()
+
+	if layoutW <= 0 || (len(arrows) == 0 && len(circles) == 0) {
+		return img
+	}
+	scale := float32(w) / layoutW
+	g.originX *= scale
+	g.originY *= scale
+	g.square *= scale
+	drawAnnotations(img, g, arrows, circles)
+	return img
 }
 
 // UpdatePieces sets the piece grid and refreshes the display.
@@ -141,6 +250,17 @@ func NewBoardWidget() *BoardWidget {
 func (b *BoardWidget) UpdatePieces(pieces [8][8]PieceType, greyed bool) {
 	b.mu.Lock()
 	b.pieces = pieces
+	b.greyed = greyed
+	b.mu.Unlock()
+
+	fyne.Do(b.refreshPiecesUnsafe)
+}
+
+// refreshPiecesUnsafe redraws the piece images from the current grid and
+// piece set. Must be called on the UI goroutine.
+func (b *BoardWidget) refreshPiecesUnsafe() {
+	b.mu.Lock()
+	pieces, greyed, set := b.pieces, b.greyed, b.pieceSet
 	b.mu.Unlock()
 
 	translucency := float64(0)
@@ -148,33 +268,74 @@ func (b *BoardWidget) UpdatePieces(pieces [8][8]PieceType, greyed bool) {
 		translucency = greyedTranslucency
 	}
 
+	for row := 0; row < 8; row++ {
+		for col := 0; col < 8; col++ {
+			img := b.pieceImgs[row][col]
+			pt := pieces[row][col]
+			res := set.Resource(pt)
+			if res != nil {
+				img.Resource = res
+				img.Translucency = translucency
+				img.Hidden = false
+			} else {
+				img.Hidden = true
+			}
+			img.Refresh()
+		}
+	}
+}
+
+// SetTheme recolours the board with the given palette. Highlights already
+// shown keep their colour until redrawn.
+func (b *BoardWidget) SetTheme(theme BoardTheme) {
+	b.mu.Lock()
+	b.theme = theme
+	b.mu.Unlock()
+
 	fyne.Do(func() {
 		for row := 0; row < 8; row++ {
 			for col := 0; col < 8; col++ {
-				img := b.pieceImgs[row][col]
-				pt := pieces[row][col]
-				res := PieceResource(pt)
-				if res != nil {
-					img.Resource = res
-					img.Translucency = translucency
-					img.Hidden = false
-				} else {
-					img.Hidden = true
+				c := theme.Light
+				if (row+col)%2 == 1 {
+					c = theme.Dark
 				}
-				img.Refresh()
+				b.squares[row][col].FillColor = c
+				b.squares[row][col].Refresh()
 			}
 		}
+		b.checkRect.FillColor = theme.HighlightCheck
+		b.checkRect.Refresh()
+		for _, l := range b.labels {
+			l.Color = theme.Label
+			l.Refresh()
+		}
 	})
 }
 
+// SetPieceSet redraws the pieces with the given set.
+func (b *BoardWidget) SetPieceSet(set PieceSet) {
+	b.mu.Lock()
+	b.pieceSet = set
+	b.mu.Unlock()
+	fyne.Do(b.refreshPiecesUnsafe)
+}
+
+// currentTheme returns the palette in use.
+func (b *BoardWidget) currentTheme() BoardTheme {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.theme
+}
+
 // HighlightMove shows from/to square highlights on the board.
 func (b *BoardWidget) HighlightMove(fromRow, fromCol, toRow, toCol int) {
+	theme := b.currentTheme()
 	fyne.Do(func() {
 		b.clearHighlightsUnsafe()
-		b.highlights[fromRow][fromCol].FillColor = highlightFrom
+		b.highlights[fromRow][fromCol].FillColor = theme.HighlightFrom
 		b.highlights[fromRow][fromCol].Hidden = false
 		b.highlights[fromRow][fromCol].Refresh()
-		b.highlights[toRow][toCol].FillColor = highlightTo
+		b.highlights[toRow][toCol].FillColor = theme.HighlightTo
 		b.highlights[toRow][toCol].Hidden = false
 		b.highlights[toRow][toCol].Refresh()
 	})
@@ -237,10 +398,11 @@ func (b *BoardWidget) FlashInvalid(diffs [][2]int) {
 	b.flashMu.Unlock()
 
 	// Show red highlights immediately
+	theme := b.currentTheme()
 	fyne.Do(func() {
 		b.clearHighlightsUnsafe()
 		for _, d := range diffs {
-			b.highlights[d[0]][d[1]].FillColor = highlightInvalid
+			b.highlights[d[0]][d[1]].FillColor = theme.HighlightInvalid
 			b.highlights[d[0]][d[1]].Hidden = false
 			b.highlights[d[0]][d[1]].Refresh()
 		}
@@ -331,10 +493,23 @@ func (r *boardRenderer) Layout(size fyne.Size) {
 
 	r.b.root.Resize(size)
 
+	r.b.mu.Lock()
+	flipped := r.b.flipped
+	cRow, cCol := r.b.checkRow, r.b.checkCol
+	r.b.geom = boardGeometry{originX: offsetX, originY: offsetY, square: sqSize, flipped: flipped}
+	r.b.geomWidth = size.Width
+	r.b.mu.Unlock()
+
+	// The annotation layer covers the whole widget
+	r.b.annotations.Move(fyne.NewPos(0, 0))
+	r.b.annotations.Resize(size)
+	r.b.annotations.Refresh()
+
 	for row := 0; row < 8; row++ {
 		for col := 0; col < 8; col++ {
-			x := offsetX + float32(col)*sqSize
-			y := offsetY + float32(row)*sqSize
+			dRow, dCol := displayPos(row, col, flipped)
+			x := offsetX + float32(dCol)*sqSize
+			y := offsetY + float32(dRow)*sqSize
 
 			r.b.squares[row][col].Move(fyne.NewPos(x, y))
 			r.b.squares[row][col].Resize(fyne.NewSize(sqSize, sqSize))
@@ -350,17 +525,15 @@ func (r *boardRenderer) Layout(size fyne.Size) {
 	}
 
 	// Position check highlight over the checked king's square
-	r.b.mu.Lock()
-	cRow, cCol := r.b.checkRow, r.b.checkCol
-	r.b.mu.Unlock()
 	if cRow >= 0 {
-		cx := offsetX + float32(cCol)*sqSize
-		cy := offsetY + float32(cRow)*sqSize
+		dRow, dCol := displayPos(cRow, cCol, flipped)
+		cx := offsetX + float32(dCol)*sqSize
+		cy := offsetY + float32(dRow)*sqSize
 		r.b.checkRect.Move(fyne.NewPos(cx, cy))
 		r.b.checkRect.Resize(fyne.NewSize(sqSize, sqSize))
 	}
 
-	// File labels (a-h) below the board (indices 0-7)
+	// File labels below the board (indices 0-7)
 	for i := 0; i < 8; i++ {
 		lbl := r.b.labels[i]
 		x := offsetX + float32(i)*sqSize
@@ -369,7 +542,7 @@ func (r *boardRenderer) Layout(size fyne.Size) {
 		lbl.Resize(fyne.NewSize(sqSize, labelMargin))
 	}
 
-	// Rank labels (8-1) to the left of the board (indices 8-15)
+	// Rank labels to the left of the board (indices 8-15)
 	for i := 0; i < 8; i++ {
 		lbl := r.b.labels[8+i]
 		x := offsetX - labelMargin
@@ -378,7 +551,7 @@ func (r *boardRenderer) Layout(size fyne.Size) {
 		lbl.Resize(fyne.NewSize(labelMargin, sqSize))
 	}
 
-	// File labels (a-h) above the board (indices 16-23)
+	// File labels above the board (indices 16-23)
 	for i := 0; i < 8; i++ {
 		lbl := r.b.labels[16+i]
 		x := offsetX + float32(i)*sqSize
@@ -387,7 +560,7 @@ func (r *boardRenderer) Layout(size fyne.Size) {
 		lbl.Resize(fyne.NewSize(sqSize, labelMargin))
 	}
 
-	// Rank labels (8-1) to the right of the board (indices 24-31)
+	// Rank labels to the right of the board (indices 24-31)
 	for i := 0; i < 8; i++ {
 		lbl := r.b.labels[24+i]
 		x := offsetX + 8*sqSize
@@ -0,0 +1,26 @@
+package ui
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+)
+
+func TestSetFlippedMovesSquares(t *testing.T) {
+	test.NewTempApp(t)
+	b := NewBoardWidget()
+	b.Resize(fyne.NewSize(492, 492))
+
+	a8, h1 := b.squares[0][0], b.squares[7][7]
+	if got := a8.Position(); got != fyne.NewPos(20, 20) {
+		t.Fatalf("a8 at %v before flipping, want (20, 20)", got)
+	}
+	b.SetFlipped(true)
+	if got := a8.Position(); got != fyne.NewPos(415.5, 415.5) {
+		t.Errorf("a8 at %v after flipping, want (415.5, 415.5)", got)
+	}
+	if got := h1.Position(); got != fyne.NewPos(20, 20) {
+		t.Errorf("h1 at %v after flipping, want (20, 20)", got)
+	}
+}
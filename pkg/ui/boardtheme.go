@@ -0,0 +1,75 @@
+package ui
+
+import "image/color"
+
+// BoardTheme is a colour palette for the virtual board.
+type BoardTheme struct {
+	Name string // shown in the UI and persisted
+
+	Light, Dark      color.NRGBA // square colours
+	HighlightFrom    color.NRGBA // move from-square
+	HighlightTo      color.NRGBA // move to-square
+	HighlightInvalid color.NRGBA // flashing invalid squares
+	HighlightCheck   color.NRGBA // king in check
+	Label            color.NRGBA // rank/file labels
+}
+
+// BoardThemes lists the available palettes; the first is the default.
+var BoardThemes = []BoardTheme{
+	{
+		Name:             "Brown",
+		Light:            color.NRGBA{R: 0xf0, G: 0xd9, B: 0xb5, A: 0xff}, // #f0d9b5
+		Dark:             color.NRGBA{R: 0xb5, G: 0x88, B: 0x63, A: 0xff}, // #b58863
+		HighlightFrom:    color.NRGBA{R: 0x00, G: 0x88, B: 0xff, A: 0x80}, // blue semi-transparent
+		HighlightTo:      color.NRGBA{R: 0x00, G: 0xcc, B: 0x44, A: 0x80}, // green semi-transparent
+		HighlightInvalid: color.NRGBA{R: 0xff, G: 0x00, B: 0x00, A: 0x80}, // red semi-transparent
+		HighlightCheck:   color.NRGBA{R: 0xff, G: 0x20, B: 0x20, A: 0xbb}, // red, more opaque
+		Label:            color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+	},
+	{
+		Name:             "Green",
+		Light:            color.NRGBA{R: 0xee, G: 0xee, B: 0xd2, A: 0xff}, // #eeeed2
+		Dark:             color.NRGBA{R: 0x76, G: 0x96, B: 0x56, A: 0xff}, // #769656
+		HighlightFrom:    color.NRGBA{R: 0xf6, G: 0xf6, B: 0x69, A: 0xa0},
+		HighlightTo:      color.NRGBA{R: 0xba, G: 0xca, B: 0x2b, A: 0xa0},
+		HighlightInvalid: color.NRGBA{R: 0xff, G: 0x00, B: 0x00, A: 0x80},
+		HighlightCheck:   color.NRGBA{R: 0xff, G: 0x20, B: 0x20, A: 0xbb},
+		Label:            color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+	},
+	{
+		Name:             "Blue",
+		Light:            color.NRGBA{R: 0xde, G: 0xe3, B: 0xe6, A: 0xff}, // #dee3e6
+		Dark:             color.NRGBA{R: 0x8c, G: 0xa2, B: 0xad, A: 0xff}, // #8ca2ad
+		HighlightFrom:    color.NRGBA{R: 0x00, G: 0x88, B: 0xff, A: 0x80},
+		HighlightTo:      color.NRGBA{R: 0x9b, G: 0xc7, B: 0x00, A: 0x90},
+		HighlightInvalid: color.NRGBA{R: 0xff, G: 0x00, B: 0x00, A: 0x80},
+		HighlightCheck:   color.NRGBA{R: 0xff, G: 0x20, B: 0x20, A: 0xbb},
+		Label:            color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+	},
+	{
+		// For projectors and bright rooms: maximum light/dark separation,
+		// near-opaque highlights and bright labels.
+		Name:             "High Contrast",
+		Light:            color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+		Dark:             color.NRGBA{R: 0x1f, G: 0x4e, B: 0x9a, A: 0xff},
+		HighlightFrom:    color.NRGBA{R: 0x00, G: 0xe5, B: 0xff, A: 0xd0},
+		HighlightTo:      color.NRGBA{R: 0x76, G: 0xff, B: 0x03, A: 0xd0},
+		HighlightInvalid: color.NRGBA{R: 0xff, G: 0x00, B: 0x00, A: 0xd0},
+		HighlightCheck:   color.NRGBA{R: 0xff, G: 0x00, B: 0x00, A: 0xee},
+		Label:            color.NRGBA{R: 0xff, G: 0xff, B: 0x00, A: 0xff},
+	},
+}
+
+// DefaultBoardTheme is the palette used unless another is selected.
+var DefaultBoardTheme = BoardThemes[0]
+
+// BoardThemeByName returns the palette with the given name, or the default
+// if there is none.
+func BoardThemeByName(name string) BoardTheme {
+	for _, t := range BoardThemes {
+		if t.Name == name {
+			return t
+		}
+	}
+	return DefaultBoardTheme
+}
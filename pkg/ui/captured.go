@@ -0,0 +1,99 @@
+package ui
+
+import (
+	"fmt"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// capturedIconSize is the size of each captured-piece icon.
+const capturedIconSize = 22
+
+// CapturedPieces is a row of small piece icons showing what one side has
+// captured, followed by its material advantage (e.g. "+2") if it is ahead.
+type CapturedPieces struct {
+	widget.BaseWidget
+
+	mu        sync.Mutex
+	pieces    []PieceType
+	advantage int
+	pieceSet  PieceSet
+
+	box *fyne.Container
+}
+
+// NewCapturedPieces creates an empty captured-pieces row.
+func NewCapturedPieces() *CapturedPieces {
+	c := &CapturedPieces{box: container.NewHBox(), pieceSet: DefaultPieceSet}
+	c.ExtendBaseWidget(c)
+	return c
+}
+
+// Set replaces the captured pieces and the material advantage in pawns.
+// Advantages of zero or less are not shown.
+func (c *CapturedPieces) Set(pieces []PieceType, advantage int) {
+	c.mu.Lock()
+	c.pieces = append([]PieceType(nil), pieces...)
+	c.advantage = advantage
+	c.mu.Unlock()
+	fyne.Do(c.Refresh)
+}
+
+// SetPieceSet redraws the icons with the given set.
+func (c *CapturedPieces) SetPieceSet(set PieceSet) {
+	c.mu.Lock()
+	c.pieceSet = set
+	c.mu.Unlock()
+	fyne.Do(c.Refresh)
+}
+
+func (c *CapturedPieces) CreateRenderer() fyne.WidgetRenderer {
+	return &capturedRenderer{c: c}
+}
+
+type capturedRenderer struct {
+	c *CapturedPieces
+}
+
+func (r *capturedRenderer) Destroy() {}
+
+func (r *capturedRenderer) Layout(size fyne.Size) {
+	r.c.box.Resize(size)
+}
+
+func (r *capturedRenderer) MinSize() fyne.Size {
+	// Keep the row's height even when nothing has been captured, so the
+	// board doesn't jump on the first capture.
+	min := r.c.box.MinSize()
+	if min.Height < capturedIconSize {
+		min.Height = capturedIconSize
+	}
+	return min
+}
+
+func (r *capturedRenderer) Objects() []fyne.CanvasObject {
+	return []fyne.CanvasObject{r.c.box}
+}
+
+func (r *capturedRenderer) Refresh() {
+	r.c.mu.Lock()
+	pieces, advantage, set := r.c.pieces, r.c.advantage, r.c.pieceSet
+	r.c.mu.Unlock()
+
+	objects := make([]fyne.CanvasObject, 0, len(pieces)+1)
+	for _, pt := range pieces {
+		img := canvas.NewImageFromResource(set.Resource(pt))
+		img.FillMode = canvas.ImageFillContain
+		img.SetMinSize(fyne.NewSize(capturedIconSize, capturedIconSize))
+		objects = append(objects, img)
+	}
+	if advantage > 0 {
+		objects = append(objects, widget.NewLabel(fmt.Sprintf("+%d", advantage)))
+	}
+	r.c.box.Objects = objects
+	r.c.box.Refresh()
+}
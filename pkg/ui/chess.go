@@ -0,0 +1,47 @@
+package ui
+
+import "github.com/notnil/chess"
+
+// PiecesFromChess converts a grid of chess.Piece values, such as
+// GameState.PieceGrid returns, to piece types for display.
+func PiecesFromChess(grid [8][8]chess.Piece) [8][8]PieceType {
+	var result [8][8]PieceType
+	for row := 0; row < 8; row++ {
+		for col := 0; col < 8; col++ {
+			result[row][col] = PieceFromChess(grid[row][col])
+		}
+	}
+	return result
+}
+
+// PieceFromChess converts a single chess.Piece to its PieceType.
+func PieceFromChess(p chess.Piece) PieceType {
+	switch p {
+	case chess.WhiteKing:
+		return WhiteKing
+	case chess.WhiteQueen:
+		return WhiteQueen
+	case chess.WhiteRook:
+		return WhiteRook
+	case chess.WhiteBishop:
+		return WhiteBishop
+	case chess.WhiteKnight:
+		return WhiteKnight
+	case chess.WhitePawn:
+		return WhitePawn
+	case chess.BlackKing:
+		return BlackKing
+	case chess.BlackQueen:
+		return BlackQueen
+	case chess.BlackRook:
+		return BlackRook
+	case chess.BlackBishop:
+		return BlackBishop
+	case chess.BlackKnight:
+		return BlackKnight
+	case chess.BlackPawn:
+		return BlackPawn
+	default:
+		return NoPieceType
+	}
+}
@@ -0,0 +1,14 @@
+package ui
+
+import (
+	"testing"
+
+	nchess "github.com/intothevoid/nayan/pkg/chess"
+)
+
+func TestPiecesFromChess(t *testing.T) {
+	grid := PiecesFromChess(nchess.NewGame(nchess.White).PieceGrid())
+	if grid != StartingPosition() {
+		t.Errorf("starting position converts to %v", grid)
+	}
+}
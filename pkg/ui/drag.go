@@ -0,0 +1,101 @@
+package ui
+
+import "fyne.io/fyne/v2"
+
+// squareAt returns the board square under pos, in board coordinates.
+// ok is false if pos is outside the board.
+func (b *BoardWidget) squareAt(pos fyne.Position) (row, col int, ok bool) {
+	b.mu.Lock()
+	g := b.geom
+	b.mu.Unlock()
+
+	if g.square <= 0 {
+		return 0, 0, false
+	}
+	dCol := int((pos.X - g.originX) / g.square)
+	dRow := int((pos.Y - g.originY) / g.square)
+	if pos.X < g.originX || pos.Y < g.originY || dCol > 7 || dRow > 7 {
+		return 0, 0, false
+	}
+	// displayPos is its own inverse
+	row, col = displayPos(dRow, dCol, g.flipped)
+	return row, col, true
+}
+
+// Dragged moves the picked-up piece with the pointer. The first event of a
+// drag picks up the piece under the point where the drag started.
+func (b *BoardWidget) Dragged(e *fyne.DragEvent) {
+	if b.OnMove == nil {
+		return
+	}
+	if !b.dragging {
+		start := e.Position.Subtract(e.Dragged)
+		row, col, ok := b.squareAt(start)
+		if !ok {
+			return
+		}
+		b.mu.Lock()
+		pt, set := b.pieces[row][col], b.pieceSet
+		b.mu.Unlock()
+		if pt == NoPieceType {
+			return
+		}
+
+		b.dragging = true
+		b.dragFromRow, b.dragFromCol = row, col
+		b.pieceImgs[row][col].Hidden = true
+		b.pieceImgs[row][col].Refresh()
+		b.dragImg.Resource = set.Resource(pt)
+		b.dragImg.Hidden = false
+	}
+
+	b.dragLast = e.Position
+	b.mu.Lock()
+	size := b.geom.square
+	b.mu.Unlock()
+	b.dragImg.Resize(fyne.NewSize(size, size))
+	b.dragImg.Move(e.Position.Subtract(fyne.NewPos(size/2, size/2)))
+	b.dragImg.Refresh()
+}
+
+// DragEnd drops the piece and reports the move through OnMove. The piece
+// goes back to its square until the caller updates the board.
+func (b *BoardWidget) DragEnd() {
+	if !b.dragging {
+		return
+	}
+	b.dragging = false
+	b.dragImg.Hidden = true
+	b.dragImg.Refresh()
+
+	fromRow, fromCol := b.dragFromRow, b.dragFromCol
+	b.pieceImgs[fromRow][fromCol].Hidden = false
+	b.pieceImgs[fromRow][fromCol].Refresh()
+
+	toRow, toCol, ok := b.squareAt(b.dragLast)
+	if !ok || (toRow == fromRow && toCol == fromCol) || b.OnMove == nil {
+		return
+	}
+	b.OnMove(fromRow, fromCol, toRow, toCol)
+}
+
+// Tapped reports a click on a square through OnTapped.
+func (b *BoardWidget) Tapped(e *fyne.PointEvent) {
+	if b.OnTapped == nil {
+		return
+	}
+	if row, col, ok := b.squareAt(e.Position); ok {
+		b.OnTapped(row, col)
+	}
+}
+
+// TappedSecondary reports a right click on a square through
+// OnSecondaryTapped.
+func (b *BoardWidget) TappedSecondary(e *fyne.PointEvent) {
+	if b.OnSecondaryTapped == nil {
+		return
+	}
+	if row, col, ok := b.squareAt(e.Position); ok {
+		b.OnSecondaryTapped(row, col)
+	}
+}
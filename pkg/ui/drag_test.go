@@ -0,0 +1,31 @@
+package ui
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+)
+
+func TestSquareAt(t *testing.T) {
+	tests := []struct {
+		flipped  bool
+		pos      fyne.Position
+		row, col int
+		ok       bool
+	}{
+		{false, fyne.NewPos(25, 25), 0, 0, true},   // a8
+		{false, fyne.NewPos(25, 375), 7, 0, true},  // a1
+		{true, fyne.NewPos(25, 25), 7, 7, true},    // h1 at top-left when flipped
+		{true, fyne.NewPos(375, 375), 0, 0, true},  // a8 at bottom-right
+		{false, fyne.NewPos(15, 25), 0, 0, false},  // left of the board
+		{false, fyne.NewPos(25, 430), 0, 0, false}, // below the board
+	}
+	for _, tt := range tests {
+		b := &BoardWidget{geom: boardGeometry{originX: 20, originY: 20, square: 50, flipped: tt.flipped}}
+		row, col, ok := b.squareAt(tt.pos)
+		if ok != tt.ok || (ok && (row != tt.row || col != tt.col)) {
+			t.Errorf("flipped=%v squareAt(%v) = (%d, %d, %v), want (%d, %d, %v)",
+				tt.flipped, tt.pos, row, col, ok, tt.row, tt.col, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package ui
+
+import (
+	"image/color"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/widget"
+)
+
+var (
+	graphBackground = color.NRGBA{R: 0x30, G: 0x30, B: 0x30, A: 0xff}
+	graphMidline    = color.NRGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xff}
+	graphLine       = color.NRGBA{R: 0xf0, G: 0xf0, B: 0xf0, A: 0xff}
+	graphMarker     = color.NRGBA{R: 0x00, G: 0x88, B: 0xff, A: 0xff}
+)
+
+// evalGraphClamp is the centipawn score drawn at the top/bottom edge of
+// the graph. Larger scores (including mates) are clamped to it.
+const evalGraphClamp = 1000
+
+// EvalGraph plots a game's evaluation move by move, from White's point of
+// view (White ahead = above the midline).
+type EvalGraph struct {
+	widget.BaseWidget
+
+	mu     sync.Mutex
+	evals  []int
+	marker int // index of the highlighted position (-1 = none)
+
+	// OnTapped is called with the index of the position nearest the tap.
+	OnTapped func(idx int)
+}
+
+// NewEvalGraph creates an empty evaluation graph.
+func NewEvalGraph() *EvalGraph {
+	g := &EvalGraph{marker: -1}
+	g.ExtendBaseWidget(g)
+	return g
+}
+
+// SetEvals replaces the plotted evaluations (one per position).
+func (g *EvalGraph) SetEvals(evals []int) {
+	g.mu.Lock()
+	g.evals = append([]int(nil), evals...)
+	g.mu.Unlock()
+	fyne.Do(g.Refresh)
+}
+
+// SetMarker highlights the position at idx with a vertical line.
+func (g *EvalGraph) SetMarker(idx int) {
+	g.mu.Lock()
+	g.marker = idx
+	g.mu.Unlock()
+	fyne.Do(g.Refresh)
+}
+
+// Tapped maps the tap's x coordinate to the nearest position index.
+func (g *EvalGraph) Tapped(e *fyne.PointEvent) {
+	g.mu.Lock()
+	n := len(g.evals)
+	g.mu.Unlock()
+	if g.OnTapped == nil || n < 2 {
+		return
+	}
+	step := g.Size().Width / float32(n-1)
+	idx := int(e.Position.X/step + 0.5)
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= n {
+		idx = n - 1
+	}
+	g.OnTapped(idx)
+}
+
+// CreateRenderer implements [fyne.Widget].
+func (g *EvalGraph) CreateRenderer() fyne.WidgetRenderer {
+	r := &evalGraphRenderer{
+		g:       g,
+		bg:      canvas.NewRectangle(graphBackground),
+		midline: canvas.NewLine(graphMidline),
+		marker:  canvas.NewLine(graphMarker),
+	}
+	r.marker.StrokeWidth = 2
+	return r
+}
+
+type evalGraphRenderer struct {
+	g       *EvalGraph
+	bg      *canvas.Rectangle
+	midline *canvas.Line
+	marker  *canvas.Line
+	lines   []*canvas.Line
+	size    fyne.Size
+}
+
+func (r *evalGraphRenderer) Destroy() {}
+
+func (r *evalGraphRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(100, 80)
+}
+
+func (r *evalGraphRenderer) Objects() []fyne.CanvasObject {
+	objects := []fyne.CanvasObject{r.bg, r.midline}
+	for _, l := range r.lines {
+		objects = append(objects, l)
+	}
+	return append(objects, r.marker)
+}
+
+func (r *evalGraphRenderer) Refresh() {
+	r.Layout(r.size)
+	canvas.Refresh(r.g)
+}
+
+func (r *evalGraphRenderer) Layout(size fyne.Size) {
+	r.size = size
+	r.bg.Resize(size)
+
+	mid := size.Height / 2
+	r.midline.Position1 = fyne.NewPos(0, mid)
+	r.midline.Position2 = fyne.NewPos(size.Width, mid)
+
+	r.g.mu.Lock()
+	evals := r.g.evals
+	marker := r.g.marker
+	r.g.mu.Unlock()
+
+	// y maps a White-POV score to a vertical position (White ahead = up).
+	y := func(cp int) float32 {
+		if cp > evalGraphClamp {
+			cp = evalGraphClamp
+		}
+		if cp < -evalGraphClamp {
+			cp = -evalGraphClamp
+		}
+		return mid - float32(cp)/evalGraphClamp*mid
+	}
+
+	// Grow or shrink the segment pool to match the number of moves.
+	want := len(evals) - 1
+	if want < 0 {
+		want = 0
+	}
+	for len(r.lines) < want {
+		l := canvas.NewLine(graphLine)
+		l.StrokeWidth = 2
+		r.lines = append(r.lines, l)
+	}
+	r.lines = r.lines[:want]
+
+	step := float32(0)
+	if len(evals) > 1 {
+		step = size.Width / float32(len(evals)-1)
+	}
+	for i, l := range r.lines {
+		l.Position1 = fyne.NewPos(float32(i)*step, y(evals[i]))
+		l.Position2 = fyne.NewPos(float32(i+1)*step, y(evals[i+1]))
+	}
+
+	if marker >= 0 && marker < len(evals) {
+		x := float32(marker) * step
+		r.marker.Position1 = fyne.NewPos(x, 0)
+		r.marker.Position2 = fyne.NewPos(x, size.Height)
+		r.marker.Hidden = false
+	} else {
+		r.marker.Hidden = true
+	}
+}
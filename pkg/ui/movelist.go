@@ -0,0 +1,175 @@
+package ui
+
+import (
+	"fmt"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// moveListMinWidth keeps the list wide enough for "99." plus two SAN moves.
+const moveListMinWidth = 200
+
+// MoveList is a scrollable two-column list of moves (White | Black) with
+// move numbers. Tapping a move reports its ply so the caller can preview
+// that position.
+type MoveList struct {
+	widget.BaseWidget
+
+	mu       sync.Mutex
+	moves    []string // SAN, in play order
+	startPly int      // plies before the first move, as chess.StartPly
+	selected int      // ply of the highlighted move (0 = none)
+
+	list *widget.List
+
+	// OnSelected is called with the ply of the tapped move within the
+	// game: 1 for the first move played, 2 for the reply, and so on.
+	OnSelected func(ply int)
+}
+
+// NewMoveList creates an empty move list.
+func NewMoveList() *MoveList {
+	l := &MoveList{}
+	l.ExtendBaseWidget(l)
+
+	l.list = widget.NewList(
+		func() int {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			return (l.startPly%2 + len(l.moves) + 1) / 2
+		},
+		func() fyne.CanvasObject {
+			return newMoveRow(l)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			l.mu.Lock()
+			moves, startPly, selected := l.moves, l.startPly, l.selected
+			l.mu.Unlock()
+			obj.(*moveRow).update(id, moves, startPly, selected)
+		},
+	)
+	return l
+}
+
+// SetMoves replaces the moves shown and scrolls to the latest one. startPly
+// is the number of plies before the first move, as chess.StartPly returns
+// for the game's starting position: 0 from the standard start, 1 when the
+// game begins with Black to move on move 1.
+func (l *MoveList) SetMoves(moves []string, startPly int) {
+	l.mu.Lock()
+	l.moves = append([]string(nil), moves...)
+	l.startPly = startPly
+	if l.selected > len(l.moves) {
+		l.selected = 0
+	}
+	l.mu.Unlock()
+	fyne.Do(func() {
+		l.list.Refresh()
+		l.list.ScrollToBottom()
+	})
+}
+
+// SetSelected highlights the move at ply (0 = none).
+func (l *MoveList) SetSelected(ply int) {
+	l.mu.Lock()
+	l.selected = ply
+	lead := l.startPly % 2
+	l.mu.Unlock()
+	fyne.Do(func() {
+		l.list.Refresh()
+		if ply > 0 {
+			l.list.ScrollTo((ply - 1 + lead) / 2)
+		}
+	})
+}
+
+func (l *MoveList) tapped(ply int) {
+	if l.OnSelected != nil {
+		l.OnSelected(ply)
+	}
+}
+
+func (l *MoveList) CreateRenderer() fyne.WidgetRenderer {
+	return &moveListRenderer{l: l}
+}
+
+type moveListRenderer struct {
+	l *MoveList
+}
+
+func (r *moveListRenderer) Destroy() {}
+
+func (r *moveListRenderer) Layout(size fyne.Size) {
+	r.l.list.Resize(size)
+}
+
+func (r *moveListRenderer) MinSize() fyne.Size {
+	min := r.l.list.MinSize()
+	if min.Width < moveListMinWidth {
+		min.Width = moveListMinWidth
+	}
+	return min
+}
+
+func (r *moveListRenderer) Objects() []fyne.CanvasObject {
+	return []fyne.CanvasObject{r.l.list}
+}
+
+func (r *moveListRenderer) Refresh() {
+	r.l.list.Refresh()
+}
+
+// moveRow is one full move: the move number and White's and Black's moves.
+type moveRow struct {
+	widget.BaseWidget
+
+	number       *widget.Label
+	white, black *widget.Button
+	whitePly     int
+	content      fyne.CanvasObject
+}
+
+func newMoveRow(l *MoveList) *moveRow {
+	r := &moveRow{number: widget.NewLabel("999.")}
+	r.white = widget.NewButton("", func() { l.tapped(r.whitePly) })
+	r.black = widget.NewButton("", func() { l.tapped(r.whitePly + 1) })
+	for _, b := range []*widget.Button{r.white, r.black} {
+		b.Alignment = widget.ButtonAlignLeading
+		b.Importance = widget.LowImportance
+	}
+	r.content = container.NewBorder(nil, nil, r.number, nil,
+		container.NewGridWithColumns(2, r.white, r.black))
+	r.ExtendBaseWidget(r)
+	return r
+}
+
+// update fills the row for the id'th full move shown (0-based). A game
+// starting with Black to move leaves the first row's White move blank.
+func (r *moveRow) update(id int, moves []string, startPly, selected int) {
+	r.whitePly = 2*id + 1 - startPly%2
+	r.number.SetText(fmt.Sprintf("%d.", startPly/2+id+1))
+
+	set := func(b *widget.Button, ply int) {
+		if ply < 1 || ply > len(moves) {
+			b.SetText("")
+			b.Disable()
+			return
+		}
+		b.Enable()
+		b.SetText(moves[ply-1])
+		b.Importance = widget.LowImportance
+		if ply == selected {
+			b.Importance = widget.HighImportance
+		}
+		b.Refresh()
+	}
+	set(r.white, r.whitePly)
+	set(r.black, r.whitePly+1)
+}
+
+func (r *moveRow) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(r.content)
+}
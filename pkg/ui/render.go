@@ -0,0 +1,183 @@
+package ui
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"path"
+	"sync"
+
+	"github.com/fyne-io/oksvg"
+	"github.com/srwiley/rasterx"
+)
+
+// Eval bar colours, matching the spectator page.
+var (
+	evalBarWhite = color.NRGBA{R: 0xf5, G: 0xf5, B: 0xf5, A: 0xff}
+	evalBarBlack = color.NRGBA{R: 0x40, G: 0x3d, B: 0x39, A: 0xff}
+)
+
+// BoardScene is everything RenderBoard draws, in board coordinates (row 0 =
+// rank 8, col 0 = file a) like BoardWidget.
+type BoardScene struct {
+	Pieces  [8][8]PieceType
+	Flipped bool // drawn from Black's side
+
+	// Last move highlight, shown if HasMove.
+	HasMove          bool
+	FromRow, FromCol int
+	ToRow, ToCol     int
+
+	// King in check, shown if InCheck.
+	InCheck            bool
+	CheckRow, CheckCol int
+
+	Invalid [][2]int // squares that differ from the game
+	Arrows  []Arrow
+	Circles []Circle
+
+	// Eval is the evaluation in centipawns from White's point of view,
+	// shown by the eval bar.
+	Eval int
+}
+
+// RenderOptions controls the size and look of a rendered board.
+type RenderOptions struct {
+	Width, Height int
+	Theme         BoardTheme
+	PieceSet      PieceSet
+	EvalBar       bool // draw an eval bar to the left of the board
+}
+
+// RenderBoard draws scene offscreen at the requested size, without a
+// Fyne window. The board is as large as fits, centred, and the rest of the
+// image is transparent.
+func RenderBoard(scene BoardScene, opts RenderOptions) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, opts.Width, opts.Height))
+
+	// With the bar, the content is the board plus a bar of 1/16 and a gap
+	// of 1/64 of its side.
+	side := min(opts.Width, opts.Height)
+	if opts.EvalBar {
+		side = min(opts.Height, opts.Width*64/69)
+	}
+	square := side / 8
+	side = square * 8
+	if square <= 0 {
+		return dst
+	}
+	bar, gap := 0, 0
+	if opts.EvalBar {
+		bar, gap = max(side/16, 1), max(side/64, 1)
+	}
+	x0 := (opts.Width-(bar+gap+side))/2 + bar + gap
+	y0 := (opts.Height - side) / 2
+
+	if opts.EvalBar {
+		drawEvalBar(dst, image.Rect(x0-gap-bar, y0, x0-gap, y0+side), scene.Eval, scene.Flipped)
+	}
+
+	sq := func(row, col int) image.Rectangle {
+		dRow, dCol := displayPos(row, col, scene.Flipped)
+		x, y := x0+dCol*square, y0+dRow*square
+		return image.Rect(x, y, x+square, y+square)
+	}
+	fill := func(r image.Rectangle, c color.Color) {
+		draw.Draw(dst, r, image.NewUniform(c), image.Point{}, draw.Over)
+	}
+
+	for row := 0; row < 8; row++ {
+		for col := 0; col < 8; col++ {
+			c := opts.Theme.Light
+			if (row+col)%2 == 1 {
+				c = opts.Theme.Dark
+			}
+			fill(sq(row, col), c)
+		}
+	}
+	if scene.HasMove {
+		fill(sq(scene.FromRow, scene.FromCol), opts.Theme.HighlightFrom)
+		fill(sq(scene.ToRow, scene.ToCol), opts.Theme.HighlightTo)
+	}
+	for _, s := range scene.Invalid {
+		fill(sq(s[0], s[1]), opts.Theme.HighlightInvalid)
+	}
+
+	inset := square / 20
+	for row := 0; row < 8; row++ {
+		for col := 0; col < 8; col++ {
+			img := opts.PieceSet.raster(scene.Pieces[row][col], square-2*inset)
+			if img == nil {
+				continue
+			}
+			r := sq(row, col).Inset(inset)
+			draw.Draw(dst, r, img, image.Point{}, draw.Over)
+		}
+	}
+	if scene.InCheck {
+		fill(sq(scene.CheckRow, scene.CheckCol), opts.Theme.HighlightCheck)
+	}
+
+	g := boardGeometry{originX: float32(x0), originY: float32(y0), square: float32(square), flipped: scene.Flipped}
+	drawAnnotations(dst, g, scene.Arrows, scene.Circles)
+	return dst
+}
+
+// drawEvalBar fills r with White's share of the evaluation, clamped like
+// the eval graph, growing from the side White plays from.
+func drawEvalBar(dst draw.Image, r image.Rectangle, cp int, flipped bool) {
+	cp = max(-evalGraphClamp, min(evalGraphClamp, cp))
+	white := r.Dy() * (cp + evalGraphClamp) / (2 * evalGraphClamp)
+	draw.Draw(dst, r, image.NewUniform(evalBarBlack), image.Point{}, draw.Src)
+	w := image.Rect(r.Min.X, r.Max.Y-white, r.Max.X, r.Max.Y)
+	if flipped {
+		w = image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+white)
+	}
+	draw.Draw(dst, w, image.NewUniform(evalBarWhite), image.Point{}, draw.Src)
+}
+
+// pieceRasterKey identifies a rasterised piece.
+type pieceRasterKey struct {
+	dir  string
+	pt   PieceType
+	size int
+}
+
+// pieceRasters caches pieces rasterised by RenderBoard. Overlays render at
+// one fixed size, so the cache stays small.
+var (
+	pieceRastersMu sync.Mutex
+	pieceRasters   = map[pieceRasterKey]*image.RGBA{}
+)
+
+// raster returns the piece drawn into a size x size image, or nil for
+// NoPieceType or an unreadable SVG.
+func (s PieceSet) raster(pt PieceType, size int) *image.RGBA {
+	filename, ok := pieceFiles[pt]
+	if !ok || size <= 0 {
+		return nil
+	}
+	key := pieceRasterKey{s.Dir, pt, size}
+
+	pieceRastersMu.Lock()
+	defer pieceRastersMu.Unlock()
+	if img, ok := pieceRasters[key]; ok {
+		return img
+	}
+
+	data, err := pieceFS.ReadFile(path.Join("pieces", s.Dir, filename))
+	if err != nil {
+		return nil
+	}
+	icon, err := oksvg.ReadIconStream(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	icon.SetTarget(0, 0, float64(size), float64(size))
+	scanner := rasterx.NewScannerGV(size, size, img, img.Bounds())
+	icon.Draw(rasterx.NewDasher(size, size, scanner), 1)
+	pieceRasters[key] = img
+	return img
+}
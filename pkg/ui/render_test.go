@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestRenderBoardTransparentMargins(t *testing.T) {
+	// 400x200: a 200 px board centred with 100 px of margin either side.
+	img := RenderBoard(BoardScene{}, RenderOptions{Width: 400, Height: 200, Theme: DefaultBoardTheme, PieceSet: DefaultPieceSet})
+	if a := img.RGBAAt(50, 100).A; a != 0 {
+		t.Errorf("margin alpha = %d, want transparent", a)
+	}
+	// a8 (light) is the top-left square.
+	if c := img.RGBAAt(112, 12); c != rgba(DefaultBoardTheme.Light) {
+		t.Errorf("a8 = %v, want %v", c, DefaultBoardTheme.Light)
+	}
+	if c := img.RGBAAt(137, 12); c != rgba(DefaultBoardTheme.Dark) {
+		t.Errorf("b8 = %v, want %v", c, DefaultBoardTheme.Dark)
+	}
+}
+
+func TestRenderBoardPiecesAndFlip(t *testing.T) {
+	scene := BoardScene{Pieces: StartingPosition()}
+	opts := RenderOptions{Width: 400, Height: 400, Theme: DefaultBoardTheme, PieceSet: DefaultPieceSet}
+
+	// The white king's centre, on e1 (bottom row) unless flipped.
+	img := RenderBoard(scene, opts)
+	if c := img.RGBAAt(4*50+25, 7*50+25); c == rgba(DefaultBoardTheme.Light) {
+		t.Error("no piece drawn on e1")
+	}
+	if c := img.RGBAAt(4*50+25, 4*50+25); c != rgba(DefaultBoardTheme.Light) {
+		t.Errorf("e4 = %v, want an empty light square", c)
+	}
+
+	scene.Flipped = true
+	img = RenderBoard(scene, opts)
+	if c := img.RGBAAt(3*50+25, 0*50+25); c == rgba(DefaultBoardTheme.Light) {
+		t.Error("flipped: no piece drawn on e1 at the top")
+	}
+}
+
+func TestRenderBoardEvalBar(t *testing.T) {
+	opts := RenderOptions{Width: 345, Height: 320, Theme: DefaultBoardTheme, PieceSet: DefaultPieceSet, EvalBar: true}
+	// side 320: bar 20 px, gap 5 px, board from x = 25.
+	for _, tc := range []struct {
+		eval    int
+		flipped bool
+		top     color.NRGBA
+	}{
+		{eval: 2000, top: evalBarWhite},
+		{eval: -2000, top: evalBarBlack},
+		{eval: -500, top: evalBarBlack},
+		{eval: 500, flipped: true, top: evalBarWhite},
+	} {
+		img := RenderBoard(BoardScene{Eval: tc.eval, Flipped: tc.flipped}, opts)
+		if c := img.RGBAAt(10, 5); c != rgba(tc.top) {
+			t.Errorf("eval %d flipped=%v: bar top = %v, want %v", tc.eval, tc.flipped, c, tc.top)
+		}
+		if a := img.RGBAAt(22, 5).A; a != 0 {
+			t.Errorf("gap alpha = %d, want transparent", a)
+		}
+	}
+}
+
+// rgba converts an opaque colour to the rendered image's pixel type.
+func rgba(c color.NRGBA) color.RGBA {
+	return color.RGBAModel.Convert(c).(color.RGBA)
+}
@@ -0,0 +1,41 @@
+package vision
+
+// Params tunes board warping and piece detection. The package-level
+// functions use DefaultParams; start from it rather than the zero value.
+// A config file's settings convert directly: vision.Params(cfg.Vision).
+type Params struct {
+	WarpSize    int     // side of the warped board image in pixels
+	SquareInset float64 // fraction of a square cropped from each edge
+
+	// A square is occupied if its greyscale stddev exceeds
+	// VarianceThreshold, its edge pixel percentage exceeds EdgeThreshold,
+	// or both exceed the combined minimums.
+	VarianceThreshold   float64
+	EdgeThreshold       float64
+	CombinedVarianceMin float64
+	CombinedEdgeMin     float64
+}
+
+// DefaultParams returns the parameters tuned for a wooden board on an
+// 800px warp.
+func DefaultParams() Params {
+	return Params{
+		WarpSize:            800,
+		SquareInset:         defaultSquareInset,
+		VarianceThreshold:   absVarianceThreshold,
+		EdgeThreshold:       absEdgeThreshold,
+		CombinedVarianceMin: absCombinedVarMin,
+		CombinedEdgeMin:     absCombinedEdgeMin,
+	}
+}
+
+// squareSize is the side of one square in the warped image.
+func (p Params) squareSize() int {
+	return p.WarpSize / 8
+}
+
+// occupied applies the detection thresholds to a square's signals.
+func (p Params) occupied(stdDev, edgePct float64) bool {
+	return stdDev > p.VarianceThreshold || edgePct > p.EdgeThreshold ||
+		(stdDev > p.CombinedVarianceMin && edgePct > p.CombinedEdgeMin)
+}
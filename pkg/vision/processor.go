@@ -197,6 +197,12 @@ func ReorderPoints(pts []image.Point) []image.Point {
 // WarpBoard creates a warp corrected gocv.Mat from a input gocv.Mat to remove
 // perspective distortion
 func WarpBoard(input gocv.Mat, corners []image.Point) gocv.Mat {
+	return DefaultParams().WarpBoard(input, corners)
+}
+
+// WarpBoard warps the board to a top-down square of p.WarpSize pixels.
+func (p Params) WarpBoard(input gocv.Mat, corners []image.Point) gocv.Mat {
+	size := p.WarpSize
 	sortedCorners := ReorderPoints(corners)
 
 	// Convert corners to float32 for OpenCV math
@@ -205,7 +211,7 @@ func WarpBoard(input gocv.Mat, corners []image.Point) gocv.Mat {
 
 	// Define target square
 	dest := gocv.NewPointVectorFromPoints([]image.Point{
-		{0, 0}, {800, 0}, {800, 800}, {0, 800},
+		{0, 0}, {size, 0}, {size, size}, {0, size},
 	})
 	defer dest.Close()
 
@@ -215,7 +221,7 @@ func WarpBoard(input gocv.Mat, corners []image.Point) gocv.Mat {
 
 	// Apply warp
 	warped := gocv.NewMat()
-	gocv.WarpPerspective(input, &warped, m, image.Pt(800, 800))
+	gocv.WarpPerspective(input, &warped, m, image.Pt(size, size))
 
 	return warped
 }
@@ -246,15 +252,21 @@ func CropAndRewarp(warped gocv.Mat, innerRect image.Rectangle) gocv.Mat {
 
 // DrawGrid draws an 8x8 grid across the board
 func DrawGrid(img *gocv.Mat) {
+	DefaultParams().DrawGrid(img)
+}
+
+// DrawGrid draws an 8x8 grid across a board warped with p.
+func (p Params) DrawGrid(img *gocv.Mat) {
 	white := color.RGBA{255, 255, 255, 0}
+	square := p.squareSize()
 
 	for i := 1; i < 9; i++ {
 		// Vertical lines
-		pos := i * 100
-		gocv.Line(img, image.Pt(pos, 0), image.Pt(pos, 800), white, 1)
+		pos := i * square
+		gocv.Line(img, image.Pt(pos, 0), image.Pt(pos, p.WarpSize), white, 1)
 
 		// Horizontal lines
-		gocv.Line(img, image.Pt(0, pos), image.Pt(800, pos), white, 1)
+		gocv.Line(img, image.Pt(0, pos), image.Pt(p.WarpSize, pos), white, 1)
 	}
 }
 
@@ -31,18 +31,27 @@ func IsSquareOccupied(live, ref gocv.Mat) (bool, float64) {
 	return percentage > 8.0, percentage
 }
 
-// squareInset is the number of pixels to crop from each edge of a 100x100
-// square. This extracts only the center region where the piece base sits,
-// avoiding bleed from neighbouring squares caused by piece height + perspective.
-const squareInset = 20
+// defaultSquareInset is the fraction of a square cropped from each edge
+// (20px of a 100x100 square). This extracts only the center region where the
+// piece base sits, avoiding bleed from neighbouring squares caused by piece
+// height + perspective.
+const defaultSquareInset = 0.2
 
 // GetSquare extracts the center region of a square based on chess coordinates (0-7).
 // col: 0=a, 4=e | row: 0=8, 7=1 (OpenCV Y starts from top).
 // Returns a 60x60 ROI (100 - 2*20 inset) to reduce neighbour bleed.
 func GetSquare(warped gocv.Mat, col, row int) gocv.Mat {
-	x := col*100 + squareInset
-	y := row*100 + squareInset
-	size := 100 - 2*squareInset
+	return DefaultParams().GetSquare(warped, col, row)
+}
+
+// GetSquare extracts the center region of a square of a board warped with
+// p, cropping p.SquareInset from each edge.
+func (p Params) GetSquare(warped gocv.Mat, col, row int) gocv.Mat {
+	square := p.squareSize()
+	inset := int(float64(square) * p.SquareInset)
+	x := col*square + inset
+	y := row*square + inset
+	size := square - 2*inset
 	rect := image.Rect(x, y, x+size, y+size)
 
 	return warped.Region(rect)
@@ -104,7 +113,7 @@ func ScanBoard(live, reference gocv.Mat) [8][8]bool {
 	return occupancy
 }
 
-// Detection thresholds for ScanBoardAbsolute.
+// Default detection thresholds, for ScanBoardAbsolute and DefaultParams.
 const (
 	// absVarianceThreshold is the minimum greyscale stddev to consider occupied.
 	// With CLAHE normalization, pieces typically have stddev 24-80+.
@@ -201,6 +210,12 @@ type SquareMetrics struct {
 // ScanBoardDebug returns the same occupancy grid as ScanBoardAbsolute
 // plus per-square metrics to help tune detection thresholds.
 func ScanBoardDebug(warped gocv.Mat) ([8][8]bool, [64]SquareMetrics) {
+	return DefaultParams().ScanBoardDebug(warped)
+}
+
+// ScanBoardDebug is ScanBoardDebug for a board warped with p, using p's
+// detection thresholds.
+func (p Params) ScanBoardDebug(warped gocv.Mat) ([8][8]bool, [64]SquareMetrics) {
 	var occupancy [8][8]bool
 	var metrics [64]SquareMetrics
 
@@ -229,18 +244,18 @@ func ScanBoardDebug(warped gocv.Mat) ([8][8]bool, [64]SquareMetrics) {
 
 	for row := 0; row < 8; row++ {
 		for col := 0; col < 8; col++ {
-			roiGrey := GetSquare(normalized, col, row)
+			roiGrey := p.GetSquare(normalized, col, row)
 			gocv.MeanStdDev(roiGrey, &mean, &stddev)
 			sd := stddev.GetDoubleAt(0, 0)
 			roiGrey.Close()
 
-			roiEdge := GetSquare(edges, col, row)
+			roiEdge := p.GetSquare(edges, col, row)
 			totalPixels := float64(roiEdge.Rows() * roiEdge.Cols())
 			edgePixels := float64(gocv.CountNonZero(roiEdge))
 			edgePct := (edgePixels / totalPixels) * 100
 			roiEdge.Close()
 
-			occupied := sd > absVarianceThreshold || edgePct > absEdgeThreshold || (sd > absCombinedVarMin && edgePct > absCombinedEdgeMin)
+			occupied := p.occupied(sd, edgePct)
 			occupancy[row][col] = occupied
 
 			idx := row*8 + col
@@ -260,6 +275,11 @@ func ScanBoardDebug(warped gocv.Mat) ([8][8]bool, [64]SquareMetrics) {
 // FormatMetrics returns a human-readable table of per-square detection metrics.
 // Squares that are borderline (close to thresholds) are marked with '!' .
 func FormatMetrics(metrics [64]SquareMetrics) string {
+	return DefaultParams().FormatMetrics(metrics)
+}
+
+// FormatMetrics is FormatMetrics against p's detection thresholds.
+func (p Params) FormatMetrics(metrics [64]SquareMetrics) string {
 	s := "  a       b       c       d       e       f       g       h\n"
 	for row := 0; row < 8; row++ {
 		s += fmt.Sprintf("%d ", 8-row)
@@ -270,8 +290,8 @@ func FormatMetrics(metrics [64]SquareMetrics) string {
 				marker = "X"
 			}
 			// Mark borderline squares (within 20% of any threshold)
-			if !m.Occupied && (m.StdDev > absVarianceThreshold*0.8 || m.EdgePct > absEdgeThreshold*0.8 ||
-				(m.StdDev > absCombinedVarMin*0.8 && m.EdgePct > absCombinedEdgeMin*0.8)) {
+			if !m.Occupied && (m.StdDev > p.VarianceThreshold*0.8 || m.EdgePct > p.EdgeThreshold*0.8 ||
+				(m.StdDev > p.CombinedVarianceMin*0.8 && m.EdgePct > p.CombinedEdgeMin*0.8)) {
 				marker = "!"
 			}
 			s += fmt.Sprintf("%s%2.0f/%1.0f ", marker, m.StdDev, m.EdgePct)
@@ -279,7 +299,7 @@ func FormatMetrics(metrics [64]SquareMetrics) string {
 		s += "\n"
 	}
 	s += fmt.Sprintf("Thresholds: var>%.0f edge>%.1f%%  Legend: X=occupied .=empty !=borderline\n",
-		absVarianceThreshold, absEdgeThreshold)
+		p.VarianceThreshold, p.EdgeThreshold)
 	return s
 }
 
@@ -287,6 +307,11 @@ func FormatMetrics(metrics [64]SquareMetrics) string {
 // region of each square. Used to distinguish white pieces from black pieces
 // when occupancy-based move inference is ambiguous.
 func ScanBrightness(warped gocv.Mat) [8][8]float64 {
+	return DefaultParams().ScanBrightness(warped)
+}
+
+// ScanBrightness is ScanBrightness for a board warped with p.
+func (p Params) ScanBrightness(warped gocv.Mat) [8][8]float64 {
 	var brightness [8][8]float64
 
 	grey := gocv.NewMat()
@@ -300,7 +325,7 @@ func ScanBrightness(warped gocv.Mat) [8][8]float64 {
 
 	for row := 0; row < 8; row++ {
 		for col := 0; col < 8; col++ {
-			roi := GetSquare(grey, col, row)
+			roi := p.GetSquare(grey, col, row)
 			gocv.MeanStdDev(roi, &mean, &stddev)
 			brightness[row][col] = mean.GetDoubleAt(0, 0)
 			roi.Close()
@@ -311,14 +336,21 @@ func ScanBrightness(warped gocv.Mat) [8][8]float64 {
 
 // DrawOccupancy draws a semi-transparent green rectangle on each occupied square.
 func DrawOccupancy(img *gocv.Mat, occupancy [8][8]bool) {
+	DefaultParams().DrawOccupancy(img, occupancy)
+}
+
+// DrawOccupancy is DrawOccupancy for a board warped with p.
+func (p Params) DrawOccupancy(img *gocv.Mat, occupancy [8][8]bool) {
 	green := color.RGBA{0, 200, 0, 0}
+	square := p.squareSize()
+	margin := square / 20
 	for row := 0; row < 8; row++ {
 		for col := 0; col < 8; col++ {
 			if occupancy[row][col] {
-				x := col * 100
-				y := row * 100
-				pt1 := image.Pt(x+5, y+5)
-				pt2 := image.Pt(x+95, y+95)
+				x := col * square
+				y := row * square
+				pt1 := image.Pt(x+margin, y+margin)
+				pt2 := image.Pt(x+square-margin, y+square-margin)
 				gocv.Rectangle(img, image.Rectangle{Min: pt1, Max: pt2}, green, 3)
 			}
 		}
@@ -0,0 +1,242 @@
+package voice
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Command is an action that can be spoken.
+type Command int
+
+const (
+	None Command = iota
+	Hint
+	TakeBack
+	NewGame
+	Resign
+	Confirm // "yes" to a command awaiting confirmation
+	Cancel  // "no" to a command awaiting confirmation
+)
+
+func (c Command) String() string {
+	switch c {
+	case Hint:
+		return "hint"
+	case TakeBack:
+		return "take back"
+	case NewGame:
+		return "new game"
+	case Resign:
+		return "resign"
+	case Confirm:
+		return "confirm"
+	case Cancel:
+		return "cancel"
+	default:
+		return "none"
+	}
+}
+
+// phrases maps each command to the phrases that trigger it. Longer phrases
+// come first so "new game" is not mistaken for another command's keyword.
+var phrases = []struct {
+	command Command
+	phrases []string
+}{
+	{TakeBack, []string{"take back", "takeback", "undo"}},
+	{NewGame, []string{"new game", "start game", "restart"}},
+	{Resign, []string{"i give up", "resign"}},
+	{Hint, []string{"help me", "hint"}},
+	{Confirm, []string{"yes", "confirm"}},
+	{Cancel, []string{"no", "cancel"}},
+}
+
+// NeedsConfirmation reports whether c ends the game in progress, and so
+// runs only once confirmed.
+func (c Command) NeedsConfirmation() bool {
+	return c == NewGame || c == Resign
+}
+
+// ConfirmTimeout is how long a command waits for its confirmation.
+const ConfirmTimeout = 5 * time.Second
+
+// Confirmer holds back commands that need confirmation until "yes" or
+// "confirm" follows within ConfirmTimeout, so a misheard word cannot end a
+// game. The zero value is ready to use.
+type Confirmer struct {
+	now func() time.Time // time.Now if nil
+
+	mu       sync.Mutex
+	pending  Command
+	deadline time.Time
+}
+
+// Resolve returns the command to run now that c has been heard, None if
+// there is none. A command needing confirmation returns None and waits; a
+// Confirm in time returns it, while Cancel or any other command drops it.
+func (cf *Confirmer) Resolve(c Command) Command {
+	now := time.Now
+	if cf.now != nil {
+		now = cf.now
+	}
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
+	pending, inTime := cf.pending, now().Before(cf.deadline)
+	cf.pending = None
+	switch {
+	case c.NeedsConfirmation():
+		cf.pending, cf.deadline = c, now().Add(ConfirmTimeout)
+		return None
+	case c == Confirm && inTime:
+		return pending
+	case c == Confirm, c == Cancel:
+		return None
+	default:
+		return c
+	}
+}
+
+// Phrases returns every phrase Parse recognises, for recognizers that accept
+// a grammar.
+func Phrases() []string {
+	var all []string
+	for _, p := range phrases {
+		all = append(all, p.phrases...)
+	}
+	return all
+}
+
+// Parse spots a command in a transcript such as "OK, take back please".
+// Case and punctuation are ignored and phrases must match whole words.
+func Parse(transcript string) Command {
+	text := " " + normalize(transcript) + " "
+	for _, p := range phrases {
+		for _, phrase := range p.phrases {
+			if strings.Contains(text, " "+phrase+" ") {
+				return p.command
+			}
+		}
+	}
+	return None
+}
+
+// normalize lowercases s and replaces punctuation with single spaces.
+func normalize(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		if r == '\'' {
+			return -1 // "let's" → "lets"
+		}
+		return ' '
+	}, s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// Recognizer turns speech into text.
+type Recognizer interface {
+	// Name identifies the recognizer, e.g. "nayan-listen".
+	Name() string
+	// Run listens until ctx is cancelled or the recognizer fails, calling
+	// heard with each transcript.
+	Run(ctx context.Context, heard func(text string)) error
+}
+
+// Listen runs r and calls handle with each recognised command and the
+// transcript it came from. Transcripts without a command are ignored.
+func Listen(ctx context.Context, r Recognizer, handle func(Command, string)) error {
+	err := r.Run(ctx, func(text string) {
+		if c := Parse(text); c != None {
+			handle(c, text)
+		}
+	})
+	if ctx.Err() != nil {
+		return nil // stopped
+	}
+	return err
+}
+
+// CommandRecognizer runs an external program that prints one transcript per
+// line, such as scripts/nayan-listen.py, which wraps the offline Vosk
+// recognizer.
+type CommandRecognizer struct {
+	Args []string // program and arguments
+}
+
+// DefaultCommand is the recognizer program looked up on PATH.
+const DefaultCommand = "nayan-listen"
+
+// ErrNoRecognizer is returned by Detect when no recognizer is installed.
+var ErrNoRecognizer = errors.New("voice: " + DefaultCommand + " not found on PATH")
+
+// Detect returns a recognizer running DefaultCommand, or ErrNoRecognizer if
+// it is not installed.
+func Detect() (Recognizer, error) {
+	if _, err := exec.LookPath(DefaultCommand); err != nil {
+		return nil, ErrNoRecognizer
+	}
+	return &CommandRecognizer{Args: []string{DefaultCommand}}, nil
+}
+
+func (r *CommandRecognizer) Name() string { return r.Args[0] }
+
+func (r *CommandRecognizer) Run(ctx context.Context, heard func(text string)) error {
+	cmd := exec.CommandContext(ctx, r.Args[0], r.Args[1:]...)
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		if text := strings.TrimSpace(scanner.Text()); text != "" {
+			heard(text)
+		}
+	}
+	if err := cmd.Wait(); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("%s: %w", r.Name(), err)
+	}
+	return nil
+}
+
+// Stub is a recognizer whose transcripts come from Say, for tests and for
+// typing commands instead of speaking them.
+type Stub struct {
+	once  sync.Once
+	heard chan string
+}
+
+func (s *Stub) init() {
+	s.once.Do(func() { s.heard = make(chan string) })
+}
+
+func (s *Stub) Name() string { return "stub" }
+
+// Say delivers text to the running recognizer, blocking until Run has
+// passed it on.
+func (s *Stub) Say(text string) {
+	s.init()
+	s.heard <- text
+}
+
+func (s *Stub) Run(ctx context.Context, heard func(text string)) error {
+	s.init()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case text := <-s.heard:
+			heard(text)
+		}
+	}
+}
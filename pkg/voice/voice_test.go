@@ -0,0 +1,118 @@
+package voice
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text string
+		want Command
+	}{
+		{"hint", Hint},
+		{"Give me a hint, please!", Hint},
+		{"help me", Hint},
+		{"take back", TakeBack},
+		{"Takeback.", TakeBack},
+		{"undo that", TakeBack},
+		{"new game", NewGame},
+		{"Let's start game", NewGame},
+		{"restart", NewGame},
+		{"I resign", Resign},
+		{"i give up", Resign},
+		{"Yes, do it", Confirm},
+		{"confirm", Confirm},
+		{"no", Cancel},
+		{"No, undo that", TakeBack}, // commands come before answers
+		{"", None},
+		{"knight to f three", None},
+		{"hints", None},      // whole words only
+		{"unresigned", None}, // whole words only
+	}
+	for _, tt := range tests {
+		if got := Parse(tt.text); got != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestPhrasesParse(t *testing.T) {
+	for _, p := range Phrases() {
+		if Parse(p) == None {
+			t.Errorf("Parse(%q) = none, want a command", p)
+		}
+	}
+}
+
+func TestConfirmer(t *testing.T) {
+	now := time.Unix(0, 0)
+	cf := &Confirmer{now: func() time.Time { return now }}
+	steps := []struct {
+		heard Command
+		after time.Duration // since the previous step
+		want  Command
+	}{
+		{Hint, 0, Hint},
+		{Confirm, 0, None}, // nothing to confirm
+		{Resign, 0, None},
+		{Confirm, time.Second, Resign},
+		{Confirm, 0, None}, // already run
+		{NewGame, 0, None},
+		{Confirm, ConfirmTimeout, None}, // too late
+		{Resign, 0, None},
+		{Cancel, 0, None},
+		{Confirm, 0, None}, // cancelled
+		{NewGame, 0, None},
+		{TakeBack, 0, TakeBack}, // drops the new game
+		{Confirm, 0, None},
+	}
+	for i, s := range steps {
+		now = now.Add(s.after)
+		if got := cf.Resolve(s.heard); got != s.want {
+			t.Errorf("step %d: Resolve(%s) = %s, want %s", i, s.heard, got, s.want)
+		}
+	}
+}
+
+func TestListenStub(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stub := &Stub{}
+	got := make(chan Command, 3)
+	done := make(chan error)
+	go func() {
+		done <- Listen(ctx, stub, func(c Command, _ string) { got <- c })
+	}()
+
+	stub.Say("hint")
+	stub.Say("what a move") // no command
+	stub.Say("take back")
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Listen returned %v", err)
+	}
+	close(got)
+
+	var commands []Command
+	for c := range got {
+		commands = append(commands, c)
+	}
+	if len(commands) != 2 || commands[0] != Hint || commands[1] != TakeBack {
+		t.Errorf("commands = %v, want [hint take back]", commands)
+	}
+}
+
+func TestCommandRecognizer(t *testing.T) {
+	r := &CommandRecognizer{Args: []string{"sh", "-c", `printf 'new game\n\nI resign\n'`}}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var commands []Command
+	if err := Listen(ctx, r, func(c Command, _ string) { commands = append(commands, c) }); err != nil {
+		t.Fatalf("Listen returned %v", err)
+	}
+	if len(commands) != 2 || commands[0] != NewGame || commands[1] != Resign {
+		t.Errorf("commands = %v, want [new game resign]", commands)
+	}
+}
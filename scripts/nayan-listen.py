@@ -0,0 +1,64 @@
+#!/usr/bin/env python3
+"""Offline speech recognizer for Nayan's voice commands.
+
+Listens on the default microphone with Vosk and prints one transcript per
+line on stdout, which Nayan parses for commands. Recognition is restricted
+to the command phrases, which makes it fast and accurate even with the
+small models.
+
+Setup:
+    pip install vosk sounddevice
+    # download and unpack a model, e.g. vosk-model-small-en-us-0.15, from
+    # https://alphacephei.com/vosk/models
+    export NAYAN_VOSK_MODEL=/path/to/vosk-model-small-en-us-0.15
+    install -m 755 scripts/nayan-listen.py ~/.local/bin/nayan-listen
+"""
+
+import json
+import os
+import queue
+import sys
+
+import sounddevice as sd
+from vosk import KaldiRecognizer, Model
+
+# Keep in sync with the phrases in pkg/voice.
+PHRASES = [
+    "take back", "takeback", "undo",
+    "new game", "start game", "restart",
+    "i give up", "resign",
+    "help me", "hint",
+    "yes", "confirm",
+    "no", "cancel",
+]
+
+SAMPLE_RATE = 16000
+
+
+def main():
+    model_path = os.environ.get("NAYAN_VOSK_MODEL")
+    if not model_path:
+        sys.exit("nayan-listen: set NAYAN_VOSK_MODEL to a Vosk model directory")
+    model = Model(model_path)
+    recognizer = KaldiRecognizer(model, SAMPLE_RATE, json.dumps(PHRASES + ["[unk]"]))
+
+    audio = queue.Queue()
+
+    def callback(data, frames, time, status):
+        audio.put(bytes(data))
+
+    with sd.RawInputStream(samplerate=SAMPLE_RATE, blocksize=8000, dtype="int16",
+                           channels=1, callback=callback):
+        while True:
+            if recognizer.AcceptWaveform(audio.get()):
+                text = json.loads(recognizer.Result()).get("text", "")
+                text = text.replace("[unk]", "").strip()
+                if text:
+                    print(text, flush=True)
+
+
+if __name__ == "__main__":
+    try:
+        main()
+    except KeyboardInterrupt:
+        pass